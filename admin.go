@@ -0,0 +1,380 @@
+package main
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/common/dependency"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"alle-task-manager-gunish/internal/common/tracing"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+)
+
+const exportBatchSize = 500
+
+func migrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Create or update the database schema, even when DB_AUTO_MIGRATE is false",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := config.LoadConfig()
+			cfg.Database.AutoMigrate = false
+			db, err := database.NewDatabase(cmd.Context(), cfg.Database)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return db.Migrate()
+		},
+	}
+}
+
+func seedCommand() *cobra.Command {
+	var count int
+	var seed uint64
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Create fake tasks for development and load testing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if count < 1 {
+				return errors.New("--count must be at least 1")
+			}
+			if !cmd.Flags().Changed("seed") {
+				seed = rand.Uint64()
+			}
+			return withContainer(cmd.Context(), config.LoadConfig(), func(c *dependency.Container) error {
+				created, err := seedTasks(cmd.Context(), c.TaskService(), count, rand.New(rand.NewPCG(seed, seed)))
+				loggingtype.GetLogger().Info("Seeded tasks", "count", created, "seed", seed)
+				return err
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&count, "count", "n", 50, "number of tasks to create")
+	cmd.Flags().Uint64Var(&seed, "seed", 0, "random seed, to create the same tasks again")
+	return cmd
+}
+
+var (
+	seedVerbs = []string{"Write", "Review", "Fix", "Plan", "Test", "Deploy", "Document", "Refactor", "Triage", "Estimate"}
+	seedNouns = []string{"release notes", "login page", "billing report", "API docs", "onboarding flow", "search index",
+		"backup job", "dashboard", "sprint board", "invoice export"}
+)
+
+// seedTasks : Goes through the task service, so seeded tasks publish events and are event sourced like real ones.
+func seedTasks(ctx context.Context, tasks *service.TaskService, count int, random *rand.Rand) (int, error) {
+	statuses := []string{string(model.Pending), string(model.InProgress), string(model.Completed)}
+	for i := 0; i < count; i++ {
+		input := service.CreateTaskInput{
+			Title:       fmt.Sprintf("%s %s", seedVerbs[random.IntN(len(seedVerbs))], seedNouns[random.IntN(len(seedNouns))]),
+			Description: fmt.Sprintf("Seeded task %d", i+1),
+		}
+		if random.IntN(3) > 0 {
+			due := time.Now().Add(time.Duration(random.IntN(60)-10) * 24 * time.Hour).Truncate(24 * time.Hour)
+			input.DueDate = &due
+		}
+		task, err := tasks.CreateTask(ctx, input)
+		if err != nil {
+			return i, err
+		}
+		if status := statuses[random.IntN(len(statuses))]; status != string(model.Pending) {
+			if _, err := tasks.UpdateTask(ctx, task.ID, service.UpdateTaskInput{Status: &status}); err != nil {
+				return i, err
+			}
+		}
+	}
+	return count, nil
+}
+
+func exportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export FILE",
+		Short: "Write every task to FILE as JSON lines, - for stdout",
+		Long: `Write every task to FILE as one JSON object per line, in the format of the REST API.
+Deleted tasks are not exported. Logs go to stdout, so prefer a file over -.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(cmd.Context(), config.LoadConfig(), func(c *dependency.Container) error {
+				out, closeOut, err := openOutput(args[0])
+				if err != nil {
+					return err
+				}
+				exported, err := exportTasks(cmd.Context(), c, out)
+				if closeErr := closeOut(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					return err
+				}
+				loggingtype.GetLogger().Info("Exported tasks", "count", exported, "file", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+// exportTasks : Pages through the tasks in change sequence order, so tasks written during the export are
+// either included once or not at all.
+func exportTasks(ctx context.Context, c *dependency.Container, out io.Writer) (int, error) {
+	encoder := json.NewEncoder(out)
+	exported := 0
+	var since int64
+	for {
+		tasks, err := c.TaskRepository().ListChangedSince(ctx, since, exportBatchSize, false)
+		if err != nil {
+			return exported, err
+		}
+		for _, task := range tasks {
+			if err := encoder.Encode(task); err != nil {
+				return exported, err
+			}
+			since = task.ChangeSeq
+			exported++
+		}
+		if len(tasks) < exportBatchSize {
+			return exported, nil
+		}
+	}
+}
+
+func importCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import FILE",
+		Short: "Create the tasks of an export, - for stdin",
+		Long: `Create the tasks written by export, keeping their IDs, statuses and timestamps. Tasks whose ID
+already exists are skipped, so an interrupted import can be run again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := io.Reader(os.Stdin)
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+			return withContainer(cmd.Context(), config.LoadConfig(), func(c *dependency.Container) error {
+				imported, skipped, err := importTasks(cmd.Context(), c.TaskService(), in)
+				loggingtype.GetLogger().Info("Imported tasks", "count", imported, "skipped", skipped, "file", args[0])
+				return err
+			})
+		},
+	}
+}
+
+func importTasks(ctx context.Context, tasks *service.TaskService, in io.Reader) (int, int, error) {
+	decoder := json.NewDecoder(in)
+	imported, skipped := 0, 0
+	for n := 1; ; n++ {
+		var task model.Task
+		if err := decoder.Decode(&task); err != nil {
+			if errors.Is(err, io.EOF) {
+				return imported, skipped, nil
+			}
+			return imported, skipped, fmt.Errorf("reading task %d: %w", n, err)
+		}
+		err := tasks.ImportTask(ctx, &task)
+		switch {
+		case err == nil:
+			imported++
+		case errors.Is(err, apperrors.ErrDuplicateEntity):
+			skipped++
+		default:
+			return imported, skipped, fmt.Errorf("importing task %d (%s): %w", n, task.ID, err)
+		}
+	}
+}
+
+func purgeTrashCommand() *cobra.Command {
+	var olderThan time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "purge-trash",
+		Short: "Permanently remove deleted tasks",
+		Long: `Permanently remove tasks that were deleted more than --older-than ago. Delta sync clients that have
+not synced since before a purged deletion get a 400 and have to sync again without a token.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withContainer(cmd.Context(), config.LoadConfig(), func(c *dependency.Container) error {
+				purged, err := c.TaskService().PurgeTrash(cmd.Context(), olderThan)
+				if err != nil {
+					return err
+				}
+				loggingtype.GetLogger().Info("Purged deleted tasks", "count", purged, "older_than", olderThan)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "only purge tasks deleted longer ago than this")
+	return cmd
+}
+
+func rebuildProjectionsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rebuild-projections",
+		Short: "Rebuild the tasks table from the event store",
+		Long: `Replay the event store into the tasks table, replacing its contents. Requires
+EVENT_SOURCING_ENABLED=true.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withContainer(cmd.Context(), config.LoadConfig(), func(c *dependency.Container) error {
+				projections := c.TaskProjectionService()
+				if projections == nil {
+					return errors.New("event sourcing is not enabled, set EVENT_SOURCING_ENABLED=true")
+				}
+				count, err := projections.Rebuild(cmd.Context())
+				if err != nil {
+					return err
+				}
+				loggingtype.GetLogger().Info("Rebuilt task projections", "tasks", count)
+				return nil
+			})
+		},
+	}
+}
+
+func checkConfigCommand() *cobra.Command {
+	var connect bool
+
+	cmd := &cobra.Command{
+		Use:   "check-config",
+		Short: "Validate the configuration and print it with secrets redacted",
+		Long: `Validate the configuration read from the environment and print the effective values, with API keys
+redacted. With --connect it also opens the database and connects to the event bus. Exits with status 1 when
+there are problems.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := config.LoadConfig()
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(configValues(reflect.ValueOf(redactConfig(cfg)))); err != nil {
+				return err
+			}
+
+			problems := []error{cfg.Validate()}
+			driver, err := eventbus.ParseDriver(cfg.EventBus.Driver)
+			problems = append(problems, err)
+			_, err = events.ParseMode(cfg.Kafka.CloudEventsMode)
+			problems = append(problems, err)
+			_, err = tracing.ParseExporter(cfg.Tracing.Exporter)
+			problems = append(problems, err)
+			_, err = loggingtype.ParseLevel(cfg.Logging.Level)
+			problems = append(problems, err)
+			_, err = loggingtype.ParseFormat(cfg.Logging.Format)
+			problems = append(problems, err)
+			_, err = loggingtype.ParsePackageLevels(cfg.Logging.PackageLevels)
+			problems = append(problems, err)
+			_, err = loggingtype.ParseRedactFields(cfg.Logging.RedactFields)
+			problems = append(problems, err)
+			_, err = ratelimit.ParseRules(cfg.RateLimit.Default, cfg.RateLimit.Routes)
+			problems = append(problems, err)
+			_, err = ratelimit.ParseStore(cfg.RateLimit.Store)
+			problems = append(problems, err)
+			if connect && errors.Join(problems...) == nil {
+				problems = append(problems, withContainer(cmd.Context(), cfg, func(c *dependency.Container) error {
+					if c.EventBusDriver() != driver {
+						return fmt.Errorf("could not connect to the %s event bus, the service would run with event delivery disabled", driver)
+					}
+					return nil
+				}))
+			}
+			if err := errors.Join(problems...); err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err)
+				return errors.New("configuration is invalid")
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "Configuration is valid")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&connect, "connect", false, "also connect to the database and event bus")
+	return cmd
+}
+
+func redactAll(secrets []string) []string {
+	redacted := make([]string, len(secrets))
+	for i := range redacted {
+		redacted[i] = "<redacted>"
+	}
+	return redacted
+}
+
+func redactConfig(cfg *config.Config) config.Config {
+	redacted := *cfg
+	redacted.Auth.APIKeys = redactAll(cfg.Auth.APIKeys)
+	redacted.Auth.AdminAPIKeys = redactAll(cfg.Auth.AdminAPIKeys)
+	if redacted.Database.DSN != "" {
+		redacted.Database.DSN = "<redacted>"
+	}
+	return redacted
+}
+
+// configValues : The exported config fields as nested maps, with durations written like 10s instead of in
+// nanoseconds. Credentials in URLs, such as redis://:password@host, are removed from every string setting.
+func configValues(value reflect.Value) interface{} {
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return withoutUserinfo(value.String())
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.String {
+			values := make([]string, value.Len())
+			for i := range values {
+				values[i] = withoutUserinfo(value.Index(i).String())
+			}
+			return values
+		}
+	}
+	if value.Kind() != reflect.Struct {
+		return value.Interface()
+	}
+	fields := make(map[string]interface{}, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Type().Field(i); field.IsExported() {
+			fields[field.Name] = configValues(value.Field(i))
+		}
+	}
+	return fields
+}
+
+// withoutUserinfo : A URL without its user and password; other values are returned as they are.
+func withoutUserinfo(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// openOutput : "-" is stdout, which is left open.
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "-" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// Profile : A named server and the API key used with it.
+type Profile struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token,omitempty"`
+}
+
+// Config : The taskctl config file.
+//
+//	current_profile: staging
+//	profiles:
+//	  staging:
+//	    server: https://tasks.staging.example.com
+//	    token: <api key>
+type Config struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// defaultConfigPath : TASKCTL_CONFIG, or taskctl/config.yaml in the user config directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("TASKCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "taskctl.yaml"
+	}
+	return filepath.Join(dir, "taskctl", "config.yaml")
+}
+
+// loadConfig : A missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: map[string]*Profile{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+	return config, nil
+}
+
+// save : The file holds API keys, so it is only readable by its owner.
+func (c *Config) save(path string) error {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o600)
+}
+
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
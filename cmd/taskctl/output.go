@@ -0,0 +1,121 @@
+package main
+
+import (
+	"alle-task-manager-gunish/pkg/client"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// printer : Writes results as a table, JSON or YAML. JSON and YAML use the API's field names.
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return &printer{format: format, out: out}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected table, json or yaml", format)
+	}
+}
+
+// encode : JSON is indented. YAML goes through JSON so it uses the same field names and time format.
+func (p *printer) encode(value interface{}) error {
+	if p.format == outputJSON {
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return err
+	}
+	return yaml.NewEncoder(p.out).Encode(generic)
+}
+
+func (p *printer) tasks(tasks []*client.Task) error {
+	if p.format != outputTable {
+		if tasks == nil {
+			tasks = []*client.Task{}
+		}
+		return p.encode(tasks)
+	}
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tSTATUS\tDUE\tUPDATED")
+	for _, task := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", task.ID, task.Title, task.Status, formatDue(task.DueDate), task.UpdatedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+func (p *printer) task(task *client.Task) error {
+	if p.format != outputTable {
+		return p.encode(task)
+	}
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", task.ID)
+	fmt.Fprintf(w, "Title:\t%s\n", task.Title)
+	fmt.Fprintf(w, "Description:\t%s\n", task.Description)
+	fmt.Fprintf(w, "Status:\t%s\n", task.Status)
+	fmt.Fprintf(w, "Due:\t%s\n", formatDue(task.DueDate))
+	fmt.Fprintf(w, "Created:\t%s\n", task.CreatedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Updated:\t%s\n", task.UpdatedAt.Local().Format(time.DateTime))
+	return w.Flush()
+}
+
+// event : One line per event in table format, and one document per event otherwise, so watch output can be
+// piped line by line (JSON) or as a YAML stream.
+func (p *printer) event(event *client.TaskEvent) error {
+	switch p.format {
+	case outputJSON:
+		return json.NewEncoder(p.out).Encode(eventOutput(event))
+	case outputYAML:
+		if _, err := fmt.Fprintln(p.out, "---"); err != nil {
+			return err
+		}
+		return p.encode(eventOutput(event))
+	}
+	if event.Type == client.EventReset {
+		_, err := fmt.Fprintln(p.out, "Missed events are no longer available, reload with 'taskctl list'")
+		return err
+	}
+	title, status := "", ""
+	if event.Task != nil {
+		title, status = event.Task.Title, string(event.Task.Status)
+	}
+	_, err := fmt.Fprintf(p.out, "%s  %-12s  %s  %-11s  %s\n", time.Now().Format(time.TimeOnly), event.Type, event.TaskID, status, title)
+	return err
+}
+
+func eventOutput(event *client.TaskEvent) interface{} {
+	return struct {
+		ID            string       `json:"id,omitempty"`
+		Type          string       `json:"type"`
+		TaskID        string       `json:"task_id,omitempty"`
+		ChangedFields []string     `json:"changed_fields,omitempty"`
+		Task          *client.Task `json:"task,omitempty"`
+	}{event.ID, event.Type, event.TaskID, event.ChangedFields, event.Task}
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return "-"
+	}
+	return due.Local().Format(time.DateOnly)
+}
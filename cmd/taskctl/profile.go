@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+)
+
+func (c *cli) profileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the server profiles in the config file",
+	}
+	cmd.AddCommand(c.profileListCommand(), c.profileSetCommand(), c.profileUseCommand(), c.profileDeleteCommand())
+	return cmd
+}
+
+func (c *cli) profileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles; the current one is marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			config, err := loadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tTOKEN")
+			for _, name := range config.profileNames() {
+				current, token := "", ""
+				if name == config.CurrentProfile {
+					current = "*"
+				}
+				if config.Profiles[name].Token != "" {
+					token = "set"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, config.Profiles[name].Server, token)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func (c *cli) profileSetCommand() *cobra.Command {
+	var server, token string
+
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Create or change a profile; the first profile becomes the current one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			profile, ok := config.Profiles[args[0]]
+			if !ok {
+				profile = &Profile{Server: defaultServer}
+				config.Profiles[args[0]] = profile
+			}
+			if cmd.Flags().Changed("server") {
+				profile.Server = server
+			}
+			if cmd.Flags().Changed("token") {
+				profile.Token = token
+			}
+			if config.CurrentProfile == "" {
+				config.CurrentProfile = args[0]
+			}
+			return config.save(c.configPath)
+		},
+	}
+	// Local flags shadow the global --server and --token, which select the server for a single command.
+	cmd.Flags().StringVar(&server, "server", "", "server URL")
+	cmd.Flags().StringVar(&token, "token", "", "API key")
+	return cmd
+}
+
+func (c *cli) profileUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "use NAME",
+		Short:             "Select the profile used by default",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: c.completeProfiles,
+		RunE: func(_ *cobra.Command, args []string) error {
+			config, err := loadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := config.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile %q not found in %s", args[0], c.configPath)
+			}
+			config.CurrentProfile = args[0]
+			return config.save(c.configPath)
+		},
+	}
+}
+
+func (c *cli) profileDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete NAME",
+		Short:             "Delete a profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: c.completeProfiles,
+		RunE: func(_ *cobra.Command, args []string) error {
+			config, err := loadConfig(c.configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := config.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile %q not found in %s", args[0], c.configPath)
+			}
+			delete(config.Profiles, args[0])
+			if config.CurrentProfile == args[0] {
+				config.CurrentProfile = ""
+			}
+			return config.save(c.configPath)
+		},
+	}
+}
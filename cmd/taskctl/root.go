@@ -0,0 +1,115 @@
+package main
+
+import (
+	"alle-task-manager-gunish/pkg/client"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+// cli : Global flags and the lazily built API client shared by the subcommands.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	profile    string
+	server     string
+	token      string
+	output     string
+}
+
+func newRootCommand(stdout, stderr io.Writer) *cobra.Command {
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	root := &cobra.Command{
+		Use:   "taskctl",
+		Short: "Manage tasks of the task management service",
+		Long: `taskctl talks to the /tasks REST API of the task management service.
+
+The server and API key come from --server and --token, then the TASKCTL_SERVER and TASKCTL_TOKEN
+environment variables, then the selected profile of the config file (see "taskctl profile").`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			_, err := newPrinter(c.output, stdout)
+			return err
+		},
+	}
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&c.configPath, "config", defaultConfigPath(), "config file")
+	flags.StringVarP(&c.profile, "profile", "p", "", "profile to use instead of the current one")
+	flags.StringVar(&c.server, "server", "", "server URL, overriding the profile")
+	flags.StringVar(&c.token, "token", "", "API key, overriding the profile")
+	flags.StringVarP(&c.output, "output", "o", "table", "output format: table, json or yaml")
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = root.RegisterFlagCompletionFunc("profile", c.completeProfiles)
+
+	root.AddCommand(
+		c.listCommand(),
+		c.getCommand(),
+		c.createCommand(),
+		c.updateCommand(),
+		c.deleteCommand(),
+		c.completeCommand(),
+		c.watchCommand(),
+		c.profileCommand(),
+	)
+
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w\nRun '%s --help' for usage", err, cmd.CommandPath())
+	})
+	return root
+}
+
+// client : Builds the API client from flags, environment and profile, in that order of precedence.
+func (c *cli) client() (*client.Client, error) {
+	config, err := loadConfig(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server, token := defaultServer, ""
+	name := c.profile
+	if name == "" {
+		name = config.CurrentProfile
+	}
+	if name != "" {
+		profile, ok := config.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in %s", name, c.configPath)
+		}
+		server, token = profile.Server, profile.Token
+	}
+	if value := os.Getenv("TASKCTL_SERVER"); value != "" {
+		server = value
+	}
+	if value := os.Getenv("TASKCTL_TOKEN"); value != "" {
+		token = value
+	}
+	if c.server != "" {
+		server = c.server
+	}
+	if c.token != "" {
+		token = c.token
+	}
+
+	return client.New(server, client.WithAPIKey(token), client.WithUserAgent("taskctl"))
+}
+
+func (c *cli) printer() *printer {
+	p, _ := newPrinter(c.output, c.stdout)
+	return p
+}
+
+func (c *cli) completeProfiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	config, err := loadConfig(c.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return config.profileNames(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"alle-task-manager-gunish/internal/api/handler"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/router"
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"alle-task-manager-gunish/pkg/client"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "secret-key"
+
+// newTestServer : The real router and task service over a temporary SQLite database.
+func newTestServer(t *testing.T) string {
+	gin.SetMode(gin.TestMode)
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+
+	api := router.SetupRouter(nil, []gin.HandlerFunc{middleware.APIKeyAuth([]string{testAPIKey})},
+		handler.NewTaskHandler(taskService), handler.NewTaskStreamHandler(broadcaster, time.Minute))
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// run : Runs taskctl with a config file in a temporary directory and returns stdout and stderr.
+func run(t *testing.T, configPath string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	root := newRootCommand(&stdout, &stderr)
+	root.SetArgs(append([]string{"--config", configPath}, args...))
+	err := root.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func isolate(t *testing.T) string {
+	t.Setenv("TASKCTL_SERVER", "")
+	t.Setenv("TASKCTL_TOKEN", "")
+	return filepath.Join(t.TempDir(), "config.yaml")
+}
+
+func TestTaskctl_Tasks(t *testing.T) {
+	configPath := isolate(t)
+	server := newTestServer(t)
+	_, _, err := run(t, configPath, "profile", "set", "local", "--server", server, "--token", testAPIKey)
+	require.NoError(t, err)
+
+	stdout, _, err := run(t, configPath, "create", "--title", "Write docs", "--due", "2030-01-02", "-o", "json")
+	require.NoError(t, err)
+	var created client.Task
+	require.NoError(t, json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, "Write docs", created.Title)
+	assert.Equal(t, client.StatusPending, created.Status)
+	require.NotNil(t, created.DueDate)
+
+	_, _, err = run(t, configPath, "create", "--title", "Review PR")
+	require.NoError(t, err)
+
+	stdout, _, err = run(t, configPath, "update", created.ID, "--description", "API and CLI", "-o", "yaml")
+	require.NoError(t, err)
+	var updated map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(stdout), &updated))
+	assert.Equal(t, "API and CLI", updated["description"])
+	assert.Equal(t, "Write docs", updated["title"], "fields without a flag are left alone")
+
+	stdout, _, err = run(t, configPath, "complete", created.ID)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "completed")
+
+	stdout, _, err = run(t, configPath, "get", created.ID)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Description:  API and CLI")
+	assert.Contains(t, stdout, "Status:       completed")
+
+	stdout, _, err = run(t, configPath, "list")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+
+	stdout, _, err = run(t, configPath, "list", "--status", "pending", "--all", "-o", "json")
+	require.NoError(t, err)
+	var pending []client.Task
+	require.NoError(t, json.Unmarshal([]byte(stdout), &pending))
+	require.Len(t, pending, 1)
+	assert.Equal(t, "Review PR", pending[0].Title)
+
+	_, stderr, err := run(t, configPath, "delete", created.ID)
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "Deleted "+created.ID)
+
+	_, _, err = run(t, configPath, "get", created.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestTaskctl_Errors(t *testing.T) {
+	configPath := isolate(t)
+	server := newTestServer(t)
+
+	_, _, err := run(t, configPath, "--server", server, "list")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	_, _, err = run(t, configPath, "--server", server, "--token", testAPIKey, "list", "-o", "xml")
+	assert.ErrorContains(t, err, "unknown output format")
+
+	_, _, err = run(t, configPath, "--server", server, "--token", testAPIKey, "update", "some-id")
+	assert.ErrorContains(t, err, "nothing to update")
+
+	_, _, err = run(t, configPath, "--server", server, "--token", testAPIKey, "list", "--status", "done")
+	assert.ErrorContains(t, err, "invalid status")
+
+	_, _, err = run(t, configPath, "--profile", "missing", "list")
+	assert.ErrorContains(t, err, `profile "missing" not found`)
+}
+
+func TestTaskctl_Profiles(t *testing.T) {
+	configPath := isolate(t)
+
+	_, _, err := run(t, configPath, "profile", "set", "staging", "--server", "https://staging.example.com", "--token", "abc")
+	require.NoError(t, err)
+	_, _, err = run(t, configPath, "profile", "set", "prod", "--server", "https://prod.example.com")
+	require.NoError(t, err)
+
+	config, err := loadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", config.CurrentProfile, "the first profile becomes the current one")
+	assert.Equal(t, &Profile{Server: "https://prod.example.com"}, config.Profiles["prod"])
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, _, err = run(t, configPath, "profile", "use", "prod")
+	require.NoError(t, err)
+	stdout, _, err := run(t, configPath, "profile", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, `\*\s+prod\s+https://prod.example.com`, stdout)
+	assert.Regexp(t, `\n\s+staging\s+https://staging.example.com\s+set`, stdout)
+
+	_, _, err = run(t, configPath, "profile", "use", "dev")
+	assert.ErrorContains(t, err, "not found")
+
+	_, _, err = run(t, configPath, "profile", "delete", "prod")
+	require.NoError(t, err)
+	config, err = loadConfig(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, config.CurrentProfile)
+	assert.Equal(t, []string{"staging"}, config.profileNames())
+}
+
+func TestTaskctl_ClientPrecedence(t *testing.T) {
+	configPath := isolate(t)
+	_, _, err := run(t, configPath, "profile", "set", "staging", "--server", "https://staging.example.com", "--token", "abc")
+	require.NoError(t, err)
+
+	c := &cli{configPath: configPath}
+	_, err = c.client()
+	require.NoError(t, err)
+
+	t.Setenv("TASKCTL_SERVER", "://bad")
+	_, err = c.client()
+	assert.ErrorContains(t, err, "base URL", "the environment overrides the profile")
+
+	c.server = "http://localhost:9999"
+	_, err = c.client()
+	assert.NoError(t, err, "flags override the environment")
+}
+
+func TestTaskctl_Watch(t *testing.T) {
+	configPath := isolate(t)
+	server := newTestServer(t)
+
+	var stdout syncBuffer
+	var stderr bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	root := newRootCommand(&stdout, &stderr)
+	root.SetArgs([]string{"--config", configPath, "--server", server, "--token", testAPIKey, "watch", "-o", "json"})
+	done := make(chan error, 1)
+	go func() { done <- root.ExecuteContext(ctx) }()
+
+	api, err := client.New(server, client.WithAPIKey(testAPIKey))
+	require.NoError(t, err)
+	// The stream may not be subscribed yet, so keep creating tasks until one shows up.
+	require.Eventually(t, func() bool {
+		_, err := api.CreateTask(context.Background(), client.CreateTaskInput{Title: "Watched"})
+		require.NoError(t, err)
+		return strings.Contains(stdout.String(), client.EventTaskCreated)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	line, _, _ := strings.Cut(stdout.String(), "\n")
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &event))
+	assert.Equal(t, client.EventTaskCreated, event["type"])
+	assert.Equal(t, "Watched", event["task"].(map[string]interface{})["title"])
+}
+
+// syncBuffer : Output of a command running in another goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"alle-task-manager-gunish/pkg/client"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var statuses = []string{string(client.StatusPending), string(client.StatusInProgress), string(client.StatusCompleted)}
+
+func (c *cli) listCommand() *cobra.Command {
+	var status string
+	var page, pageSize int
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			taskStatus, err := parseStatus(status, true)
+			if err != nil {
+				return err
+			}
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			options := client.ListTasksOptions{Status: taskStatus, Page: page, PageSize: pageSize}
+
+			if !all {
+				result, err := api.ListTasks(cmd.Context(), options)
+				if err != nil {
+					return err
+				}
+				if err := c.printer().tasks(result.Tasks); err != nil {
+					return err
+				}
+				if c.output == outputTable && result.PageInfo.TotalPages > 1 {
+					fmt.Fprintf(c.stderr, "Page %d of %d (%d tasks), use --page or --all for more\n",
+						result.PageInfo.Page, result.PageInfo.TotalPages, result.PageInfo.TotalItems)
+				}
+				return nil
+			}
+
+			var tasks []*client.Task
+			for task, err := range api.AllTasks(cmd.Context(), options) {
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, task)
+			}
+			return c.printer().tasks(tasks)
+		},
+	}
+	cmd.Flags().StringVar(&status, "status", "", "only list tasks in this status")
+	cmd.Flags().IntVar(&page, "page", 1, "page to list")
+	cmd.Flags().IntVar(&pageSize, "page-size", 0, "tasks per page, the server default when 0")
+	cmd.Flags().BoolVar(&all, "all", false, "list every page")
+	_ = cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(statuses, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func (c *cli) getCommand() *cobra.Command {
+	var asOf string
+
+	cmd := &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			var task *client.Task
+			if asOf != "" {
+				at, err := parseTime(asOf)
+				if err != nil {
+					return fmt.Errorf("invalid --as-of: %w", err)
+				}
+				task, err = api.GetTaskAsOf(cmd.Context(), args[0], at)
+				if err != nil {
+					return err
+				}
+			} else {
+				task, err = api.GetTask(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+			}
+			return c.printer().task(task)
+		},
+	}
+	cmd.Flags().StringVar(&asOf, "as-of", "", "show the task as it was at this time (RFC 3339 or YYYY-MM-DD)")
+	return cmd
+}
+
+func (c *cli) createCommand() *cobra.Command {
+	var title, description, due string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a task",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			input := client.CreateTaskInput{Title: title, Description: description}
+			if due != "" {
+				dueDate, err := parseTime(due)
+				if err != nil {
+					return fmt.Errorf("invalid --due: %w", err)
+				}
+				input.DueDate = &dueDate
+			}
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			task, err := api.CreateTask(cmd.Context(), input)
+			if err != nil {
+				return err
+			}
+			return c.printer().task(task)
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "task title")
+	cmd.Flags().StringVar(&description, "description", "", "task description")
+	cmd.Flags().StringVar(&due, "due", "", "due date (RFC 3339 or YYYY-MM-DD)")
+	_ = cmd.MarkFlagRequired("title")
+	return cmd
+}
+
+func (c *cli) updateCommand() *cobra.Command {
+	var title, description, status, due string
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Change a task; only the given flags are changed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input client.UpdateTaskInput
+			flags := cmd.Flags()
+			if flags.Changed("title") {
+				input.Title = &title
+			}
+			if flags.Changed("description") {
+				input.Description = &description
+			}
+			if flags.Changed("status") {
+				taskStatus, err := parseStatus(status, false)
+				if err != nil {
+					return err
+				}
+				input.Status = &taskStatus
+			}
+			if flags.Changed("due") {
+				dueDate, err := parseTime(due)
+				if err != nil {
+					return fmt.Errorf("invalid --due: %w", err)
+				}
+				input.DueDate = &dueDate
+			}
+			if input == (client.UpdateTaskInput{}) {
+				return errors.New("nothing to update, pass at least one of --title, --description, --status or --due")
+			}
+
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			task, err := api.UpdateTask(cmd.Context(), args[0], input)
+			if err != nil {
+				return err
+			}
+			return c.printer().task(task)
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "new title")
+	cmd.Flags().StringVar(&description, "description", "", "new description")
+	cmd.Flags().StringVar(&status, "status", "", "new status")
+	cmd.Flags().StringVar(&due, "due", "", "new due date (RFC 3339 or YYYY-MM-DD)")
+	_ = cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(statuses, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func (c *cli) deleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID...",
+		Short: "Delete tasks",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if err := api.DeleteTask(cmd.Context(), id); err != nil {
+					return fmt.Errorf("deleting %s: %w", id, err)
+				}
+				fmt.Fprintf(c.stderr, "Deleted %s\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func (c *cli) completeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "complete ID...",
+		Short: "Mark tasks as completed",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			completed := client.StatusCompleted
+			tasks := make([]*client.Task, 0, len(args))
+			for _, id := range args {
+				task, err := api.UpdateTask(cmd.Context(), id, client.UpdateTaskInput{Status: &completed})
+				if err != nil {
+					return fmt.Errorf("completing %s: %w", id, err)
+				}
+				tasks = append(tasks, task)
+			}
+			return c.printer().tasks(tasks)
+		},
+	}
+}
+
+func (c *cli) watchCommand() *cobra.Command {
+	var status, lastEventID string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream task changes until interrupted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			taskStatus, err := parseStatus(status, true)
+			if err != nil {
+				return err
+			}
+			api, err := c.client()
+			if err != nil {
+				return err
+			}
+			p := c.printer()
+			for event, err := range api.WatchTasks(cmd.Context(), client.WatchOptions{Status: taskStatus, LastEventID: lastEventID}) {
+				if err != nil {
+					if cmd.Context().Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if err := p.event(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&status, "status", "", "only show changes to tasks in, or leaving, this status")
+	cmd.Flags().StringVar(&lastEventID, "last-event-id", "", "resume after this event ID")
+	_ = cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(statuses, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func parseStatus(value string, allowEmpty bool) (client.TaskStatus, error) {
+	if value == "" && allowEmpty {
+		return "", nil
+	}
+	for _, status := range statuses {
+		if value == status {
+			return client.TaskStatus(value), nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q, expected pending, in_progress or completed", value)
+}
+
+// parseTime : Accepts RFC 3339 timestamps and plain dates, which are taken as midnight local time.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time or YYYY-MM-DD date", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,168 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphapi
+
+import (
+	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/domain/model"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type CreateTaskInput struct {
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
+
+type Subscription struct {
+}
+
+type TaskChange struct {
+	EventID   string        `json:"eventId"`
+	EventType TaskEventType `json:"eventType"`
+	TaskID    string        `json:"taskId"`
+	// The task after the change, or as it was when deleted. Created events only carry the title, description and status.
+	Task          *model.Task `json:"task"`
+	ChangedFields []string    `json:"changedFields"`
+}
+
+type TaskFilter struct {
+	Status *TaskStatus `json:"status,omitempty"`
+}
+
+type TaskPage struct {
+	Tasks    []*model.Task        `json:"tasks"`
+	PageInfo *pagination.PageInfo `json:"pageInfo"`
+}
+
+// Only the fields that are set are changed.
+type UpdateTaskInput struct {
+	Title       *string     `json:"title,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Status      *TaskStatus `json:"status,omitempty"`
+	DueDate     *time.Time  `json:"dueDate,omitempty"`
+}
+
+type TaskEventType string
+
+const (
+	TaskEventTypeTaskCreated TaskEventType = "TASK_CREATED"
+	TaskEventTypeTaskUpdated TaskEventType = "TASK_UPDATED"
+	TaskEventTypeTaskDeleted TaskEventType = "TASK_DELETED"
+)
+
+var AllTaskEventType = []TaskEventType{
+	TaskEventTypeTaskCreated,
+	TaskEventTypeTaskUpdated,
+	TaskEventTypeTaskDeleted,
+}
+
+func (e TaskEventType) IsValid() bool {
+	switch e {
+	case TaskEventTypeTaskCreated, TaskEventTypeTaskUpdated, TaskEventTypeTaskDeleted:
+		return true
+	}
+	return false
+}
+
+func (e TaskEventType) String() string {
+	return string(e)
+}
+
+func (e *TaskEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaskEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaskEventType", str)
+	}
+	return nil
+}
+
+func (e TaskEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TaskEventType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TaskEventType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TaskStatus string
+
+const (
+	TaskStatusPending    TaskStatus = "PENDING"
+	TaskStatusInProgress TaskStatus = "IN_PROGRESS"
+	TaskStatusCompleted  TaskStatus = "COMPLETED"
+)
+
+var AllTaskStatus = []TaskStatus{
+	TaskStatusPending,
+	TaskStatusInProgress,
+	TaskStatusCompleted,
+}
+
+func (e TaskStatus) IsValid() bool {
+	switch e {
+	case TaskStatusPending, TaskStatusInProgress, TaskStatusCompleted:
+		return true
+	}
+	return false
+}
+
+func (e TaskStatus) String() string {
+	return string(e)
+}
+
+func (e *TaskStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaskStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaskStatus", str)
+	}
+	return nil
+}
+
+func (e TaskStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TaskStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TaskStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,17 @@
+package graphapi
+
+import (
+	"alle-task-manager-gunish/internal/service"
+)
+
+//go:generate go tool gqlgen generate
+
+// Resolver : The root resolver, backed by the same services as the REST handlers.
+type Resolver struct {
+	taskService *service.TaskService
+	broadcaster *service.TaskStreamBroadcaster
+}
+
+func NewResolver(taskService *service.TaskService, broadcaster *service.TaskStreamBroadcaster) *Resolver {
+	return &Resolver{taskService: taskService, broadcaster: broadcaster}
+}
@@ -0,0 +1,151 @@
+package graphapi
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"strings"
+	"time"
+)
+
+// CreateTask is the resolver for the createTask field.
+func (r *mutationResolver) CreateTask(ctx context.Context, input CreateTaskInput) (*model.Task, error) {
+	createInput := service.CreateTaskInput{Title: input.Title, DueDate: input.DueDate}
+	if input.Description != nil {
+		createInput.Description = *input.Description
+	}
+	return r.taskService.CreateTask(ctx, createInput)
+}
+
+// UpdateTask is the resolver for the updateTask field.
+func (r *mutationResolver) UpdateTask(ctx context.Context, id string, input UpdateTaskInput) (*model.Task, error) {
+	updateInput := service.UpdateTaskInput{
+		Title:       input.Title,
+		Description: input.Description,
+		DueDate:     input.DueDate,
+	}
+	if input.Status != nil {
+		status := strings.ToLower(string(*input.Status))
+		updateInput.Status = &status
+	}
+	return r.taskService.UpdateTask(ctx, id, updateInput)
+}
+
+// DeleteTask is the resolver for the deleteTask field.
+func (r *mutationResolver) DeleteTask(ctx context.Context, id string) (bool, error) {
+	if err := r.taskService.DeleteTask(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Task is the resolver for the task field.
+func (r *queryResolver) Task(ctx context.Context, id string, asOf *time.Time) (*model.Task, error) {
+	var (
+		task *model.Task
+		err  error
+	)
+	if asOf != nil {
+		task, err = r.taskService.GetTaskAsOf(ctx, id, *asOf)
+	} else {
+		task, err = r.taskService.GetTask(ctx, id)
+	}
+	if err == errors.ErrNotFound {
+		return nil, nil
+	}
+	return task, err
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, filter *TaskFilter, page int, pageSize int) (*TaskPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = 10
+	}
+	status := ""
+	if filter != nil && filter.Status != nil {
+		status = strings.ToLower(string(*filter.Status))
+	}
+
+	tasks, pageInfo, err := r.taskService.ListTasks(ctx, status, &pagination.Page{Number: page, Size: pageSize})
+	if err != nil {
+		return nil, err
+	}
+	return &TaskPage{Tasks: tasks, PageInfo: pageInfo}, nil
+}
+
+// TaskChanged is the resolver for the taskChanged field.
+func (r *subscriptionResolver) TaskChanged(ctx context.Context, status *TaskStatus) (<-chan *TaskChange, error) {
+	var filter service.TaskStreamFilter
+	if status != nil {
+		filter.Status = strings.ToLower(string(*status))
+	}
+	subscription, _, _ := r.broadcaster.Subscribe("", filter)
+
+	changes := make(chan *TaskChange)
+	go func() {
+		defer close(changes)
+		defer r.broadcaster.Unsubscribe(subscription)
+		for {
+			select {
+			case event, ok := <-subscription.Events():
+				// A closed subscription fell too far behind; ending the stream tells the client to resubscribe.
+				if !ok {
+					return
+				}
+				task, changedFields, err := event.Task()
+				if err != nil {
+					continue
+				}
+				if changedFields == nil {
+					changedFields = []string{}
+				}
+				change := &TaskChange{
+					EventID:       event.ID,
+					EventType:     TaskEventType(event.Type),
+					TaskID:        event.TaskID,
+					Task:          task,
+					ChangedFields: changedFields,
+				}
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// Status is the resolver for the status field.
+func (r *taskResolver) Status(ctx context.Context, obj *model.Task) (TaskStatus, error) {
+	return TaskStatus(strings.ToUpper(string(obj.Status))), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type taskResolver struct{ *Resolver }
@@ -0,0 +1,152 @@
+package graphapi
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+	"time"
+)
+
+// MaxPageSize : The largest page the tasks query returns, which also bounds its complexity.
+const MaxPageSize = 100
+
+type Limits struct {
+	// MaxDepth is the deepest field nesting a query may have.
+	MaxDepth int
+	// MaxComplexity is the highest complexity score a query may have. Every field counts 1, and list
+	// fields count their children once per item of the requested page.
+	MaxComplexity int
+}
+
+// NewServer : The GraphQL endpoint, serving queries and mutations over GET and POST and subscriptions over
+// WebSocket (graphql-ws and graphql-transport-ws). checkOrigin guards WebSocket handshakes.
+func NewServer(resolver *Resolver, limits Limits, checkOrigin func(r *http.Request) bool) *handler.Server {
+	config := Config{Resolvers: resolver}
+	config.Complexity.Query.Tasks = func(childComplexity int, _ *TaskFilter, _ int, pageSize int) int {
+		if pageSize < 1 || pageSize > MaxPageSize {
+			pageSize = 10
+		}
+		return 1 + childComplexity*pageSize
+	}
+
+	server := handler.New(NewExecutableSchema(config))
+	server.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader:              websocket.Upgrader{CheckOrigin: checkOrigin},
+	})
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.Use(extension.Introspection{})
+	server.Use(DepthLimit{MaxDepth: limits.MaxDepth})
+	server.Use(extension.FixedComplexityLimit(limits.MaxComplexity))
+	server.SetErrorPresenter(presentError)
+	return server
+}
+
+// presentError : Maps domain errors to an extensions.code the way the REST handlers map them to statuses.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	var code, message string
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		code = "BAD_USER_INPUT"
+	case errors.Is(err, apperrors.ErrInvalidStatus):
+		code, message = "BAD_USER_INPUT", "invalid task status"
+	case errors.Is(err, apperrors.ErrNotFound):
+		code, message = "NOT_FOUND", "task not found"
+	case errors.Is(err, apperrors.ErrDuplicateEntity):
+		code, message = "CONFLICT", "task with this ID already exists"
+	case errors.Is(err, apperrors.ErrConflict):
+		code, message = "CONFLICT", "task was modified concurrently, retry the request"
+	case errors.Is(err, apperrors.ErrEventSourcingDisabled):
+		code, message = "BAD_USER_INPUT", "asOf requires event sourcing to be enabled"
+	default:
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) && gqlErr.Err == nil {
+			// Parse, validation and limit errors are already meant for the client.
+			return presented
+		}
+		loggingtype.ForPackage("http").ErrorContext(ctx, "GraphQL resolver failed", "path", presented.Path.String(), "error", err)
+		code, message = "INTERNAL_SERVER_ERROR", "an unexpected error occurred"
+	}
+
+	if message != "" {
+		presented.Message = message
+	}
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]interface{}{}
+	}
+	presented.Extensions["code"] = code
+	return presented
+}
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// DepthLimit : Rejects operations nested deeper than MaxDepth, counting fields through fragments.
+type DepthLimit struct {
+	MaxDepth int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+func (DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d DepthLimit) Validate(graphql.ExecutableSchema) error {
+	if d.MaxDepth < 1 {
+		return fmt.Errorf("max depth must be at least 1, got %d", d.MaxDepth)
+	}
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(_ context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+	if depth := selectionDepth(rc.Operation.SelectionSet, map[string]bool{}); depth > d.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.MaxDepth)
+		err.Extensions = map[string]interface{}{"code": errDepthLimit}
+		return err
+	}
+	return nil
+}
+
+// selectionDepth : visiting guards against fragment cycles, which validation rejects anyway.
+func selectionDepth(selections ast.SelectionSet, visiting map[string]bool) int {
+	depth := 0
+	for _, selection := range selections {
+		var d int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d = 1 + selectionDepth(selection.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			d = selectionDepth(selection.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			if selection.Definition == nil || visiting[selection.Name] {
+				continue
+			}
+			visiting[selection.Name] = true
+			d = selectionDepth(selection.Definition.SelectionSet, visiting)
+			delete(visiting, selection.Name)
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
@@ -0,0 +1,161 @@
+package graphapi
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"encoding/json"
+	"github.com/99designs/gqlgen/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, limits Limits) *client.Client {
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+	return client.New(NewServer(NewResolver(taskService, broadcaster), limits, nil))
+}
+
+var testLimits = Limits{MaxDepth: 5, MaxComplexity: 200}
+
+type taskResult struct {
+	ID          string
+	Title       string
+	Description string
+	Status      string
+	DueDate     *string
+}
+
+// errorCodes : The extensions.code of each error in a failed response.
+func errorCodes(t *testing.T, err error) []string {
+	require.Error(t, err)
+	var errs []struct {
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(err.Error()), &errs), err.Error())
+	codes := make([]string, len(errs))
+	for i, e := range errs {
+		codes[i] = e.Extensions.Code
+	}
+	return codes
+}
+
+func TestGraphQL_QueriesAndMutations(t *testing.T) {
+	c := newTestClient(t, testLimits)
+
+	var created struct{ CreateTask taskResult }
+	c.MustPost(`mutation { createTask(input: {title: "Write docs", dueDate: "2025-03-01T00:00:00Z"}) { id title description status dueDate } }`, &created)
+	assert.Equal(t, "Write docs", created.CreateTask.Title)
+	assert.Equal(t, "PENDING", created.CreateTask.Status)
+	require.NotNil(t, created.CreateTask.DueDate)
+	assert.Equal(t, "2025-03-01T00:00:00Z", *created.CreateTask.DueDate)
+	id := created.CreateTask.ID
+
+	var updated struct{ UpdateTask taskResult }
+	c.MustPost(`mutation($id: ID!) { updateTask(id: $id, input: {status: IN_PROGRESS}) { title status } }`, &updated, client.Var("id", id))
+	assert.Equal(t, taskResult{Title: "Write docs", Status: "IN_PROGRESS"}, updated.UpdateTask)
+
+	c.MustPost(`mutation { createTask(input: {title: "Review docs"}) { id } }`, &struct{ CreateTask taskResult }{})
+
+	var list struct {
+		Tasks struct {
+			Tasks    []taskResult
+			PageInfo struct{ Page, PageSize, TotalItems, TotalPages int }
+		}
+	}
+	c.MustPost(`{ tasks(filter: {status: IN_PROGRESS}) { tasks { id } pageInfo { page pageSize totalItems totalPages } } }`, &list)
+	require.Len(t, list.Tasks.Tasks, 1)
+	assert.Equal(t, id, list.Tasks.Tasks[0].ID)
+	assert.Equal(t, 1, list.Tasks.PageInfo.TotalItems)
+
+	c.MustPost(`{ tasks(page: 2, pageSize: 1) { tasks { id } pageInfo { page pageSize totalItems totalPages } } }`, &list)
+	assert.Len(t, list.Tasks.Tasks, 1)
+	assert.Equal(t, 2, list.Tasks.PageInfo.TotalPages)
+
+	var deleted struct{ DeleteTask bool }
+	c.MustPost(`mutation($id: ID!) { deleteTask(id: $id) }`, &deleted, client.Var("id", id))
+	assert.True(t, deleted.DeleteTask)
+
+	var fetched struct{ Task *taskResult }
+	c.MustPost(`query($id: ID!) { task(id: $id) { id } }`, &fetched, client.Var("id", id))
+	assert.Nil(t, fetched.Task, "a missing task is null")
+
+	err := c.Post(`mutation($id: ID!) { deleteTask(id: $id) }`, &deleted, client.Var("id", id))
+	assert.Equal(t, []string{"NOT_FOUND"}, errorCodes(t, err))
+
+	err = c.Post(`query($id: ID!) { task(id: $id, asOf: "2025-01-01T00:00:00Z") { id } }`, &fetched, client.Var("id", id))
+	assert.Equal(t, []string{"BAD_USER_INPUT"}, errorCodes(t, err))
+}
+
+func TestGraphQL_Limits(t *testing.T) {
+	c := newTestClient(t, Limits{MaxDepth: 3, MaxComplexity: 100})
+
+	var resp struct{ Tasks struct{ Tasks []taskResult } }
+	c.MustPost(`{ tasks(pageSize: 5) { tasks { id title } } }`, &resp)
+
+	err := c.Post(`{ tasks { tasks { ...deep } } } fragment deep on Task { id ... on Task { title } }`, &resp)
+	assert.NoError(t, err, "fragments don't add depth")
+
+	err = c.Post(`{ __schema { types { fields { type { name } } } } }`, &resp)
+	assert.Equal(t, []string{errDepthLimit}, errorCodes(t, err))
+
+	err = c.Post(`{ tasks(pageSize: 100) { tasks { id title } } }`, &resp)
+	assert.Equal(t, []string{"COMPLEXITY_LIMIT_EXCEEDED"}, errorCodes(t, err))
+}
+
+func TestGraphQL_TaskChangedSubscription(t *testing.T) {
+	c := newTestClient(t, testLimits)
+
+	subscription := c.Websocket(`subscription { taskChanged(status: COMPLETED) { eventType taskId changedFields task { title status } } }`)
+	defer subscription.Close()
+
+	var created struct{ CreateTask taskResult }
+	// The subscription is registered asynchronously, so keep changing tasks until one gets through.
+	var resp struct {
+		TaskChanged struct {
+			EventType     string
+			TaskID        string
+			ChangedFields []string
+			Task          taskResult
+		}
+	}
+	received := make(chan error, 1)
+	go func() { received <- subscription.Next(&resp) }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		c.MustPost(`mutation { createTask(input: {title: "Ship it"}) { id } }`, &created)
+		c.MustPost(`mutation($id: ID!) { updateTask(id: $id, input: {status: COMPLETED}) { id } }`, &struct{ UpdateTask taskResult }{}, client.Var("id", created.CreateTask.ID))
+		select {
+		case err := <-received:
+			require.NoError(t, err)
+			assert.Equal(t, "TASK_UPDATED", resp.TaskChanged.EventType, "the pending task's creation is filtered out")
+			assert.Equal(t, []string{"status"}, resp.TaskChanged.ChangedFields)
+			assert.Equal(t, taskResult{Title: "Ship it", Status: "COMPLETED"}, resp.TaskChanged.Task)
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no task change received")
+		}
+	}
+}
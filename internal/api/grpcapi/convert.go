@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	taskv1 "alle-task-manager-gunish/api/task/v1"
+	"alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+var taskStatuses = map[model.TaskStatus]taskv1.TaskStatus{
+	model.Pending:    taskv1.TaskStatus_TASK_STATUS_PENDING,
+	model.InProgress: taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS,
+	model.Completed:  taskv1.TaskStatus_TASK_STATUS_COMPLETED,
+}
+
+func statusToProto(status model.TaskStatus) taskv1.TaskStatus {
+	return taskStatuses[status]
+}
+
+func statusFromProto(status taskv1.TaskStatus) (string, error) {
+	for modelStatus, protoStatus := range taskStatuses {
+		if protoStatus == status {
+			return string(modelStatus), nil
+		}
+	}
+	return "", errors.ErrInvalidStatus
+}
+
+// filterFromProto : Like statusFromProto, but an unspecified status means no filter.
+func filterFromProto(status taskv1.TaskStatus) (string, error) {
+	if status == taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		return "", nil
+	}
+	return statusFromProto(status)
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func taskToProto(task *model.Task) *taskv1.Task {
+	return &taskv1.Task{
+		Id:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      statusToProto(task.Status),
+		DueDate:     timeToProto(task.DueDate),
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+	}
+}
+
+// eventToProto : Created events only carry the task's title, description and status.
+func eventToProto(event *service.TaskStreamEvent) *taskv1.WatchTasksResponse {
+	resp := &taskv1.WatchTasksResponse{EventId: event.ID, EventType: event.Type, TaskId: event.TaskID}
+	task, changedFields, err := event.Task()
+	if err != nil {
+		return resp
+	}
+	resp.Task, resp.ChangedFields = taskToProto(task), changedFields
+	return resp
+}
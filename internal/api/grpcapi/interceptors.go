@@ -0,0 +1,106 @@
+package grpcapi
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// toStatus : Maps domain errors to gRPC status codes, the way TaskHandler.handleError maps them to HTTP.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, apperrors.ErrNotFound):
+		return status.Error(codes.NotFound, "task not found")
+	case errors.Is(err, apperrors.ErrDuplicateEntity):
+		return status.Error(codes.AlreadyExists, "task with this ID already exists")
+	case errors.Is(err, apperrors.ErrInvalidStatus):
+		return status.Error(codes.InvalidArgument, "invalid task status")
+	case errors.Is(err, apperrors.ErrConflict):
+		return status.Error(codes.Aborted, "task was modified concurrently, retry the request")
+	case errors.Is(err, apperrors.ErrEventSourcingDisabled):
+		return status.Error(codes.FailedPrecondition, "as_of requires event sourcing to be enabled")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "an unexpected error occurred")
+	}
+}
+
+// authorize : Accepts the same keys as the HTTP API, in x-api-key metadata or as a bearer token.
+func authorize(ctx context.Context, apiKeys *middleware.APIKeys) error {
+	if !apiKeys.Enabled() {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := first(md.Get(strings.ToLower(middleware.HeaderAPIKey)))
+	if authorization := first(md.Get("authorization")); key == "" && strings.HasPrefix(authorization, "Bearer ") {
+		key = strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if !apiKeys.Valid(key) {
+		return status.Error(codes.Unauthenticated, "a valid API key is required")
+	}
+	return nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func unaryAuth(apiKeys *middleware.APIKeys) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, apiKeys); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(apiKeys *middleware.APIKeys) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), apiKeys); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// withRequestID : Accepts the caller's x-request-id metadata or generates one, like middleware.RequestID, and
+// returns it to the caller in the response header.
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := strings.ToLower(middleware.HeaderRequestID)
+	id := middleware.NormalizeRequestID(first(md.Get(key)))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(key, id))
+	return loggingtype.WithRequestID(ctx, id)
+}
+
+func unaryRequestID(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func streamRequestID(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &requestIDStream{ServerStream: stream, ctx: withRequestID(stream.Context())})
+}
+
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,168 @@
+package grpcapi
+
+import (
+	taskv1 "alle-task-manager-gunish/api/task/v1"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// TaskServer : The gRPC counterpart of TaskHandler and TaskStreamHandler, backed by the same services.
+type TaskServer struct {
+	taskv1.UnimplementedTaskServiceServer
+	taskService *service.TaskService
+	broadcaster *service.TaskStreamBroadcaster
+}
+
+func NewTaskServer(taskService *service.TaskService, broadcaster *service.TaskStreamBroadcaster) *TaskServer {
+	return &TaskServer{taskService: taskService, broadcaster: broadcaster}
+}
+
+// NewServer : A gRPC server with the task service and reflection registered, guarded by the API keys.
+func NewServer(taskServer *TaskServer, apiKeys *middleware.APIKeys) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryRequestID, unaryAuth(apiKeys)),
+		grpc.ChainStreamInterceptor(streamRequestID, streamAuth(apiKeys)),
+	)
+	taskv1.RegisterTaskServiceServer(server, taskServer)
+	reflection.Register(server)
+	return server
+}
+
+func (s *TaskServer) CreateTask(ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.Task, error) {
+	input := service.CreateTaskInput{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     timeFromProto(req.GetDueDate()),
+	}
+	task, err := s.taskService.CreateTask(ctx, input)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) GetTask(ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.Task, error) {
+	var (
+		task *model.Task
+		err  error
+	)
+	if req.GetAsOf() != nil {
+		task, err = s.taskService.GetTaskAsOf(ctx, req.GetId(), req.GetAsOf().AsTime())
+	} else {
+		task, err = s.taskService.GetTask(ctx, req.GetId())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) UpdateTask(ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.Task, error) {
+	input := service.UpdateTaskInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     timeFromProto(req.GetDueDate()),
+	}
+	if req.GetStatus() != taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		taskStatus, err := statusFromProto(req.GetStatus())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		input.Status = &taskStatus
+	}
+	task, err := s.taskService.UpdateTask(ctx, req.GetId(), input)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) DeleteTask(ctx context.Context, req *taskv1.DeleteTaskRequest) (*taskv1.DeleteTaskResponse, error) {
+	if err := s.taskService.DeleteTask(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &taskv1.DeleteTaskResponse{}, nil
+}
+
+func (s *TaskServer) ListTasks(ctx context.Context, req *taskv1.ListTasksRequest) (*taskv1.ListTasksResponse, error) {
+	taskStatus, err := filterFromProto(req.GetStatus())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	page := &pagination.Page{Number: int(req.GetPage()), Size: int(req.GetPageSize())}
+	if page.Number < 1 {
+		page.Number = 1
+	}
+	if page.Size < 1 || page.Size > 100 {
+		page.Size = 10
+	}
+
+	tasks, pageInfo, err := s.taskService.ListTasks(ctx, taskStatus, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &taskv1.ListTasksResponse{
+		Tasks: make([]*taskv1.Task, len(tasks)),
+		PageInfo: &taskv1.PageInfo{
+			Page:       int32(pageInfo.Page),
+			PageSize:   int32(pageInfo.PageSize),
+			TotalItems: int32(pageInfo.TotalItems),
+			TotalPages: int32(pageInfo.TotalPages),
+		},
+	}
+	for i, task := range tasks {
+		resp.Tasks[i] = taskToProto(task)
+	}
+	return resp, nil
+}
+
+// WatchTasks : Streams task changes until the client goes away. A client that falls too far behind is
+// ended with Unavailable and can resume with last_event_id.
+func (s *TaskServer) WatchTasks(req *taskv1.WatchTasksRequest, stream taskv1.TaskService_WatchTasksServer) error {
+	taskStatus, err := filterFromProto(req.GetStatus())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	subscription, missed, resumed := s.broadcaster.Subscribe(req.GetLastEventId(), service.TaskStreamFilter{Status: taskStatus})
+	defer s.broadcaster.Unsubscribe(subscription)
+	// Headers tell the client the subscription is in place, before any event has to arrive.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	if req.GetLastEventId() != "" && !resumed {
+		if err := stream.Send(&taskv1.WatchTasksResponse{Resync: true}); err != nil {
+			return err
+		}
+	}
+	for _, event := range missed {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "too far behind, resume with last_event_id")
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package grpcapi
+
+import (
+	taskv1 "alle-task-manager-gunish/api/task/v1"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "secret-key"
+
+func newTestClient(t *testing.T) taskv1.TaskServiceClient {
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+	server := NewServer(NewTaskServer(taskService, broadcaster), middleware.NewAPIKeys([]string{testAPIKey}))
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return taskv1.NewTaskServiceClient(conn)
+}
+
+func authorized() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", testAPIKey)
+}
+
+func TestTaskServer_CRUD(t *testing.T) {
+	client := newTestClient(t)
+	ctx := authorized()
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := client.CreateTask(ctx, &taskv1.CreateTaskRequest{Title: "Write docs", DueDate: timestamppb.New(due)})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.GetId())
+	assert.Equal(t, taskv1.TaskStatus_TASK_STATUS_PENDING, created.GetStatus())
+	assert.True(t, due.Equal(created.GetDueDate().AsTime()))
+
+	title := "Write better docs"
+	updated, err := client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{Id: created.GetId(), Title: &title, Status: taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS})
+	require.NoError(t, err)
+	assert.Equal(t, title, updated.GetTitle())
+	assert.Equal(t, taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS, updated.GetStatus())
+
+	fetched, err := client.GetTask(ctx, &taskv1.GetTaskRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, title, fetched.GetTitle())
+
+	_, err = client.CreateTask(ctx, &taskv1.CreateTaskRequest{Title: "Review docs"})
+	require.NoError(t, err)
+	list, err := client.ListTasks(ctx, &taskv1.ListTasksRequest{Status: taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS})
+	require.NoError(t, err)
+	require.Len(t, list.GetTasks(), 1)
+	assert.Equal(t, created.GetId(), list.GetTasks()[0].GetId())
+	assert.Equal(t, &taskv1.PageInfo{Page: 1, PageSize: 10, TotalItems: 1, TotalPages: 1}, list.GetPageInfo())
+
+	list, err = client.ListTasks(ctx, &taskv1.ListTasksRequest{PageSize: 1, Page: 2})
+	require.NoError(t, err)
+	assert.Len(t, list.GetTasks(), 1)
+	assert.Equal(t, int32(2), list.GetPageInfo().GetTotalPages())
+
+	_, err = client.DeleteTask(ctx, &taskv1.DeleteTaskRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, err = client.GetTask(ctx, &taskv1.GetTaskRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTaskServer_ErrorCodes(t *testing.T) {
+	client := newTestClient(t)
+	ctx := authorized()
+	created, err := client.CreateTask(ctx, &taskv1.CreateTaskRequest{Title: "Task"})
+	require.NoError(t, err)
+
+	_, err = client.DeleteTask(ctx, &taskv1.DeleteTaskRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{Id: created.GetId(), Status: taskv1.TaskStatus(42)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetTask(ctx, &taskv1.GetTaskRequest{Id: created.GetId(), AsOf: timestamppb.Now()})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.ListTasks(context.Background(), &taskv1.ListTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	bearer := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testAPIKey)
+	_, err = client.ListTasks(bearer, &taskv1.ListTasksRequest{})
+	assert.NoError(t, err)
+}
+
+func TestTaskServer_RequestID(t *testing.T) {
+	client := newTestClient(t)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(authorized(), "x-request-id", "grpc-req-1")
+	_, err := client.ListTasks(ctx, &taskv1.ListTasksRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"grpc-req-1"}, header.Get("x-request-id"))
+
+	_, err = client.ListTasks(authorized(), &taskv1.ListTasksRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get("x-request-id"), 1)
+	assert.NotEqual(t, "grpc-req-1", header.Get("x-request-id")[0], "a missing ID is generated")
+}
+
+func TestTaskServer_WatchTasks(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(authorized(), 5*time.Second)
+	defer cancel()
+
+	all, err := client.WatchTasks(ctx, &taskv1.WatchTasksRequest{})
+	require.NoError(t, err)
+	completed, err := client.WatchTasks(ctx, &taskv1.WatchTasksRequest{Status: taskv1.TaskStatus_TASK_STATUS_COMPLETED})
+	require.NoError(t, err)
+	// The subscriptions are registered once the streams' headers arrive.
+	for _, watch := range []taskv1.TaskService_WatchTasksClient{all, completed} {
+		_, err = watch.Header()
+		require.NoError(t, err)
+	}
+
+	created, err := client.CreateTask(ctx, &taskv1.CreateTaskRequest{Title: "Ship it"})
+	require.NoError(t, err)
+	_, err = client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{Id: created.GetId(), Status: taskv1.TaskStatus_TASK_STATUS_COMPLETED})
+	require.NoError(t, err)
+
+	createdEvent, err := all.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "TASK_CREATED", createdEvent.GetEventType())
+	assert.Equal(t, "Ship it", createdEvent.GetTask().GetTitle())
+	assert.Equal(t, taskv1.TaskStatus_TASK_STATUS_PENDING, createdEvent.GetTask().GetStatus())
+
+	event, err := completed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "TASK_UPDATED", event.GetEventType(), "the pending task's creation is filtered out")
+	assert.Equal(t, created.GetId(), event.GetTaskId())
+	assert.Equal(t, taskv1.TaskStatus_TASK_STATUS_COMPLETED, event.GetTask().GetStatus())
+	assert.Equal(t, []string{"status"}, event.GetChangedFields())
+
+	resumed, err := client.WatchTasks(ctx, &taskv1.WatchTasksRequest{LastEventId: createdEvent.GetEventId()})
+	require.NoError(t, err)
+	replayed, err := resumed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, event.GetEventId(), replayed.GetEventId())
+	assert.False(t, replayed.GetResync())
+
+	expired, err := client.WatchTasks(ctx, &taskv1.WatchTasksRequest{LastEventId: "unknown"})
+	require.NoError(t, err)
+	first, err := expired.Recv()
+	require.NoError(t, err)
+	assert.True(t, first.GetResync())
+}
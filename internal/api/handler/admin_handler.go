@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/response"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultReplayLimit = 100
+	maxReplayLimit     = 1000
+)
+
+type DeadLetterReplayer interface {
+	Replay(ctx context.Context, limit int) (int, error)
+}
+
+type ConsumerStats interface {
+	DuplicatesSkipped() uint64
+}
+
+type AdminHandler struct {
+	dlqReplayer   DeadLetterReplayer
+	consumerStats ConsumerStats
+	adminKeys     []string
+}
+
+type AdminHandlerOption func(*AdminHandler)
+
+// WithAdminKeys : The keys accepted in the X-Admin-Key header by every admin route, which are refused
+// without them.
+func WithAdminKeys(keys []string) AdminHandlerOption {
+	return func(handler *AdminHandler) {
+		handler.adminKeys = keys
+	}
+}
+
+func NewAdminHandler(dlqReplayer DeadLetterReplayer, consumerStats ConsumerStats, options ...AdminHandlerOption) *AdminHandler {
+	handler := &AdminHandler{
+		dlqReplayer:   dlqReplayer,
+		consumerStats: consumerStats,
+	}
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
+}
+
+func (handler *AdminHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/admin", middleware.AdminKeyAuth(handler.adminKeys))
+	{
+		admin.POST("/dlq/replay", handler.ReplayDeadLetters)
+		admin.GET("/consumer/stats", handler.GetConsumerStats)
+		admin.GET("/log-level", handler.GetLogLevels)
+		admin.PUT("/log-level", handler.SetLogLevel)
+		admin.PUT("/log-level/:package", handler.SetPackageLogLevel)
+		admin.DELETE("/log-level/:package", handler.ResetPackageLogLevel)
+	}
+}
+
+func (handler *AdminHandler) ReplayDeadLetters(c *gin.Context) {
+	if handler.dlqReplayer == nil {
+		response.Error(c, http.StatusNotImplemented, "NOT_IMPLEMENTED", "Dead-letter replay is only available with the Kafka event bus")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReplayLimit)))
+	if err != nil || limit < 1 || limit > maxReplayLimit {
+		response.BadRequest(c, "limit must be between 1 and "+strconv.Itoa(maxReplayLimit))
+		return
+	}
+
+	replayed, err := handler.dlqReplayer.Replay(c.Request.Context(), limit)
+	if err != nil {
+		loggingtype.ForPackage("http").ErrorContext(c.Request.Context(), "Failed to replay dead-letter messages", "replayed", replayed, "error", err)
+		response.InternalServerError(c)
+		return
+	}
+
+	response.Success(c, gin.H{"replayed": replayed})
+}
+
+func (handler *AdminHandler) GetConsumerStats(c *gin.Context) {
+	response.Success(c, gin.H{"duplicates_skipped": handler.consumerStats.DuplicatesSkipped()})
+}
+
+type logLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+type logLevelsResponse struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+// GetLogLevels : The global level and the packages that override it.
+func (handler *AdminHandler) GetLogLevels(c *gin.Context) {
+	response.Success(c, currentLogLevels())
+}
+
+// SetLogLevel : Changes the level of every package without an override, until the next restart.
+func (handler *AdminHandler) SetLogLevel(c *gin.Context) {
+	level, ok := bindLogLevel(c)
+	if !ok {
+		return
+	}
+	loggingtype.SetLevel(level)
+	loggingtype.ForPackage("http").InfoContext(c.Request.Context(), "Log level changed", "level", loggingtype.LevelName(level))
+	response.Success(c, currentLogLevels())
+}
+
+// SetPackageLogLevel : Overrides the global level for one package, such as repository or kafka.
+func (handler *AdminHandler) SetPackageLogLevel(c *gin.Context) {
+	if !knownLogPackage(c) {
+		return
+	}
+	level, ok := bindLogLevel(c)
+	if !ok {
+		return
+	}
+	loggingtype.SetPackageLevel(c.Param("package"), level)
+	loggingtype.ForPackage("http").InfoContext(c.Request.Context(), "Log level changed", "package", c.Param("package"), "level", loggingtype.LevelName(level))
+	response.Success(c, currentLogLevels())
+}
+
+// ResetPackageLogLevel : Makes the package follow the global level again.
+func (handler *AdminHandler) ResetPackageLogLevel(c *gin.Context) {
+	if !knownLogPackage(c) {
+		return
+	}
+	loggingtype.ResetPackageLevel(c.Param("package"))
+	response.Success(c, currentLogLevels())
+}
+
+func knownLogPackage(c *gin.Context) bool {
+	if loggingtype.KnownPackage(c.Param("package")) {
+		return true
+	}
+	response.BadRequest(c, "unknown package "+strconv.Quote(c.Param("package"))+", expected one of "+strings.Join(loggingtype.Packages, ", "))
+	return false
+}
+
+func bindLogLevel(c *gin.Context) (slog.Level, bool) {
+	var request logLevelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, "level is required")
+		return 0, false
+	}
+	level, err := loggingtype.ParseLevel(request.Level)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return 0, false
+	}
+	return level, true
+}
+
+func currentLogLevels() logLevelsResponse {
+	global, packages := loggingtype.Levels()
+	names := make(map[string]string, len(packages))
+	for name, level := range packages {
+		names[name] = loggingtype.LevelName(level)
+	}
+	return logLevelsResponse{Level: loggingtype.LevelName(global), Packages: names}
+}
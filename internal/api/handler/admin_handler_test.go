@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/common/logging"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler_LogLevels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() {
+		loggingtype.SetLevel(slog.LevelInfo)
+		loggingtype.ResetPackageLevel("repository")
+	})
+
+	router := gin.New()
+	NewAdminHandler(nil, nil, WithAdminKeys([]string{"admin-key"})).RegisterRoutes(router)
+	send := func(method, path, body string) (int, logLevelsResponse) {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set(middleware.HeaderAdminKey, "admin-key")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		var envelope struct {
+			Data logLevelsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+		return recorder.Code, envelope.Data
+	}
+
+	code, levels := send(http.MethodPut, "/admin/log-level", `{"level":"warn"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warn", levels.Level)
+
+	code, levels = send(http.MethodPut, "/admin/log-level/repository", `{"level":"DEBUG"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"repository": "debug"}, levels.Packages)
+	assert.True(t, loggingtype.ForPackage("repository").Enabled(t.Context(), slog.LevelDebug))
+	assert.False(t, loggingtype.ForPackage("kafka").Enabled(t.Context(), slog.LevelInfo))
+
+	code, levels = send(http.MethodGet, "/admin/log-level", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, logLevelsResponse{Level: "warn", Packages: map[string]string{"repository": "debug"}}, levels)
+
+	code, levels = send(http.MethodDelete, "/admin/log-level/repository", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, levels.Packages)
+
+	code, _ = send(http.MethodPut, "/admin/log-level", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = send(http.MethodPut, "/admin/log-level", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = send(http.MethodPut, "/admin/log-level/made-up", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = send(http.MethodDelete, "/admin/log-level/made-up", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	_, packages := loggingtype.Levels()
+	assert.NotContains(t, packages, "made-up")
+}
+
+func TestAdminHandler_RequiresAdminKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/admin/dlq/replay"},
+		{http.MethodGet, "/admin/consumer/stats"},
+		{http.MethodGet, "/admin/log-level"},
+		{http.MethodPut, "/admin/log-level"},
+		{http.MethodPut, "/admin/log-level/repository"},
+		{http.MethodDelete, "/admin/log-level/repository"},
+	}
+
+	disabled := gin.New()
+	NewAdminHandler(nil, nil).RegisterRoutes(disabled)
+	guarded := gin.New()
+	NewAdminHandler(nil, nil, WithAdminKeys([]string{"admin-key"})).RegisterRoutes(guarded)
+	for _, route := range routes {
+		recorder := httptest.NewRecorder()
+		disabled.ServeHTTP(recorder, httptest.NewRequest(route.method, route.path, strings.NewReader(`{"level":"debug"}`)))
+		assert.Equal(t, http.StatusForbidden, recorder.Code, route.method+" "+route.path)
+
+		request := httptest.NewRequest(route.method, route.path, strings.NewReader(`{"level":"debug"}`))
+		request.Header.Set(middleware.HeaderAdminKey, "wrong")
+		recorder = httptest.NewRecorder()
+		guarded.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, route.method+" "+route.path)
+	}
+}
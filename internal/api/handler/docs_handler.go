@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// swaggerUIPage : Swagger UI from its CDN, pointed at /openapi.json.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Task Management Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>`
+
+// DocsHandler : Serves the OpenAPI document and Swagger UI. Both are public, like /ping.
+type DocsHandler struct {
+	spec []byte
+}
+
+func NewDocsHandler(doc *openapi3.T) (*DocsHandler, error) {
+	spec, err := doc.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{spec: spec}, nil
+}
+
+func (handler *DocsHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/openapi.json", handler.ServeSpec)
+	router.GET("/docs", handler.ServeUI)
+}
+
+func (handler *DocsHandler) ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", handler.spec)
+}
+
+func (handler *DocsHandler) ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/openapi"
+	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func newDocumentedRouter(t *testing.T) (*gin.Engine, *openapi3.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validation, err := middleware.RequestValidation(doc)
+	require.NoError(t, err)
+	docs, err := NewDocsHandler(doc)
+	require.NoError(t, err)
+
+	repo := &fakeTaskRepository{tasks: map[string]*model.Task{}}
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+
+	router := gin.New()
+	docs.RegisterRoutes(router)
+	router.Use(validation)
+	NewTaskHandler(taskService).RegisterRoutes(router)
+	return router, doc
+}
+
+func TestOpenAPI_DocumentsEveryTaskRoute(t *testing.T) {
+	router, doc := newDocumentedRouter(t)
+	pathParam := regexp.MustCompile(`:(\w+)`)
+
+	for _, route := range router.Routes() {
+		if route.Path == "/openapi.json" || route.Path == "/docs" {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		item := doc.Paths.Find(path)
+		if assert.NotNil(t, item, "%s is not documented", path) {
+			assert.NotNil(t, item.GetOperation(route.Method), "%s %s is not documented", route.Method, path)
+		}
+	}
+}
+
+// validatedRequest : Serves the request and checks the response against the document.
+func validatedRequest(t *testing.T, router *gin.Engine, doc *openapi3.T, method, target string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newRequest())
+
+	docRouter, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+	req := newRequest()
+	route, pathParams, err := docRouter.FindRoute(req)
+	require.NoError(t, err)
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+		Status:                 recorder.Code,
+		Header:                 recorder.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.Body.Bytes())),
+	})
+	assert.NoError(t, err, "%s %s responded %d %s", method, target, recorder.Code, recorder.Body.String())
+	return recorder
+}
+
+func TestOpenAPI_ResponsesMatchDocument(t *testing.T) {
+	router, doc := newDocumentedRouter(t)
+
+	created := validatedRequest(t, router, doc, http.MethodPost, "/tasks", map[string]interface{}{"title": "Write docs", "due_date": "2025-03-01T00:00:00Z"})
+	require.Equal(t, http.StatusCreated, created.Code)
+	var envelope struct{ Data model.Task }
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &envelope))
+	id := envelope.Data.ID
+
+	assert.Equal(t, http.StatusOK, validatedRequest(t, router, doc, http.MethodGet, "/tasks/"+id, nil).Code)
+	assert.Equal(t, http.StatusOK, validatedRequest(t, router, doc, http.MethodPut, "/tasks/"+id, map[string]interface{}{"status": "completed"}).Code)
+	assert.Equal(t, http.StatusOK, validatedRequest(t, router, doc, http.MethodGet, "/tasks?status=completed&page=1&page_size=5", nil).Code)
+	assert.Equal(t, http.StatusOK, validatedRequest(t, router, doc, http.MethodGet, "/tasks/changes?limit=10", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, validatedRequest(t, router, doc, http.MethodGet, "/tasks/"+id+"?as_of=2025-01-01T00:00:00Z", nil).Code)
+	assert.Equal(t, http.StatusNoContent, validatedRequest(t, router, doc, http.MethodDelete, "/tasks/"+id, nil).Code)
+	assert.Equal(t, http.StatusNotFound, validatedRequest(t, router, doc, http.MethodGet, "/tasks/"+id, nil).Code)
+}
+
+func TestOpenAPI_RateLimitedResponseMatchesDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validation, err := middleware.RequestValidation(doc)
+	require.NoError(t, err)
+	rules, err := ratelimit.ParseRules("1/m", nil)
+	require.NoError(t, err)
+	repo := &fakeTaskRepository{tasks: map[string]*model.Task{}}
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(service.NewTaskStreamBroadcaster(10, 64)))
+
+	router := gin.New()
+	router.Use(middleware.RateLimit(rules, ratelimit.NewMemoryStore(), middleware.NewAPIKeys(nil)), validation)
+	NewTaskHandler(taskService).RegisterRoutes(router)
+
+	assert.Equal(t, http.StatusOK, validatedRequest(t, router, doc, http.MethodGet, "/tasks", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, validatedRequest(t, router, doc, http.MethodGet, "/tasks", nil).Code)
+}
+
+func TestRequestValidation(t *testing.T) {
+	router, _ := newDocumentedRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		message string
+	}{
+		{name: "missing title", method: http.MethodPost, target: "/tasks", body: `{"description": "no title"}`, message: `property "title" is missing`},
+		{name: "wrong type", method: http.MethodPost, target: "/tasks", body: `{"title": 42}`, message: "title: value must be a string"},
+		{name: "bad due date", method: http.MethodPut, target: "/tasks/1", body: `{"due_date": "tomorrow"}`, message: "due_date"},
+		{name: "unknown status", method: http.MethodPut, target: "/tasks/1", body: `{"status": "done"}`, message: "status"},
+		{name: "changes limit", method: http.MethodGet, target: "/tasks/changes?limit=0", message: `parameter "limit"`},
+		{name: "as_of", method: http.MethodGet, target: "/tasks/1?as_of=yesterday", message: `parameter "as_of"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			var envelope struct {
+				Success bool
+				Error   struct{ Code, Message string }
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+			assert.Equal(t, "BAD_REQUEST", envelope.Error.Code)
+			assert.Contains(t, envelope.Error.Message, tt.message)
+		})
+	}
+}
+
+// TestRequestValidation_ListTasksQueryIsLenient : The list query is normalised by the handler, as it was
+// before the document existed, rather than rejected.
+func TestRequestValidation_ListTasksQueryIsLenient(t *testing.T) {
+	router, doc := newDocumentedRouter(t)
+	created := validatedRequest(t, router, doc, http.MethodPost, "/tasks", map[string]interface{}{"title": "Write docs"})
+	require.Equal(t, http.StatusCreated, created.Code)
+
+	for _, target := range []string{"/tasks?status=PENDING", "/tasks?status=done", "/tasks?page_size=500", "/tasks?page=abc&page_size=0"} {
+		recorder := validatedRequest(t, router, doc, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusOK, recorder.Code, target)
+		var envelope struct {
+			Meta struct{ Pagination pagination.PageInfo }
+		}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &envelope))
+		assert.Equal(t, 1, envelope.Meta.Pagination.Page, target)
+		assert.Equal(t, 10, envelope.Meta.Pagination.PageSize, target)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	router, _ := newDocumentedRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/tasks/{id}")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "/openapi.json")
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/graphapi"
+	"alle-task-manager-gunish/internal/service"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type GraphQLHandler struct {
+	server     http.Handler
+	playground http.Handler
+}
+
+// NewGraphQLHandler : Subscriptions share the WebSocket origin policy of /ws.
+func NewGraphQLHandler(taskService *service.TaskService, broadcaster *service.TaskStreamBroadcaster, limits graphapi.Limits, allowedOrigins []string) *GraphQLHandler {
+	resolver := graphapi.NewResolver(taskService, broadcaster)
+	return &GraphQLHandler{
+		server:     graphapi.NewServer(resolver, limits, checkOrigin(allowedOrigins)),
+		playground: playground.Handler("Tasks", "/graphql"),
+	}
+}
+
+func (handler *GraphQLHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/graphql", gin.WrapH(handler.server))
+	router.POST("/graphql", gin.WrapH(handler.server))
+	router.GET("/graphql/playground", gin.WrapH(handler.playground))
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/common/health"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// HealthHandler : Serves the probes. /healthz answers as long as the process can serve HTTP; /readyz runs the
+// readiness checks and answers 503 when any fails or the service is shutting down. Both are public, like /ping.
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+func (handler *HealthHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/healthz", handler.Live)
+	router.GET("/readyz", handler.Ready)
+}
+
+func (handler *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+func (handler *HealthHandler) Ready(c *gin.Context) {
+	report := handler.checker.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
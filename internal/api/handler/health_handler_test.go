@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/common/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var kafkaErr error
+	checker := health.NewChecker(time.Second)
+	checker.Register("database", func(context.Context) error { return nil })
+	checker.Register("kafka", func(context.Context) error { return kafkaErr })
+
+	router := gin.New()
+	NewHealthHandler(checker).RegisterRoutes(router)
+	get := func(path string) (int, health.Report) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		var report health.Report
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+		return recorder.Code, report
+	}
+
+	code, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["kafka"].Status)
+
+	kafkaErr = errors.New("kafka: client has run out of available brokers to talk to")
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, kafkaErr.Error(), report.Checks["kafka"].Error)
+
+	code, report = get("/healthz")
+	assert.Equal(t, http.StatusOK, code, "a failing dependency doesn't make the process unhealthy")
+	assert.Equal(t, health.StatusOK, report.Status)
+
+	kafkaErr = nil
+	checker.Shutdown()
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	code, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/response"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/service"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders : The response headers stored with an idempotent response, besides its body.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotent : Wraps a mutating route so that requests with an Idempotency-Key header are handled once per
+// client and key. Retries with the same method, path and body get the stored response; the same key with a
+// different request gets 422, and a retry that arrives while the first request is still handled gets 409.
+// Requests without the header are handled as usual.
+func (handler *TaskHandler) idempotent(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(HeaderIdempotencyKey)
+		if handler.idempotency == nil || idempotencyKey == "" {
+			next(c)
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			response.BadRequest(c, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.BadRequest(c, "The request body could not be read")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		key := scopedIdempotencyKey(middleware.ClientID(c), idempotencyKey)
+		stored, err := handler.idempotency.Begin(ctx, key, requestFingerprint(c.Request, body))
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			response.Error(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			c.Header(middleware.HeaderRetryAfter, "1")
+			response.Conflict(c, "A request with this Idempotency-Key is still being processed, retry later")
+			return
+		case err != nil:
+			response.InternalServerError(c)
+			return
+		case stored != nil:
+			for _, name := range replayedHeaders {
+				if value := stored.Header.Get(name); value != "" {
+					c.Header(name, value)
+				}
+			}
+			c.Header(HeaderIdempotentReplayed, "true")
+			c.Data(stored.StatusCode, stored.Header.Get("Content-Type"), stored.Body)
+			return
+		}
+
+		// The outcome is stored even when the client has gone away, since that is when it retries.
+		ctx = context.WithoutCancel(ctx)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			c.Writer = recorder.ResponseWriter
+			if r := recover(); r != nil {
+				_ = handler.idempotency.Release(ctx, key)
+				panic(r)
+			}
+		}()
+
+		next(c)
+
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		res := &service.IdempotentResponse{StatusCode: recorder.Status(), Header: header, Body: recorder.body.Bytes()}
+		if err := handler.idempotency.Complete(ctx, key, res); err != nil {
+			loggingtype.ForPackage("http").ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// scopedIdempotencyKey : Keys are chosen by clients, so each client gets keys of its own.
+func scopedIdempotencyKey(clientID, idempotencyKey string) string {
+	hash := sha256.Sum256([]byte(clientID + "\x00" + idempotencyKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// requestFingerprint : JSON bodies are compared by value, so a retry that serialises the same input with
+// other whitespace or key order is still the same request.
+func requestFingerprint(r *http.Request, body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		if canonical, err := json.Marshal(value); err == nil {
+			body = canonical
+		}
+	}
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder : Keeps a copy of the body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
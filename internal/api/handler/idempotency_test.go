@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/openapi"
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newIdempotentTaskRouter(t *testing.T, middlewares ...gin.HandlerFunc) (*gin.Engine, *fakeTaskRepository, *service.IdempotencyService) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	keyRepo, err := repository.NewGormIdempotencyKeyRepository(db.Db)
+	require.NoError(t, err)
+	idempotency := service.NewIdempotencyService(keyRepo, time.Hour, time.Minute)
+
+	repo := &fakeTaskRepository{tasks: map[string]*model.Task{}}
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(eventbus.NewNoopBus()))
+	router := gin.New()
+	router.Use(middlewares...)
+	NewTaskHandler(taskService, WithIdempotency(idempotency)).RegisterRoutes(router)
+	return router, repo, idempotency
+}
+
+func sendIdempotent(router *gin.Engine, method, path, apiKey, idempotencyKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.HeaderAPIKey, apiKey)
+	if idempotencyKey != "" {
+		req.Header.Set(HeaderIdempotencyKey, idempotencyKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTaskHandler_IdempotentCreate(t *testing.T) {
+	router, repo, _ := newIdempotentTaskRouter(t, middleware.APIKeyAuth([]string{"key-a", "key-b"}))
+
+	first := sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "create-1", `{"title":"Pay invoice","description":"4711"}`)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+
+	retry := sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "create-1", "{\n  \"description\": \"4711\",\n  \"title\": \"Pay invoice\"\n}")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Len(t, repo.tasks, 1, "the retry didn't create another task")
+
+	reused := sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "create-1", `{"title":"Something else"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Contains(t, reused.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+	other := sendIdempotent(router, http.MethodPost, "/tasks", "key-b", "create-1", `{"title":"Something else"}`)
+	assert.Equal(t, http.StatusCreated, other.Code, "keys are scoped to the client")
+
+	assert.Equal(t, http.StatusCreated, sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "", `{"title":"Pay invoice"}`).Code)
+	assert.Equal(t, http.StatusCreated, sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "", `{"title":"Pay invoice"}`).Code)
+	assert.Len(t, repo.tasks, 4, "requests without a key are not deduplicated")
+}
+
+func TestTaskHandler_IdempotentUpdateAndDelete(t *testing.T) {
+	router, repo, _ := newIdempotentTaskRouter(t, middleware.APIKeyAuth([]string{"key-a", "key-b"}))
+	task := model.NewTask("Board card", "")
+	require.NoError(t, repo.Create(context.Background(), task))
+
+	update := sendIdempotent(router, http.MethodPut, "/tasks/"+task.ID, "key-a", "update-1", `{"status":"in_progress"}`)
+	require.Equal(t, http.StatusOK, update.Code, update.Body.String())
+	replayed := sendIdempotent(router, http.MethodPut, "/tasks/"+task.ID, "key-a", "update-1", `{"status":"in_progress"}`)
+	assert.Equal(t, update.Body.String(), replayed.Body.String())
+	assert.Equal(t, "true", replayed.Header().Get(HeaderIdempotentReplayed))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, sendIdempotent(router, http.MethodDelete, "/tasks/"+task.ID, "key-a", "update-1", "").Code,
+		"the same key on another route is another request")
+
+	assert.Equal(t, http.StatusNoContent, sendIdempotent(router, http.MethodDelete, "/tasks/"+task.ID, "key-a", "delete-1", "").Code)
+	deleted := sendIdempotent(router, http.MethodDelete, "/tasks/"+task.ID, "key-a", "delete-1", "")
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, "true", deleted.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestOpenAPI_IdempotencyResponsesMatchDocument(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validation, err := middleware.RequestValidation(doc)
+	require.NoError(t, err)
+	router, _, idempotency := newIdempotentTaskRouter(t, validation)
+
+	send := func(key string, body map[string]interface{}) int {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(HeaderIdempotencyKey, key)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		docRouter, err := legacy.NewRouter(doc)
+		require.NoError(t, err)
+		route, pathParams, err := docRouter.FindRoute(req)
+		require.NoError(t, err)
+		err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+			Status:                 recorder.Code,
+			Header:                 recorder.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(recorder.Body.Bytes())),
+		})
+		assert.NoError(t, err, "responded %d %s", recorder.Code, recorder.Body.String())
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, send("create-1", map[string]interface{}{"title": "Pay invoice"}))
+	assert.Equal(t, http.StatusCreated, send("create-1", map[string]interface{}{"title": "Pay invoice"}))
+	assert.Equal(t, http.StatusUnprocessableEntity, send("create-1", map[string]interface{}{"title": "Other"}))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	_, err = idempotency.Begin(context.Background(), scopedIdempotencyKey("", "create-2"), requestFingerprint(req, []byte(`{"title":"Pay invoice"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, send("create-2", map[string]interface{}{"title": "Pay invoice"}))
+}
+
+func TestTaskHandler_IdempotencyKeyInProgress(t *testing.T) {
+	router, repo, idempotency := newIdempotentTaskRouter(t, middleware.APIKeyAuth([]string{"key-a", "key-b"}))
+	router.GET("/client", func(c *gin.Context) { c.String(http.StatusOK, middleware.ClientID(c)) })
+	clientID := sendIdempotent(router, http.MethodGet, "/client", "key-a", "", "").Body.String()
+	require.NotEmpty(t, clientID)
+
+	// Another instance is still handling the first attempt.
+	body := `{"title":"Pay invoice"}`
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	stored, err := idempotency.Begin(context.Background(), scopedIdempotencyKey(clientID, "create-1"), requestFingerprint(req, []byte(body)))
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	w := sendIdempotent(router, http.MethodPost, "/tasks", "key-a", "create-1", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get(middleware.HeaderRetryAfter))
+	assert.Empty(t, repo.tasks)
+
+	assert.Equal(t, http.StatusBadRequest, sendIdempotent(router, http.MethodPost, "/tasks", "key-a", strings.Repeat("k", 256), body).Code)
+}
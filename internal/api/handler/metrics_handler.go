@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler : Serves /metrics in the Prometheus text format. It is public, like /ping, so scrapers
+// don't need an API key.
+type MetricsHandler struct {
+	gatherer prometheus.Gatherer
+}
+
+func NewMetricsHandler(gatherer prometheus.Gatherer) *MetricsHandler {
+	return &MetricsHandler{gatherer: gatherer}
+}
+
+func (handler *MetricsHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(handler.gatherer, promhttp.HandlerOpts{})))
+}
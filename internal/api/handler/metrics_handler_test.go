@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(metrics.NewTaskCollector(func(context.Context) (map[string]int, error) {
+		return map[string]int{"pending": 2}, nil
+	}, []string{"pending"})))
+
+	router := gin.New()
+	router.Use(middleware.Metrics())
+	NewMetricsHandler(prometheus.Gatherers{prometheus.DefaultGatherer, registry}).RegisterRoutes(router)
+	router.GET("/widgets/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	for _, target := range []string{"/widgets/1", "/widgets/2", "/no/such/route"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `task_manager_http_requests_total{method="GET",route="/widgets/:id",status="404"} 2`)
+	assert.Contains(t, body, `task_manager_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `task_manager_http_request_duration_seconds_count{method="GET",route="/widgets/:id",status="404"} 2`)
+	assert.Contains(t, body, `task_manager_tasks{status="pending"} 2`)
+	assert.Contains(t, body, "go_goroutines")
+}
@@ -4,37 +4,55 @@ import (
 	"alle-task-manager-gunish/internal/api/response"
 	"alle-task-manager-gunish/internal/common/errors"
 	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/domain/model"
 	"alle-task-manager-gunish/internal/service"
+	stderrors "errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type TaskHandler struct {
 	taskService *service.TaskService
+	idempotency *service.IdempotencyService
 }
 
-func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
-	return &TaskHandler{
+type TaskHandlerOption func(*TaskHandler)
+
+// WithIdempotency : Honours the Idempotency-Key header on the routes that create, update and delete tasks.
+func WithIdempotency(idempotency *service.IdempotencyService) TaskHandlerOption {
+	return func(handler *TaskHandler) {
+		handler.idempotency = idempotency
+	}
+}
+
+func NewTaskHandler(taskService *service.TaskService, options ...TaskHandlerOption) *TaskHandler {
+	handler := &TaskHandler{
 		taskService: taskService,
 	}
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
 }
 
 func (handler *TaskHandler) RegisterRoutes(router *gin.Engine) {
 	tasks := router.Group("/tasks")
 	{
 		tasks.GET("", handler.ListTasks)
-		tasks.POST("", handler.CreateTask)
+		tasks.POST("", handler.idempotent(handler.CreateTask))
+		tasks.GET("/changes", handler.ListChanges)
 		tasks.GET("/:id", handler.GetTask)
-		tasks.PUT("/:id", handler.UpdateTask)
-		tasks.DELETE("/:id", handler.DeleteTask)
+		tasks.PUT("/:id", handler.idempotent(handler.UpdateTask))
+		tasks.DELETE("/:id", handler.idempotent(handler.DeleteTask))
 	}
 }
 
 func (handler *TaskHandler) CreateTask(c *gin.Context) {
 	var input service.CreateTaskInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		response.BadRequest(c, "Invalid request payload: "+err.Error())
+		response.InvalidPayload(c, err)
 		return
 	}
 
@@ -49,7 +67,21 @@ func (handler *TaskHandler) CreateTask(c *gin.Context) {
 
 func (handler *TaskHandler) GetTask(c *gin.Context) {
 	id := c.Param("id")
-	task, err := handler.taskService.GetTask(c.Request.Context(), id)
+
+	var (
+		task *model.Task
+		err  error
+	)
+	if asOf := c.Query("as_of"); asOf != "" {
+		timestamp, parseErr := time.Parse(time.RFC3339Nano, asOf)
+		if parseErr != nil {
+			response.BadRequest(c, "Invalid as_of timestamp, expected RFC 3339")
+			return
+		}
+		task, err = handler.taskService.GetTaskAsOf(c.Request.Context(), id, timestamp)
+	} else {
+		task, err = handler.taskService.GetTask(c.Request.Context(), id)
+	}
 	if err != nil {
 		handler.handleError(c, err)
 		return
@@ -62,7 +94,7 @@ func (handler *TaskHandler) UpdateTask(c *gin.Context) {
 	id := c.Param("id")
 	var input service.UpdateTaskInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		response.BadRequest(c, "Invalid request payload: "+err.Error())
+		response.InvalidPayload(c, err)
 		return
 	}
 
@@ -114,7 +146,28 @@ func (handler *TaskHandler) ListTasks(c *gin.Context) {
 	response.SuccessWithPagination(c, tasks, pageInfo)
 }
 
+// ListChanges : Delta sync. Returns the tasks created, updated or deleted since the given token.
+func (handler *TaskHandler) ListChanges(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultChangesLimit)))
+	if err != nil || limit < 1 || limit > service.MaxChangesLimit {
+		response.BadRequest(c, "limit must be between 1 and "+strconv.Itoa(service.MaxChangesLimit))
+		return
+	}
+
+	changes, err := handler.taskService.ListChanges(c.Request.Context(), c.Query("since"), limit)
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Success(c, changes)
+}
+
 func (handler *TaskHandler) handleError(c *gin.Context, err error) {
+	if stderrors.Is(err, errors.ErrValidation) {
+		response.BadRequest(c, err.Error())
+		return
+	}
 	switch err {
 	case errors.ErrNotFound:
 		response.NotFound(c, "Task not found")
@@ -122,6 +175,10 @@ func (handler *TaskHandler) handleError(c *gin.Context, err error) {
 		response.BadRequest(c, "Task with this ID already exists")
 	case errors.ErrInvalidStatus:
 		response.BadRequest(c, "Invalid task status")
+	case errors.ErrConflict:
+		response.Conflict(c, "Task was modified concurrently, retry the request")
+	case errors.ErrEventSourcingDisabled:
+		response.BadRequest(c, "as_of requires event sourcing to be enabled")
 	default:
 		response.InternalServerError(c)
 	}
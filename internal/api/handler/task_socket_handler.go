@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/response"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketUpdate      = "update"
+	SocketAck         = "ack"
+	SocketEvent       = "event"
+	SocketError       = "error"
+
+	socketWriteWait      = 10 * time.Second
+	socketPongWait       = 60 * time.Second
+	socketPingPeriod     = socketPongWait * 9 / 10
+	socketMaxMessageSize = 64 * 1024
+)
+
+// SocketRequest : A message from the client. ID is echoed in the ack or error that answers it.
+type SocketRequest struct {
+	Type         string                   `json:"type"`
+	ID           string                   `json:"id,omitempty"`
+	Subscription string                   `json:"subscription,omitempty"`
+	TaskIDs      []string                 `json:"task_ids,omitempty"`
+	Status       string                   `json:"status,omitempty"`
+	TaskID       string                   `json:"task_id,omitempty"`
+	Changes      *service.UpdateTaskInput `json:"changes,omitempty"`
+}
+
+// SocketMessage : A message to the client: an ack or error answering a request, or an event matching a subscription.
+type SocketMessage struct {
+	Type          string          `json:"type"`
+	ID            string          `json:"id,omitempty"`
+	Subscriptions []string        `json:"subscriptions,omitempty"`
+	Subscription  string          `json:"subscription,omitempty"`
+	EventID       string          `json:"event_id,omitempty"`
+	EventType     string          `json:"event_type,omitempty"`
+	TaskID        string          `json:"task_id,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	Task          *model.Task     `json:"task,omitempty"`
+	Error         *response.Err   `json:"error,omitempty"`
+}
+
+type socketFilter struct {
+	taskIDs map[string]bool
+	stream  service.TaskStreamFilter
+}
+
+func (f socketFilter) matches(event *service.TaskStreamEvent) bool {
+	if len(f.taskIDs) > 0 && !f.taskIDs[event.TaskID] {
+		return false
+	}
+	return f.stream.Matches(event)
+}
+
+type TaskSocketHandler struct {
+	taskService *service.TaskService
+	broadcaster *service.TaskStreamBroadcaster
+	upgrader    websocket.Upgrader
+	sendBuffer  int
+}
+
+// NewTaskSocketHandler : With no allowed origins only same-origin handshakes are accepted; "*" allows any origin.
+func NewTaskSocketHandler(taskService *service.TaskService, broadcaster *service.TaskStreamBroadcaster, allowedOrigins []string, sendBuffer int) *TaskSocketHandler {
+	handler := &TaskSocketHandler{
+		taskService: taskService,
+		broadcaster: broadcaster,
+		sendBuffer:  sendBuffer,
+	}
+	handler.upgrader.CheckOrigin = checkOrigin(allowedOrigins)
+	return handler
+}
+
+// checkOrigin : A WebSocket origin check for the allowed origins. It is nil, which makes the upgrader only
+// accept same-origin handshakes, when none are configured.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	var origins []string
+	for _, origin := range allowedOrigins {
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		return nil
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		for _, allowed := range origins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (handler *TaskSocketHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/ws", handler.ServeSocket)
+}
+
+func (handler *TaskSocketHandler) ServeSocket(c *gin.Context) {
+	conn, err := handler.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response.
+		loggingtype.ForPackage("http").Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	socket := &taskSocket{
+		handler:       handler,
+		conn:          conn,
+		send:          make(chan SocketMessage, handler.sendBuffer),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]socketFilter),
+	}
+	socket.run(context.WithoutCancel(c.Request.Context()))
+}
+
+// taskSocket : One WebSocket connection. A reader handles requests, a writer owns all writes to the
+// connection, and a pump forwards task events. Replies wait for room in the send buffer, which stops
+// reading from a client that doesn't read its replies; events never wait, and a client that lets the
+// buffer fill up is disconnected.
+type taskSocket struct {
+	handler   *TaskSocketHandler
+	conn      *websocket.Conn
+	send      chan SocketMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	mu            sync.RWMutex
+	subscriptions map[string]socketFilter
+}
+
+func (s *taskSocket) run(ctx context.Context) {
+	events, _, _ := s.handler.broadcaster.Subscribe("", service.TaskStreamFilter{})
+	defer s.handler.broadcaster.Unsubscribe(events)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.writeLoop()
+	}()
+	go func() {
+		defer wg.Done()
+		s.pumpEvents(events)
+	}()
+	s.readLoop(ctx)
+	s.close(websocket.CloseNormalClosure, "")
+	wg.Wait()
+	_ = s.conn.Close()
+}
+
+func (s *taskSocket) close(code int, text string) {
+	s.closeOnce.Do(func() {
+		s.closeCode, s.closeText = code, text
+		close(s.done)
+	})
+}
+
+func (s *taskSocket) readLoop(ctx context.Context) {
+	s.conn.SetReadLimit(socketMaxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		var request SocketRequest
+		if err := s.conn.ReadJSON(&request); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				if !s.reply(SocketMessage{Type: SocketError, Error: &response.Err{Code: "BAD_REQUEST", Message: "Invalid message: " + response.BindErrorMessage(err)}}) {
+					return
+				}
+				continue
+			}
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				loggingtype.ForPackage("http").Warn("WebSocket read failed", "error", err)
+			}
+			return
+		}
+		if !s.reply(s.handle(ctx, &request)) {
+			return
+		}
+	}
+}
+
+func (s *taskSocket) handle(ctx context.Context, request *SocketRequest) SocketMessage {
+	switch request.Type {
+	case SocketSubscribe:
+		filter := socketFilter{stream: service.TaskStreamFilter{Status: strings.ToLower(request.Status)}}
+		if len(request.TaskIDs) > 0 {
+			filter.taskIDs = make(map[string]bool, len(request.TaskIDs))
+			for _, id := range request.TaskIDs {
+				filter.taskIDs[id] = true
+			}
+		}
+		id := uuid.New().String()
+		s.mu.Lock()
+		s.subscriptions[id] = filter
+		s.mu.Unlock()
+		return SocketMessage{Type: SocketAck, ID: request.ID, Subscription: id}
+	case SocketUnsubscribe:
+		s.mu.Lock()
+		_, ok := s.subscriptions[request.Subscription]
+		delete(s.subscriptions, request.Subscription)
+		s.mu.Unlock()
+		if !ok {
+			return socketError(request.ID, "NOT_FOUND", "Unknown subscription")
+		}
+		return SocketMessage{Type: SocketAck, ID: request.ID, Subscription: request.Subscription}
+	case SocketUpdate:
+		if request.TaskID == "" || request.Changes == nil {
+			return socketError(request.ID, "BAD_REQUEST", "update requires task_id and changes")
+		}
+		task, err := s.handler.taskService.UpdateTask(ctx, request.TaskID, *request.Changes)
+		if err != nil {
+			return socketTaskError(request.ID, err)
+		}
+		return SocketMessage{Type: SocketAck, ID: request.ID, Task: task}
+	default:
+		return socketError(request.ID, "BAD_REQUEST", "Unknown message type "+request.Type)
+	}
+}
+
+// reply : Queues a reply, waiting for room in the send buffer. Returns false once the socket is closing.
+func (s *taskSocket) reply(message SocketMessage) bool {
+	select {
+	case s.send <- message:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *taskSocket) pumpEvents(events *service.TaskStreamSubscription) {
+	for {
+		select {
+		case event, ok := <-events.Events():
+			if !ok {
+				s.close(websocket.CloseTryAgainLater, "too far behind, reconnect and resync")
+				return
+			}
+			s.mu.RLock()
+			var matched []string
+			for id, filter := range s.subscriptions {
+				if filter.matches(event) {
+					matched = append(matched, id)
+				}
+			}
+			s.mu.RUnlock()
+			if len(matched) == 0 {
+				continue
+			}
+
+			message := SocketMessage{Type: SocketEvent, Subscriptions: matched, EventID: event.ID, EventType: event.Type, TaskID: event.TaskID, Data: event.Data}
+			select {
+			case s.send <- message:
+			case <-s.done:
+				return
+			default:
+				s.close(websocket.CloseTryAgainLater, "send buffer full, reconnect and resync")
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *taskSocket) writeLoop() {
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case message := <-s.send:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := s.conn.WriteJSON(message); err != nil {
+				s.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				s.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-s.done:
+			if s.closeCode != websocket.CloseAbnormalClosure {
+				_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(s.closeCode, s.closeText), time.Now().Add(socketWriteWait))
+			}
+			// Unblocks the reader when the close was initiated here.
+			_ = s.conn.SetReadDeadline(time.Now())
+			return
+		}
+	}
+}
+
+func socketError(id, code, message string) SocketMessage {
+	return SocketMessage{Type: SocketError, ID: id, Error: &response.Err{Code: code, Message: message}}
+}
+
+func socketTaskError(id string, err error) SocketMessage {
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return socketError(id, "NOT_FOUND", "Task not found")
+	case errors.Is(err, apperrors.ErrInvalidStatus):
+		return socketError(id, "BAD_REQUEST", "Invalid task status")
+	case errors.Is(err, apperrors.ErrConflict):
+		return socketError(id, "CONFLICT", "Task was modified concurrently, retry the request")
+	default:
+		loggingtype.ForPackage("http").Error("WebSocket task update failed", "error", err)
+		return socketError(id, "INTERNAL_SERVER_ERROR", "An unexpected error occurred")
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/common/pagination"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTaskRepository struct {
+	mu    sync.Mutex
+	tasks map[string]*model.Task
+}
+
+func (r *fakeTaskRepository) Create(_ context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *task
+	r.tasks[task.ID] = &copied
+	return nil
+}
+
+func (r *fakeTaskRepository) GetByID(_ context.Context, id string) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *task
+	return &copied, nil
+}
+
+func (r *fakeTaskRepository) Update(ctx context.Context, task *model.Task) error {
+	if _, err := r.GetByID(ctx, task.ID); err != nil {
+		return err
+	}
+	return r.Create(ctx, task)
+}
+
+func (r *fakeTaskRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tasks, id)
+	return nil
+}
+
+// List : Ignores the filter and page.
+func (r *fakeTaskRepository) List(context.Context, map[string]interface{}, *pagination.Page) ([]*model.Task, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tasks := make([]*model.Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		copied := *task
+		tasks = append(tasks, &copied)
+	}
+	return tasks, len(tasks), nil
+}
+
+func (r *fakeTaskRepository) ListChangedSince(context.Context, int64, int, bool) ([]*model.Task, error) {
+	return nil, nil
+}
+
+func (r *fakeTaskRepository) PurgeDeleted(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeTaskRepository) PurgedThrough(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeTaskRepository) CountByStatus(context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+func newSocketTestServer(t *testing.T, sendBuffer int) (*httptest.Server, *service.TaskStreamBroadcaster, *fakeTaskRepository) {
+	gin.SetMode(gin.TestMode)
+	repo := &fakeTaskRepository{tasks: map[string]*model.Task{}}
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth([]string{"secret-key"}))
+	NewTaskSocketHandler(taskService, broadcaster, nil, sendBuffer).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, broadcaster, repo
+}
+
+func dialSocket(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws"+query, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readSocket(t *testing.T, conn *websocket.Conn) SocketMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var message SocketMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestTaskSocketHandler_RequiresAPIKey(t *testing.T) {
+	server, _, _ := newSocketTestServer(t, 8)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestTaskSocketHandler_SubscribeAndUpdate(t *testing.T) {
+	server, _, repo := newSocketTestServer(t, 8)
+	task := model.NewTask("Board card", "")
+	require.NoError(t, repo.Create(context.Background(), task))
+	conn := dialSocket(t, server, "?api_key=secret-key")
+
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketSubscribe, ID: "1", TaskIDs: []string{task.ID}}))
+	subscribed := readSocket(t, conn)
+	assert.Equal(t, SocketAck, subscribed.Type)
+	assert.Equal(t, "1", subscribed.ID)
+	require.NotEmpty(t, subscribed.Subscription)
+
+	status := string(model.InProgress)
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketUpdate, ID: "2", TaskID: task.ID, Changes: &service.UpdateTaskInput{Status: &status}}))
+
+	var ack, event SocketMessage
+	for _, message := range []SocketMessage{readSocket(t, conn), readSocket(t, conn)} {
+		if message.Type == SocketAck {
+			ack = message
+		} else {
+			event = message
+		}
+	}
+	assert.Equal(t, "2", ack.ID)
+	require.NotNil(t, ack.Task)
+	assert.Equal(t, model.InProgress, ack.Task.Status)
+
+	assert.Equal(t, SocketEvent, event.Type)
+	assert.Equal(t, events.EventTypeTaskUpdated, event.EventType)
+	assert.Equal(t, task.ID, event.TaskID)
+	assert.Equal(t, []string{subscribed.Subscription}, event.Subscriptions)
+
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketUpdate, ID: "3", TaskID: "missing", Changes: &service.UpdateTaskInput{Status: &status}}))
+	failed := readSocket(t, conn)
+	assert.Equal(t, SocketError, failed.Type)
+	assert.Equal(t, "3", failed.ID)
+	assert.Equal(t, "NOT_FOUND", failed.Error.Code)
+
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketUnsubscribe, ID: "4", Subscription: subscribed.Subscription}))
+	assert.Equal(t, SocketAck, readSocket(t, conn).Type)
+}
+
+func TestTaskSocketHandler_FiltersEvents(t *testing.T) {
+	server, broadcaster, _ := newSocketTestServer(t, 8)
+	conn := dialSocket(t, server, "?api_key=secret-key")
+
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketSubscribe, ID: "1", Status: "completed"}))
+	require.Equal(t, SocketAck, readSocket(t, conn).Type)
+
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "e1", TaskID: "a", Statuses: []string{"pending"}, Data: []byte(`{}`)})
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "e2", TaskID: "b", Statuses: []string{"completed"}, Data: []byte(`{}`)})
+
+	event := readSocket(t, conn)
+	assert.Equal(t, "e2", event.EventID)
+}
+
+func TestTaskSocketHandler_DisconnectsSlowClients(t *testing.T) {
+	server, broadcaster, _ := newSocketTestServer(t, 1)
+	conn := dialSocket(t, server, "?api_key=secret-key")
+
+	require.NoError(t, conn.WriteJSON(SocketRequest{Type: SocketSubscribe, ID: "1"}))
+	require.Equal(t, SocketAck, readSocket(t, conn).Type)
+
+	// The client stops reading while events keep coming.
+	for i := 0; i < 2000; i++ {
+		broadcaster.Broadcast(&service.TaskStreamEvent{ID: "e", TaskID: "a", Data: []byte(`{"padding":"` + strings.Repeat("x", 1024) + `"}`)})
+	}
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		var message SocketMessage
+		err := conn.ReadJSON(&message)
+		if err == nil {
+			continue
+		}
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), "unexpected error %v", err)
+		return
+	}
+}
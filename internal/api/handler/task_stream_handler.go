@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/service"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const sseRetryMillis = 3000
+
+type TaskStreamHandler struct {
+	broadcaster       *service.TaskStreamBroadcaster
+	heartbeatInterval time.Duration
+}
+
+func NewTaskStreamHandler(broadcaster *service.TaskStreamBroadcaster, heartbeatInterval time.Duration) *TaskStreamHandler {
+	return &TaskStreamHandler{
+		broadcaster:       broadcaster,
+		heartbeatInterval: heartbeatInterval,
+	}
+}
+
+func (handler *TaskStreamHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/tasks/stream", handler.StreamTasks)
+}
+
+// StreamTasks : Server-Sent Events feed of task changes. Accepts the status filter of GET /tasks and resumes
+// after the Last-Event-ID header (or last_event_id query parameter). When the requested event is no longer
+// buffered the stream starts with a "reset" event telling the client to reload; new clients, which send no
+// ID, start with live events only.
+func (handler *TaskStreamHandler) StreamTasks(c *gin.Context) {
+	filter := service.TaskStreamFilter{Status: strings.ToLower(c.Query("status"))}
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	subscription, missed, resumed := handler.broadcaster.Subscribe(lastEventID, filter)
+	defer handler.broadcaster.Unsubscribe(subscription)
+
+	// Streams outlive the server's write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	if lastEventID != "" && !resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeStreamEvent(w, event)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(handler.heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			writeStreamEvent(w, event)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			w.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w io.Writer, event *service.TaskStreamEvent) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
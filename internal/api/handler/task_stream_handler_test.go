@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/service"
+	"bufio"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readStreamLines(t *testing.T, reader *bufio.Reader, until string) []string {
+	var lines []string
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		lines = append(lines, line)
+		if line == until {
+			return lines
+		}
+	}
+	t.Fatalf("did not receive %q, got %v", until, lines)
+	return nil
+}
+
+func TestTaskStreamHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	broadcaster := service.NewTaskStreamBroadcaster(10, 8)
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "1", Type: events.EventTypeTaskCreated, Statuses: []string{"pending"}, Data: []byte(`{"task_id":"a"}`)})
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "2", Type: events.EventTypeTaskCreated, Statuses: []string{"pending"}, Data: []byte(`{"task_id":"b"}`)})
+
+	router := gin.New()
+	NewTaskHandler(nil).RegisterRoutes(router)
+	NewTaskStreamHandler(broadcaster, 20*time.Millisecond).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/tasks/stream?status=PENDING", nil)
+	require.NoError(t, err)
+	request.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	lines := readStreamLines(t, reader, `data: {"task_id":"b"}`)
+	assert.Contains(t, lines, "id: 2")
+	assert.Contains(t, lines, "event: TASK_CREATED")
+	assert.NotContains(t, lines, "event: reset")
+
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "3", Type: events.EventTypeTaskDeleted, Statuses: []string{"completed"}, Data: []byte(`{"task_id":"c"}`)})
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "4", Type: events.EventTypeTaskUpdated, Statuses: []string{"pending"}, Data: []byte(`{"task_id":"d"}`)})
+	lines = readStreamLines(t, reader, `data: {"task_id":"d"}`)
+	assert.NotContains(t, lines, "id: 3")
+
+	readStreamLines(t, reader, ": heartbeat")
+}
+
+func TestTaskStreamHandler_Reset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	broadcaster := service.NewTaskStreamBroadcaster(1, 8)
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "1", Type: events.EventTypeTaskCreated, Statuses: []string{"pending"}, Data: []byte(`{"task_id":"a"}`)})
+	broadcaster.Broadcast(&service.TaskStreamEvent{ID: "2", Type: events.EventTypeTaskCreated, Statuses: []string{"pending"}, Data: []byte(`{"task_id":"b"}`)})
+
+	router := gin.New()
+	NewTaskStreamHandler(broadcaster, 20*time.Millisecond).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	stream := func(lastEventID string) []string {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/tasks/stream", nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			request.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return readStreamLines(t, bufio.NewReader(resp.Body), ": heartbeat")
+	}
+
+	assert.NotContains(t, stream(""), "event: reset", "a new client has nothing to reload")
+	assert.NotContains(t, stream("2"), "event: reset", "event 2 is still buffered")
+	assert.Contains(t, stream("1"), "event: reset", "event 1 has aged out of the buffer")
+}
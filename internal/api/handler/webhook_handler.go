@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"alle-task-manager-gunish/internal/api/response"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// createdWebhook : The create response is the only one that includes the signing secret.
+type createdWebhook struct {
+	*model.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (handler *WebhookHandler) RegisterRoutes(router *gin.Engine) {
+	webhooks := router.Group("/webhooks")
+	{
+		webhooks.GET("", handler.ListWebhooks)
+		webhooks.POST("", handler.CreateWebhook)
+		webhooks.GET("/:id", handler.GetWebhook)
+		webhooks.PUT("/:id", handler.UpdateWebhook)
+		webhooks.DELETE("/:id", handler.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", handler.ListDeliveries)
+	}
+}
+
+func (handler *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var input service.CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.InvalidPayload(c, err)
+		return
+	}
+
+	subscription, secret, err := handler.webhookService.CreateSubscription(c.Request.Context(), input)
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Created(c, createdWebhook{WebhookSubscription: subscription, Secret: secret})
+}
+
+func (handler *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := handler.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Success(c, subscriptions)
+}
+
+func (handler *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, err := handler.webhookService.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Success(c, subscription)
+}
+
+func (handler *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var input service.UpdateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.InvalidPayload(c, err)
+		return
+	}
+
+	subscription, err := handler.webhookService.UpdateSubscription(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Success(c, subscription)
+}
+
+func (handler *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := handler.webhookService.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (handler *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		response.BadRequest(c, "limit must be a positive number")
+		return
+	}
+
+	deliveries, err := handler.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		handler.handleError(c, err)
+		return
+	}
+
+	response.Success(c, deliveries)
+}
+
+func (handler *WebhookHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		response.NotFound(c, "Webhook subscription not found")
+	case errors.Is(err, apperrors.ErrValidation):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalServerError(c)
+	}
+}
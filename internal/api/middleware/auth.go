@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/api/response"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderAPIKey   = "X-API-Key"
+	HeaderAdminKey = "X-Admin-Key"
+)
+
+// contextClientID : The gin context key APIKeyAuth stores the ID of the caller's API key under.
+const contextClientID = "client_id"
+
+// APIKeys : The set of accepted API keys, shared by the HTTP and gRPC APIs. Only hashes are kept, and keys
+// are compared in constant time.
+type APIKeys struct {
+	hashes [][32]byte
+}
+
+func NewAPIKeys(keys []string) *APIKeys {
+	hashes := make([][32]byte, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			hashes = append(hashes, sha256.Sum256([]byte(key)))
+		}
+	}
+	return &APIKeys{hashes: hashes}
+}
+
+// Enabled : Authentication is off when no keys are configured.
+func (k *APIKeys) Enabled() bool {
+	return len(k.hashes) > 0
+}
+
+func (k *APIKeys) Valid(key string) bool {
+	presented := sha256.Sum256([]byte(key))
+	for _, hash := range k.hashes {
+		if subtle.ConstantTimeCompare(presented[:], hash[:]) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyAuth : Requires one of the given keys in the X-API-Key header or as a bearer token. WebSocket
+// handshakes may pass it as the api_key query parameter instead, since browsers can't set headers on them.
+// With no keys configured every request is let through.
+func APIKeyAuth(keys []string) gin.HandlerFunc {
+	apiKeys := NewAPIKeys(keys)
+
+	return func(c *gin.Context) {
+		if !apiKeys.Enabled() {
+			c.Next()
+			return
+		}
+		if key := apiKeyFrom(c.Request); apiKeys.Valid(key) {
+			c.Set(contextClientID, keyID(key))
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Bearer realm="alle-task-manager"`)
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "A valid API key is required")
+		c.Abort()
+	}
+}
+
+// AdminKeyAuth : Requires one of the given keys in the X-Admin-Key header, for operations that change the
+// system's state. Unlike APIKeyAuth it is never open: with no keys configured every request is refused.
+func AdminKeyAuth(keys []string) gin.HandlerFunc {
+	adminKeys := NewAPIKeys(keys)
+
+	return func(c *gin.Context) {
+		if !adminKeys.Enabled() {
+			response.Error(c, http.StatusForbidden, "FORBIDDEN", "This endpoint is disabled until ADMIN_API_KEYS is configured")
+			c.Abort()
+			return
+		}
+		if adminKeys.Valid(c.GetHeader(HeaderAdminKey)) {
+			c.Next()
+			return
+		}
+
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "A valid admin key is required in the "+HeaderAdminKey+" header")
+		c.Abort()
+	}
+}
+
+// ClientID : Identifies the API key the request was authenticated with, without revealing it. It is empty
+// when authentication is off.
+func ClientID(c *gin.Context) string {
+	return c.GetString(contextClientID)
+}
+
+func keyID(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(hash[:8])
+}
+
+func apiKeyFrom(r *http.Request) string {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(keys []string) *gin.Engine {
+		router := gin.New()
+		router.Use(APIKeyAuth(keys))
+		router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+
+	tests := []struct {
+		name    string
+		keys    []string
+		headers map[string]string
+		query   string
+		status  int
+	}{
+		{name: "disabled without keys", keys: nil, status: http.StatusOK},
+		{name: "missing key", keys: []string{"k1"}, status: http.StatusUnauthorized},
+		{name: "X-API-Key header", keys: []string{"k1", "k2"}, headers: map[string]string{HeaderAPIKey: "k2"}, status: http.StatusOK},
+		{name: "bearer token", keys: []string{"k1"}, headers: map[string]string{"Authorization": "Bearer k1"}, status: http.StatusOK},
+		{name: "wrong key", keys: []string{"k1"}, headers: map[string]string{HeaderAPIKey: "nope"}, status: http.StatusUnauthorized},
+		{name: "query ignored outside websocket handshakes", keys: []string{"k1"}, query: "?api_key=k1", status: http.StatusUnauthorized},
+		{name: "query on websocket handshake", keys: []string{"k1"}, headers: map[string]string{"Upgrade": "websocket"}, query: "?api_key=k1", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks"+tt.query, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			newRouter(tt.keys).ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestAdminKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(keys []string) *gin.Engine {
+		router := gin.New()
+		router.POST("/admin/dlq/replay", AdminKeyAuth(keys), func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+
+	tests := []struct {
+		name    string
+		keys    []string
+		headers map[string]string
+		status  int
+	}{
+		{name: "refused without keys", keys: nil, headers: map[string]string{HeaderAdminKey: "anything"}, status: http.StatusForbidden},
+		{name: "missing key", keys: []string{"admin"}, status: http.StatusUnauthorized},
+		{name: "wrong key", keys: []string{"admin"}, headers: map[string]string{HeaderAdminKey: "nope"}, status: http.StatusUnauthorized},
+		{name: "API key header ignored", keys: []string{"admin"}, headers: map[string]string{HeaderAPIKey: "admin"}, status: http.StatusUnauthorized},
+		{name: "X-Admin-Key header", keys: []string{"admin"}, headers: map[string]string{HeaderAdminKey: "admin"}, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/dlq/replay", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			newRouter(tt.keys).ServeHTTP(recorder, req)
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func Logging() gin.HandlerFunc {
-	logger := loggingtype.GetLogger()
+	logger := loggingtype.ForPackage("http")
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -19,7 +19,7 @@ func Logging() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 
 		if statusCode >= 500 {
-			logger.Error("Request",
+			logger.ErrorContext(c.Request.Context(), "Request",
 				"method", method,
 				"path", path,
 				"status", statusCode,
@@ -27,7 +27,7 @@ func Logging() gin.HandlerFunc {
 				"client_ip", c.ClientIP(),
 			)
 		} else {
-			logger.Info("Request",
+			logger.InfoContext(c.Request.Context(), "Request",
 				"method", method,
 				"path", path,
 				"status", statusCode,
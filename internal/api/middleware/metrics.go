@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/common/metrics"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute : The route label of requests that matched no route, so unknown paths can't create new series.
+const unmatchedRoute = "unmatched"
+
+// Metrics : Counts requests and observes their latency by method, route template (such as /tasks/:id) and status.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/api/response"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimit : Limits requests per client and route. Clients are told apart by API key when apiKeys is enabled,
+// so clients behind one proxy don't share a limit, and by IP otherwise. Every limited response carries the
+// RateLimit-* headers; rejected ones get a 429 with Retry-After. Requests are let through when the store
+// fails, since the limiter shouldn't take the API down with it.
+func RateLimit(rules ratelimit.Rules, store ratelimit.Store, apiKeys *APIKeys) gin.HandlerFunc {
+	var failing atomic.Bool
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limit, bucket, ok := rules.For(route)
+		if !ok || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		result, err := store.Take(ctx, clientKey(c, apiKeys)+"|"+bucket, limit)
+		if err != nil {
+			if !failing.Swap(true) {
+				loggingtype.ForPackage("http").WarnContext(ctx, "Rate limit store failed, letting requests through", "error", err)
+			}
+			c.Next()
+			return
+		}
+		if failing.Swap(false) {
+			loggingtype.ForPackage("http").InfoContext(ctx, "Rate limit store recovered")
+		}
+
+		c.Header(HeaderRateLimitLimit, strconv.Itoa(limit.Requests))
+		c.Header(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		c.Header(HeaderRateLimitReset, strconv.Itoa(seconds(result.Reset)))
+		c.Header(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)))
+		if result.Allowed {
+			c.Next()
+			return
+		}
+
+		metrics.RateLimited.WithLabelValues(c.Request.Method, c.FullPath()).Inc()
+		retryAfter := seconds(result.RetryAfter)
+		c.Header(HeaderRetryAfter, strconv.Itoa(retryAfter))
+		response.Error(c, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", fmt.Sprintf("Rate limit of %d requests per %s exceeded, retry in %d seconds", limit.Requests, limit.Period, retryAfter))
+		c.Abort()
+	}
+}
+
+// clientKey : API keys are hashed so they never reach the store.
+func clientKey(c *gin.Context, apiKeys *APIKeys) string {
+	if apiKeys.Enabled() {
+		if key := apiKeyFrom(c.Request); key != "" && apiKeys.Valid(key) {
+			return keyID(key)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds : Rounded up, so clients that wait that long find a token.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules, err := ratelimit.ParseRules("", []string{"POST /tasks=2/m"})
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(RateLimit(rules, ratelimit.NewMemoryStore(), NewAPIKeys([]string{"k1", "k2"})))
+	router.POST("/tasks", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, key, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/tasks", nil)
+		req.RemoteAddr = ip + ":1234"
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "k1", "10.0.0.1")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "30", w.Header().Get(HeaderRateLimitReset))
+	assert.Equal(t, "2;w=60", w.Header().Get(HeaderRateLimitPolicy))
+	assert.Empty(t, w.Header().Get(HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusCreated, send(http.MethodPost, "k1", "10.0.0.2").Code)
+	w = send(http.MethodPost, "k1", "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "the limit follows the API key across IPs")
+	assert.Equal(t, "30", w.Header().Get(HeaderRetryAfter))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, "TOO_MANY_REQUESTS", body["error"].(map[string]interface{})["code"])
+
+	assert.Equal(t, http.StatusCreated, send(http.MethodPost, "k2", "10.0.0.3").Code, "other keys have their own limit")
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "k1", "10.0.0.3").Code, "routes without a limit are not limited")
+	assert.Empty(t, send(http.MethodGet, "k1", "10.0.0.3").Header().Get(HeaderRateLimitLimit))
+
+	assert.Equal(t, http.StatusCreated, send(http.MethodPost, "", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusCreated, send(http.MethodPost, "unknown", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, "", "10.0.0.9").Code, "without a valid key the limit follows the IP")
+}
+
+func TestRateLimit_StoreFailureLetsRequestsThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules, err := ratelimit.ParseRules("1/m", nil)
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(RateLimit(rules, failingStore{}, NewAPIKeys(nil)))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(HeaderRateLimitLimit))
+	}
+}
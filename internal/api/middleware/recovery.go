@@ -10,11 +10,11 @@ import (
 
 // Recovery : To catch any panics that might occur during request handling.
 func Recovery() gin.HandlerFunc {
-	logger := loggingtype.GetLogger()
+	logger := loggingtype.ForPackage("http")
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Error("Recovery from panic",
+				logger.ErrorContext(c.Request.Context(), "Recovery from panic",
 					"error", err,
 					"stack", string(debug.Stack()),
 				)
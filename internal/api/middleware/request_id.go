@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/common/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID : The header a caller may set to choose the ID of its request. The response always
+// carries it.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength : Longer IDs are replaced, so a caller cannot blow up every log line of its request.
+const maxRequestIDLength = 128
+
+// RequestID : Accepts the caller's X-Request-ID, or generates one when it is missing or not a short
+// printable token, and stores it in the request context where loggingtype and the task events pick it up.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := NormalizeRequestID(c.GetHeader(HeaderRequestID))
+		c.Request = c.Request.WithContext(loggingtype.WithRequestID(c.Request.Context(), id))
+		c.Header(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+// NormalizeRequestID : Returns value when it is a usable request ID, a new UUID otherwise.
+func NormalizeRequestID(value string) string {
+	if value == "" || len(value) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] <= ' ' || value[i] > '~' {
+			return uuid.NewString()
+		}
+	}
+	return value
+}
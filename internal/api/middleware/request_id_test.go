@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/common/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var seen string
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/tasks", func(c *gin.Context) {
+		seen = loggingtype.RequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	serve := func(header string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if header != "" {
+			request.Header.Set(HeaderRequestID, header)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("client-abc-123")
+	assert.Equal(t, "client-abc-123", seen, "a valid ID from the caller is kept")
+	assert.Equal(t, "client-abc-123", recorder.Header().Get(HeaderRequestID))
+
+	recorder = serve("")
+	assert.NoError(t, uuid.Validate(seen), "a missing ID is generated")
+	assert.Equal(t, seen, recorder.Header().Get(HeaderRequestID))
+
+	for _, invalid := range []string{"has space", "tab\there", strings.Repeat("x", maxRequestIDLength+1), "naïve"} {
+		serve(invalid)
+		assert.NotEqual(t, invalid, seen)
+		assert.NoError(t, uuid.Validate(seen), "%q is replaced", invalid)
+	}
+}
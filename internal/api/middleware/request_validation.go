@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/api/response"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// extensionLenientQuery : Marks operations whose query parameters are documented but not validated, because
+// their handlers normalise the values clients sent before the document existed.
+const extensionLenientQuery = "x-lenient-query"
+
+// RequestValidation : Rejects requests to documented operations whose parameters or body don't match the
+// OpenAPI document. Routes the document doesn't describe are let through, and authentication is left to
+// APIKeyAuth.
+func RequestValidation(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("building OpenAPI router: %w", err)
+	}
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+	lenientOptions := *options
+	lenientOptions.ExcludeRequestQueryParams = true
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if lenient, _ := route.Operation.Extensions[extensionLenientQuery].(bool); lenient {
+			input.Options = &lenientOptions
+		}
+		err = openapi3filter.ValidateRequest(c.Request.Context(), input)
+		if err != nil {
+			response.BadRequest(c, "Request does not match the API specification: "+validationMessage(err))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// validationMessage : One line per problem, without the schema dumps kin-openapi appends by default. Values
+// from the request are never quoted: they can be customer data.
+func validationMessage(err error) string {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+	messages := make([]string, 0, len(multi))
+	for _, err := range multi {
+		var requestErr *openapi3filter.RequestError
+		var schemaErr *openapi3.SchemaError
+		switch {
+		case errors.As(err, &schemaErr) && errors.As(err, &requestErr) && requestErr.Parameter != nil:
+			messages = append(messages, fmt.Sprintf("parameter %q %s", requestErr.Parameter.Name, schemaErr.Reason))
+		case errors.As(err, &schemaErr):
+			field := strings.Join(schemaErr.JSONPointer(), ".")
+			if field == "" {
+				field = "body"
+			}
+			messages = append(messages, fmt.Sprintf("%s: %s", field, schemaErr.Reason))
+		case errors.As(err, &requestErr) && requestErr.Parameter != nil:
+			messages = append(messages, fmt.Sprintf("parameter %q is invalid", requestErr.Parameter.Name))
+		case errors.As(err, &requestErr) && requestErr.RequestBody != nil && errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired):
+			messages = append(messages, "body: the body is empty")
+		case errors.As(err, &requestErr) && requestErr.RequestBody != nil:
+			messages = append(messages, "body: "+response.BindErrorMessage(requestErr.Err))
+		case errors.As(err, &requestErr):
+			messages = append(messages, requestErr.Error())
+		default:
+			messages = append(messages, err.Error())
+		}
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"net/http"
+)
+
+// Tracing : Starts a server span per request, named after the route template and continuing the W3C trace
+// context of the caller. Probes and metric scrapes are not traced. The server address is taken from
+// the Host header; the service name comes from the tracer provider's resource.
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware("", otelgin.WithFilter(func(r *http.Request) bool {
+		switch r.URL.Path {
+		case "/ping", "/healthz", "/readyz", "/metrics":
+			return false
+		default:
+			return true
+		}
+	}))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/tracing"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: tracing.ExporterMemory, ServiceName: "test", SampleRatio: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	var handledIn trace.SpanContext
+	router := gin.New()
+	router.Use(Tracing())
+	router.GET("/tasks/:id", func(c *gin.Context) {
+		handledIn = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := httptest.NewRequest(http.MethodGet, "/tasks/42", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	spans := provider.Spans()
+	require.Len(t, spans, 1, "/ping is not traced")
+	span := spans[0]
+	assert.Equal(t, "GET /tasks/:id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), "the caller's trace continues")
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, span.SpanContext.SpanID(), handledIn.SpanID(), "handlers see the request span in their context")
+}
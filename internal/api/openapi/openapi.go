@@ -0,0 +1,24 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load : Parses and validates the embedded OpenAPI document for the REST API. The document is written by hand;
+// TestOpenAPI_DocumentsEveryTaskRoute and TestOpenAPI_ResponsesMatchDocument keep it in step with the handlers.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validating OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// InvalidPayload : 400 for a request body that could not be bound.
+func InvalidPayload(c *gin.Context, err error) {
+	BadRequest(c, "Invalid request payload: "+BindErrorMessage(err))
+}
+
+// BindErrorMessage : Describes a JSON decoding or binding error by the field it is about. Unlike the error
+// text, it never quotes the body, which can hold customer data such as task descriptions.
+func BindErrorMessage(err error) string {
+	var (
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		timeErr        *time.ParseError
+		validationErrs validator.ValidationErrors
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return "the body is empty"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return "the body is not valid JSON"
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return "the body must be " + jsonType(typeErr.Type)
+		}
+		return fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type))
+	case errors.As(err, &timeErr):
+		return "timestamps must be RFC 3339 strings"
+	case errors.As(err, &validationErrs):
+		messages := make([]string, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			messages = append(messages, fmt.Sprintf("%s failed the %s check", strings.ToLower(fieldErr.Field()), fieldErr.Tag()))
+		}
+		return strings.Join(messages, "; ")
+	default:
+		return "the body does not match the expected fields"
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "an RFC 3339 string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
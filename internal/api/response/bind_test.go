@@ -0,0 +1,41 @@
+package response
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindErrorMessage(t *testing.T) {
+	type input struct {
+		Title       string     `json:"title" validate:"required"`
+		Description string     `json:"description"`
+		DueDate     *time.Time `json:"due_date"`
+	}
+	decode := func(body string) error {
+		var target input
+		return json.NewDecoder(strings.NewReader(body)).Decode(&target)
+	}
+
+	for body, expected := range map[string]string{
+		``:                                   "the body is empty",
+		`{"description": customer-data}`:     "the body is not valid JSON",
+		`{"description": "customer-data"`:    "the body is not valid JSON",
+		`{"description": ["customer-data"]}`: "description must be a string",
+		`["customer-data"]`:                  "the body must be an object",
+		`{"due_date": "customer-data"}`:      "timestamps must be RFC 3339 strings",
+	} {
+		err := decode(body)
+		message := BindErrorMessage(err)
+		assert.Equal(t, expected, message, body)
+		assert.NotContains(t, message, "customer")
+	}
+
+	err := validator.New().Struct(input{Description: "customer-data"})
+	assert.Equal(t, "title failed the required check", BindErrorMessage(err))
+	assert.Equal(t, "the body does not match the expected fields", BindErrorMessage(io.ErrClosedPipe))
+}
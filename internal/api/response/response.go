@@ -64,6 +64,10 @@ func BadRequest(c *gin.Context, message string) {
 	Error(c, http.StatusBadRequest, "BAD_REQUEST", message)
 }
 
+func Conflict(c *gin.Context, message string) {
+	Error(c, http.StatusConflict, "CONFLICT", message)
+}
+
 func InternalServerError(c *gin.Context) {
 	Error(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "An unexpected error occurred")
 }
@@ -6,10 +6,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(taskHandler *handler.TaskHandler) *gin.Engine {
+type RouteRegistrar interface {
+	RegisterRoutes(router *gin.Engine)
+}
+
+// SetupRouter : The given middlewares run on every route except /ping and the public ones, after tracing,
+// request IDs, logging, request metrics and panic recovery. No proxy is trusted, so the client IP is the
+// remote address and X-Forwarded-For can't be spoofed to dodge per-IP limits; call SetTrustedProxies on the
+// result to trust the proxies in front of the service.
+func SetupRouter(public []RouteRegistrar, middlewares []gin.HandlerFunc, taskHandler *handler.TaskHandler, registrars ...RouteRegistrar) *gin.Engine {
 	router := gin.New()
+	_ = router.SetTrustedProxies(nil)
 
+	router.Use(middleware.Tracing())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logging())
+	router.Use(middleware.Metrics())
 	router.Use(middleware.Recovery())
 
 	router.GET("/ping", func(c *gin.Context) {
@@ -18,7 +30,15 @@ func SetupRouter(taskHandler *handler.TaskHandler) *gin.Engine {
 		})
 	})
 
+	for _, registrar := range public {
+		registrar.RegisterRoutes(router)
+	}
+
+	router.Use(middlewares...)
 	taskHandler.RegisterRoutes(router)
+	for _, registrar := range registrars {
+		registrar.RegisterRoutes(router)
+	}
 
 	return router
 }
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"alle-task-manager-gunish/internal/api/handler"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/openapi"
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	apiKey      = "tok-9f8e7d6c5b4a"
+	description = "Call Jane Doe at +1 555 0100 about invoice 4711"
+)
+
+// TestSetupRouter_KeepsDescriptionsAndTokensOutOfLogs : Drives valid and invalid requests through the whole
+// stack, with debug logging, and checks that neither the description nor the API key shows up in the log
+// output or in error responses.
+func TestSetupRouter_KeepsDescriptionsAndTokensOutOfLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logPath := filepath.Join(t.TempDir(), "service.log")
+	require.NoError(t, loggingtype.Setup(config.LoggingConfig{
+		Level: "debug", Format: "json", Output: logPath, SampleTick: time.Second, RedactFields: config.DefaultLogRedactFields,
+	}, apiKey))
+	t.Cleanup(func() {
+		require.NoError(t, loggingtype.Setup(config.LoggingConfig{Level: "info", Format: "json", Output: "stdout", RedactFields: config.DefaultLogRedactFields}))
+	})
+
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validation, err := middleware.RequestValidation(doc)
+	require.NoError(t, err)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(eventbus.NewNoopBus()))
+	router := SetupRouter(nil, []gin.HandlerFunc{middleware.APIKeyAuth([]string{apiKey}), validation}, handler.NewTaskHandler(taskService))
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", "Bearer "+apiKey)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, "/tasks", `{"title":"Follow up","description":"`+description+`"}`)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	id := created.Data.ID
+
+	require.Equal(t, http.StatusOK, send(http.MethodPut, "/tasks/"+id, `{"description":"`+description+` (updated)"}`).Code)
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/tasks/"+id, "").Code)
+
+	for _, body := range []string{
+		`{"title":"Follow up","description":` + description + `}`,
+		`{"title":"Follow up","description":"` + description + `","due_date":"` + description + `"}`,
+		`{"title":"Follow up","description":["` + description + `"]}`,
+		`{"description":"` + description + `"`,
+	} {
+		recorder := send(http.MethodPost, "/tasks", body)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+		assert.NotContains(t, recorder.Body.String(), "Jane", body)
+	}
+	recorder = send(http.MethodPut, "/tasks/"+id, `{"due_date":"`+description+`"}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "Jane")
+
+	// Values logged on purpose are redacted too.
+	loggingtype.ForPackage("service").Info("Task input",
+		"input", service.CreateTaskInput{Title: "Follow up", Description: description},
+		"authorization", "Bearer "+apiKey,
+		"error", errors.New("rejected key "+apiKey))
+
+	logged, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.Contains(t, string(logged), `"msg":"Task created successfully"`)
+	require.Contains(t, string(logged), `"msg":"Task input"`)
+	assert.NotContains(t, string(logged), "Jane")
+	assert.NotContains(t, string(logged), "invoice")
+	assert.NotContains(t, string(logged), apiKey)
+}
+
+type routeFunc func(router *gin.Engine)
+
+func (f routeFunc) RegisterRoutes(router *gin.Engine) { f(router) }
+
+func TestSetupRouter_RateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules, err := ratelimit.ParseRules("", []string{"GET /limited=1/m"})
+	require.NoError(t, err)
+	limit := middleware.RateLimit(rules, ratelimit.NewMemoryStore(), middleware.NewAPIKeys(nil))
+	router := SetupRouter(nil, []gin.HandlerFunc{limit}, handler.NewTaskHandler(nil), routeFunc(func(router *gin.Engine) {
+		router.GET("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}))
+
+	send := func(forwardedFor string) int {
+		request := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		request.RemoteAddr = "203.0.113.7:4321"
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("198.51.100.1"))
+	for _, forwardedFor := range []string{"198.51.100.2", "198.51.100.3", "10.0.0.1"} {
+		assert.Equal(t, http.StatusTooManyRequests, send(forwardedFor), forwardedFor)
+	}
+
+	// A configured proxy may forward the client address.
+	require.NoError(t, router.SetTrustedProxies([]string{"203.0.113.0/24"}))
+	assert.Equal(t, http.StatusOK, send("198.51.100.4"))
+	assert.Equal(t, http.StatusTooManyRequests, send("198.51.100.4"))
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -9,20 +12,167 @@ import (
 
 type Config struct {
 	Server   ServerConfig
+	GRPC     GRPCConfig
+	Auth     AuthConfig
+	EventBus EventBusConfig
 	Kafka    KafkaConfig
+	NATS     NATSConfig
 	Database DBConfig
+
+	EventSourcing EventSourcingConfig
+	Webhook       WebhookConfig
+	TaskStream    TaskStreamConfig
+	WebSocket     WebSocketConfig
+	GraphQL       GraphQLConfig
+	Tracing       TracingConfig
+	Health        HealthConfig
+	Logging       LoggingConfig
+	RateLimit     RateLimitConfig
+	Idempotency   IdempotencyConfig
+
+	// problems are the environment variables that were set but could not be parsed and fell back to defaults.
+	problems []error
 }
 
+// ServerConfig : TrustedProxies lists the addresses or CIDRs of the proxies allowed to set X-Forwarded-For.
+// With none, the client IP is always the remote address.
 type ServerConfig struct {
-	Port         int
-	ReadTimeout  int
-	WriteTimeout int
+	Port           int
+	ReadTimeout    int
+	WriteTimeout   int
+	TrustedProxies []string
+}
+
+// GRPCConfig : The gRPC API listens on a port of its own. It is disabled when Port is 0.
+type GRPCConfig struct {
+	Port int
+}
+
+// AuthConfig : API keys accepted by the HTTP, WebSocket and gRPC APIs. Authentication is off when none are set.
+// AdminAPIKeys guard operations such as dead-letter replay, which are refused when none are set.
+type AuthConfig struct {
+	APIKeys      []string
+	AdminAPIKeys []string
+}
+
+type WebSocketConfig struct {
+	AllowedOrigins []string
+	SendBuffer     int
+}
+
+// GraphQLConfig : Limits on the shape of /graphql operations, checked before they execute.
+type GraphQLConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// TracingConfig : Where OpenTelemetry spans go. Exporter is none, stdout, otlp or memory; SampleRatio applies
+// to traces that don't arrive with a sampling decision.
+type TracingConfig struct {
+	Exporter     string
+	ServiceName  string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	SampleRatio  float64
+}
+
+// HealthConfig : CheckTimeout bounds each readiness check. ShutdownDelay is how long /readyz fails before the
+// servers stop accepting requests, so load balancers can take the instance out of rotation first. The outbox
+// check fails when more than OutboxMaxBacklog webhook deliveries have been due for longer than OutboxMaxDelay.
+type HealthConfig struct {
+	CheckTimeout     time.Duration
+	ShutdownDelay    time.Duration
+	OutboxMaxBacklog int
+	OutboxMaxDelay   time.Duration
+}
+
+// DefaultLogRedactFields : Task descriptions hold customer data; the rest are credentials.
+var DefaultLogRedactFields = []string{"description", "*password*", "*secret*", "*token*", "authorization", "*api_key*", "x-api-key"}
+
+// LoggingConfig : Level applies to packages without an entry in PackageLevels, which holds package=level
+// pairs. Output is stdout, stderr or a file path. Sampling of debug and info logs is off while
+// SampleInitial is 0. Attributes whose key matches one of the RedactFields patterns are never written.
+type LoggingConfig struct {
+	Level            string
+	Format           string
+	Output           string
+	PackageLevels    []string
+	SampleInitial    int
+	SampleThereafter int
+	SampleTick       time.Duration
+	RedactFields     []string
+}
+
+// RateLimitConfig : Default is the limit of routes without one in Routes, which holds "METHOD /path=limit"
+// entries; limits look like 30/m. Store is memory, or redis to share the limits between instances.
+type RateLimitConfig struct {
+	Enabled  bool
+	Default  string
+	Routes   []string
+	Store    string
+	RedisURL string
+}
+
+// IdempotencyConfig : Responses to requests with an Idempotency-Key are replayed for TTL. LockTimeout is how
+// long a key stays taken by a request that never completes.
+type IdempotencyConfig struct {
+	TTL             time.Duration
+	LockTimeout     time.Duration
+	CleanupInterval time.Duration
+}
+
+type EventBusConfig struct {
+	Driver           string
+	MemoryBufferSize int
+}
+
+// EventSourcingConfig : When enabled, task mutations are appended to the event store and the tasks
+// table is treated as a projection that can be rebuilt from it.
+type EventSourcingConfig struct {
+	Enabled bool
+}
+
+type WebhookConfig struct {
+	Timeout                time.Duration
+	MaxRetries             int
+	RetryBackoff           time.Duration
+	RetryMaxBackoff        time.Duration
+	MaxConsecutiveFailures int
+	Workers                int
+	PollInterval           time.Duration
+	// AllowPrivateNetworks lets subscriptions target loopback, link-local and private addresses, which are
+	// refused by default so webhooks can't be used to reach internal services.
+	AllowPrivateNetworks bool
 }
 
+type TaskStreamConfig struct {
+	ReplayBufferSize  int
+	SubscriberBuffer  int
+	HeartbeatInterval time.Duration
+}
+
+type NATSConfig struct {
+	URL    string
+	Stream string
+}
+
+// KafkaConfig : Topic, group, retry, CloudEvents and deduplication settings also apply to the
+// other event bus drivers; they keep the KAFKA_ prefix for backwards compatibility. StreamGroupID is the
+// group this instance reads task events with for its live streams; it must be unique per instance and
+// stable across restarts, so restarts don't leave groups behind.
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
-	GroupID string
+	Brokers         []string
+	Topic           string
+	GroupID         string
+	StreamGroupID   string
+	DLQTopic        string
+	CloudEventsMode string
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+
+	ProcessedEventTTL             time.Duration
+	ProcessedEventCleanupInterval time.Duration
 }
 
 type DBConfig struct {
@@ -36,63 +186,256 @@ type DBConfig struct {
 	ConnMaxLifetime    time.Duration
 }
 
+// LoadConfig : Values that cannot be parsed fall back to their defaults; Validate reports them.
 func LoadConfig() *Config {
-	return &Config{
+	env := &envReader{}
+	cfg := &Config{
 		Server: ServerConfig{
-			Port:         getEnvInt("SERVER_PORT", 8080),
-			ReadTimeout:  getEnvInt("SERVER_READ_TIMEOUT", 10),
-			WriteTimeout: getEnvInt("SERVER_WRITE_TIMEOUT", 10),
+			Port:           env.getInt("SERVER_PORT", 8080),
+			ReadTimeout:    env.getInt("SERVER_READ_TIMEOUT", 10),
+			WriteTimeout:   env.getInt("SERVER_WRITE_TIMEOUT", 10),
+			TrustedProxies: withoutEmpty(env.getStringSlice("TRUSTED_PROXIES", nil)),
+		},
+		GRPC: GRPCConfig{
+			Port: env.getInt("GRPC_PORT", 9090),
+		},
+		Auth: AuthConfig{
+			APIKeys:      env.getStringSlice("API_KEYS", nil),
+			AdminAPIKeys: env.getStringSlice("ADMIN_API_KEYS", nil),
+		},
+		EventBus: EventBusConfig{
+			Driver:           env.getString("EVENT_BUS_DRIVER", "kafka"),
+			MemoryBufferSize: env.getInt("EVENT_BUS_MEMORY_BUFFER_SIZE", 256),
 		},
 		Kafka: KafkaConfig{
-			Brokers: getEnvStringSlice("KAFKA_BROKERS", []string{"localhost:9092"}),
-			Topic:   getEnvString("KAFKA_TOPIC", "task-events"),
-			GroupID: getEnvString("KAFKA_GROUP_ID", "task-management-group"),
+			Brokers:         env.getStringSlice("KAFKA_BROKERS", []string{"localhost:9092"}),
+			Topic:           env.getString("KAFKA_TOPIC", "task-events"),
+			GroupID:         env.getString("KAFKA_GROUP_ID", "task-management-group"),
+			StreamGroupID:   env.getString("KAFKA_STREAM_GROUP_ID", ""),
+			DLQTopic:        env.getString("KAFKA_DLQ_TOPIC", "task-events.dlq"),
+			CloudEventsMode: env.getString("KAFKA_CLOUDEVENTS_MODE", "structured"),
+			MaxRetries:      env.getInt("KAFKA_CONSUMER_MAX_RETRIES", 3),
+			RetryBackoff:    env.getDuration("KAFKA_CONSUMER_RETRY_BACKOFF", 500*time.Millisecond),
+			RetryMaxBackoff: env.getDuration("KAFKA_CONSUMER_RETRY_MAX_BACKOFF", 10*time.Second),
+
+			ProcessedEventTTL:             env.getDuration("KAFKA_PROCESSED_EVENT_TTL", 7*24*time.Hour),
+			ProcessedEventCleanupInterval: env.getDuration("KAFKA_PROCESSED_EVENT_CLEANUP_INTERVAL", time.Hour),
+		},
+		NATS: NATSConfig{
+			URL:    env.getString("NATS_URL", "nats://localhost:4222"),
+			Stream: env.getString("NATS_STREAM", "TASK_EVENTS"),
 		},
 		Database: DBConfig{
-			Driver:             getEnvString("DB_DRIVER", "sqlite"),
-			Path:               getEnvString("SQLITE_DB_PATH", "tasks.db"),
-			AutoMigrate:        getEnvBool("DB_AUTO_MIGRATE", true),
-			LogLevel:           getEnvString("DB_LOG_LEVEL", "warn"),
-			MaxIdleConnections: getEnvInt("DB_MAX_IDLE_CONNS", 10),
-			MaxOpenConnections: getEnvInt("DB_MAX_OPEN_CONNS", 100),
-			ConnMaxLifetime:    getEnvDuration("DB_CONN_MAX_LIFETIME", time.Hour),
+			Driver:             env.getString("DB_DRIVER", "sqlite"),
+			Path:               env.getString("SQLITE_DB_PATH", "tasks.db"),
+			AutoMigrate:        env.getBool("DB_AUTO_MIGRATE", true),
+			LogLevel:           env.getString("DB_LOG_LEVEL", "warn"),
+			MaxIdleConnections: env.getInt("DB_MAX_IDLE_CONNS", 10),
+			MaxOpenConnections: env.getInt("DB_MAX_OPEN_CONNS", 100),
+			ConnMaxLifetime:    env.getDuration("DB_CONN_MAX_LIFETIME", time.Hour),
+		},
+		EventSourcing: EventSourcingConfig{
+			Enabled: env.getBool("EVENT_SOURCING_ENABLED", false),
+		},
+		TaskStream: TaskStreamConfig{
+			ReplayBufferSize:  env.getInt("TASK_STREAM_REPLAY_BUFFER_SIZE", 1000),
+			SubscriberBuffer:  env.getInt("TASK_STREAM_SUBSCRIBER_BUFFER", 64),
+			HeartbeatInterval: env.getDuration("TASK_STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+		},
+		WebSocket: WebSocketConfig{
+			AllowedOrigins: env.getStringSlice("WS_ALLOWED_ORIGINS", nil),
+			SendBuffer:     env.getInt("WS_SEND_BUFFER", 64),
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      env.getInt("GRAPHQL_MAX_DEPTH", 8),
+			MaxComplexity: env.getInt("GRAPHQL_MAX_COMPLEXITY", 2000),
+		},
+		Tracing: TracingConfig{
+			Exporter:     env.getString("TRACING_EXPORTER", "none"),
+			ServiceName:  env.getString("TRACING_SERVICE_NAME", "task-manager"),
+			OTLPEndpoint: env.getString("TRACING_OTLP_ENDPOINT", "localhost:4317"),
+			OTLPInsecure: env.getBool("TRACING_OTLP_INSECURE", true),
+			SampleRatio:  env.getFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Health: HealthConfig{
+			CheckTimeout:     env.getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			ShutdownDelay:    env.getDuration("HEALTH_SHUTDOWN_DELAY", 0),
+			OutboxMaxBacklog: env.getInt("HEALTH_OUTBOX_MAX_BACKLOG", 100),
+			OutboxMaxDelay:   env.getDuration("HEALTH_OUTBOX_MAX_DELAY", time.Minute),
+		},
+		Logging: LoggingConfig{
+			Level:            env.getString("LOG_LEVEL", "info"),
+			Format:           env.getString("LOG_FORMAT", "json"),
+			Output:           env.getString("LOG_OUTPUT", "stdout"),
+			PackageLevels:    env.getStringSlice("LOG_PACKAGE_LEVELS", nil),
+			SampleInitial:    env.getInt("LOG_SAMPLE_INITIAL", 0),
+			SampleThereafter: env.getInt("LOG_SAMPLE_THEREAFTER", 0),
+			SampleTick:       env.getDuration("LOG_SAMPLE_TICK", time.Second),
+			RedactFields:     env.getStringSlice("LOG_REDACT_FIELDS", DefaultLogRedactFields),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  env.getBool("RATE_LIMIT_ENABLED", false),
+			Default:  env.getString("RATE_LIMIT_DEFAULT", "100/m"),
+			Routes:   env.getStringSlice("RATE_LIMIT_ROUTES", []string{"POST /tasks=30/m", "GET /tasks=120/m"}),
+			Store:    env.getString("RATE_LIMIT_STORE", "memory"),
+			RedisURL: env.getString("RATE_LIMIT_REDIS_URL", "redis://localhost:6379/0"),
 		},
+		Idempotency: IdempotencyConfig{
+			TTL:             env.getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			LockTimeout:     env.getDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+			CleanupInterval: env.getDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+		},
+		Webhook: WebhookConfig{
+			Timeout:                env.getDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxRetries:             env.getInt("WEBHOOK_MAX_RETRIES", 5),
+			RetryBackoff:           env.getDuration("WEBHOOK_RETRY_BACKOFF", time.Second),
+			RetryMaxBackoff:        env.getDuration("WEBHOOK_RETRY_MAX_BACKOFF", time.Minute),
+			MaxConsecutiveFailures: env.getInt("WEBHOOK_MAX_CONSECUTIVE_FAILURES", 10),
+			Workers:                env.getInt("WEBHOOK_WORKERS", 4),
+			PollInterval:           env.getDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+			AllowPrivateNetworks:   env.getBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+	}
+	if cfg.Kafka.StreamGroupID == "" {
+		cfg.Kafka.StreamGroupID = defaultStreamGroupID(cfg.Kafka.GroupID)
 	}
+	cfg.problems = env.problems
+	return cfg
 }
 
-func getEnvInt(key string, defaultValue int) int {
+// Validate : Reports unparsable environment variables and values the service cannot run with.
+func (c *Config) Validate() error {
+	problems := append([]error(nil), c.problems...)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "SERVER_PORT must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.GRPC.Port >= 0 && c.GRPC.Port <= 65535, "GRPC_PORT must be between 0 and 65535, got %d", c.GRPC.Port)
+	check(c.GRPC.Port != c.Server.Port, "GRPC_PORT and SERVER_PORT must differ, both are %d", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT must be positive, got %d", c.Server.ReadTimeout)
+	check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT must be positive, got %d", c.Server.WriteTimeout)
+	for _, proxy := range c.Server.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		check(prefixErr == nil || addrErr == nil, "TRUSTED_PROXIES must contain IP addresses or CIDRs, got %q", proxy)
+	}
+	check(c.Database.Driver == "sqlite", "DB_DRIVER must be sqlite, got %q", c.Database.Driver)
+	check(c.Database.Driver != "sqlite" || c.Database.Path != "", "SQLITE_DB_PATH is required")
+	check(c.Database.MaxOpenConnections > 0, "DB_MAX_OPEN_CONNS must be positive, got %d", c.Database.MaxOpenConnections)
+	check(len(c.Kafka.Brokers) > 0 && c.Kafka.Brokers[0] != "", "KAFKA_BROKERS is required")
+	check(c.Kafka.Topic != "", "KAFKA_TOPIC is required")
+	check(c.Kafka.GroupID != "", "KAFKA_GROUP_ID is required")
+	check(c.Kafka.StreamGroupID != c.Kafka.GroupID, "KAFKA_STREAM_GROUP_ID must differ from KAFKA_GROUP_ID")
+	check(c.Kafka.MaxRetries >= 0, "KAFKA_CONSUMER_MAX_RETRIES must not be negative, got %d", c.Kafka.MaxRetries)
+	check(c.Kafka.ProcessedEventTTL > 0, "KAFKA_PROCESSED_EVENT_TTL must be positive, got %s", c.Kafka.ProcessedEventTTL)
+	check(c.Kafka.ProcessedEventCleanupInterval > 0, "KAFKA_PROCESSED_EVENT_CLEANUP_INTERVAL must be positive, got %s", c.Kafka.ProcessedEventCleanupInterval)
+	check(c.EventBus.MemoryBufferSize > 0, "EVENT_BUS_MEMORY_BUFFER_SIZE must be positive, got %d", c.EventBus.MemoryBufferSize)
+	check(c.TaskStream.ReplayBufferSize >= 0, "TASK_STREAM_REPLAY_BUFFER_SIZE must not be negative, got %d", c.TaskStream.ReplayBufferSize)
+	check(c.TaskStream.SubscriberBuffer > 0, "TASK_STREAM_SUBSCRIBER_BUFFER must be positive, got %d", c.TaskStream.SubscriberBuffer)
+	check(c.TaskStream.HeartbeatInterval > 0, "TASK_STREAM_HEARTBEAT_INTERVAL must be positive, got %s", c.TaskStream.HeartbeatInterval)
+	check(c.WebSocket.SendBuffer > 0, "WS_SEND_BUFFER must be positive, got %d", c.WebSocket.SendBuffer)
+	check(c.GraphQL.MaxDepth > 0, "GRAPHQL_MAX_DEPTH must be positive, got %d", c.GraphQL.MaxDepth)
+	check(c.GraphQL.MaxComplexity > 0, "GRAPHQL_MAX_COMPLEXITY must be positive, got %d", c.GraphQL.MaxComplexity)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required")
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive, got %s", c.Health.CheckTimeout)
+	check(c.Health.ShutdownDelay >= 0, "HEALTH_SHUTDOWN_DELAY must not be negative, got %s", c.Health.ShutdownDelay)
+	check(c.Health.OutboxMaxBacklog >= 0, "HEALTH_OUTBOX_MAX_BACKLOG must not be negative, got %d", c.Health.OutboxMaxBacklog)
+	check(c.Health.OutboxMaxDelay > 0, "HEALTH_OUTBOX_MAX_DELAY must be positive, got %s", c.Health.OutboxMaxDelay)
+	check(c.Logging.Output != "", "LOG_OUTPUT is required")
+	check(c.Logging.SampleInitial >= 0, "LOG_SAMPLE_INITIAL must not be negative, got %d", c.Logging.SampleInitial)
+	check(c.Logging.SampleThereafter >= 0, "LOG_SAMPLE_THEREAFTER must not be negative, got %d", c.Logging.SampleThereafter)
+	check(c.Logging.SampleTick > 0, "LOG_SAMPLE_TICK must be positive, got %s", c.Logging.SampleTick)
+	check(c.RateLimit.Store != "redis" || c.RateLimit.RedisURL != "", "RATE_LIMIT_REDIS_URL is required when RATE_LIMIT_STORE is redis")
+	check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be positive, got %s", c.Idempotency.TTL)
+	check(c.Idempotency.LockTimeout > 0, "IDEMPOTENCY_LOCK_TIMEOUT must be positive, got %s", c.Idempotency.LockTimeout)
+	check(c.Idempotency.CleanupInterval > 0, "IDEMPOTENCY_CLEANUP_INTERVAL must be positive, got %s", c.Idempotency.CleanupInterval)
+	check(c.Webhook.Timeout > 0, "WEBHOOK_TIMEOUT must be positive, got %s", c.Webhook.Timeout)
+	check(c.Webhook.Workers > 0, "WEBHOOK_WORKERS must be positive, got %d", c.Webhook.Workers)
+	check(c.Webhook.PollInterval > 0, "WEBHOOK_POLL_INTERVAL must be positive, got %s", c.Webhook.PollInterval)
+	return errors.Join(problems...)
+}
+
+// envReader : Reads typed environment variables, collecting the ones that are set but cannot be parsed.
+type envReader struct {
+	problems []error
+}
+
+func (r *envReader) invalid(key, value, expected string) {
+	r.problems = append(r.problems, fmt.Errorf("%s=%q is not %s, using the default", key, value, expected))
+}
+
+func (r *envReader) getInt(key string, defaultValue int) int {
 	if value, exists := os.LookupEnv(key); exists {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		r.invalid(key, value, "an integer")
 	}
 	return defaultValue
 }
 
-func getEnvBool(key string, defaultValue bool) bool {
+func (r *envReader) getFloat(key string, defaultValue float64) float64 {
 	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		r.invalid(key, value, "a number")
+	}
+	return defaultValue
+}
+
+// defaultStreamGroupID : "<group>-stream-<hostname>". Hostnames are unique per replica under most
+// orchestrators, such as Kubernetes pod names.
+func defaultStreamGroupID(groupID string) string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "local"
+	}
+	return groupID + "-stream-" + hostname
+}
+
+func (r *envReader) getBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if value != "true" && value != "1" && value != "false" && value != "0" {
+			r.invalid(key, value, "true, false, 1 or 0")
+		}
 		return value == "true" || value == "1"
 	}
 	return defaultValue
 }
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+func (r *envReader) getDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		r.invalid(key, value, "a duration such as 500ms or 10s")
 	}
 	return defaultValue
 }
 
-func getEnvString(key, defaultValue string) string {
+func (r *envReader) getString(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvStringSlice(key string, defaultValue []string) []string {
+// withoutEmpty : Drops blank entries, so an empty list variable means no entries.
+func withoutEmpty(values []string) []string {
+	kept := values[:0:0]
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+func (r *envReader) getStringSlice(key string, defaultValue []string) []string {
 	if value, exists := os.LookupEnv(key); exists {
 		values := strings.Split(value, ",")
 		for i := range values {
@@ -3,14 +3,21 @@ package database
 import (
 	"alle-task-manager-gunish/internal/common/config"
 	"alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/common/tracing"
 	"alle-task-manager-gunish/internal/domain/model"
 	"context"
 	"errors"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"os"
+	"strconv"
+	"strings"
 )
 
+// sqliteBusyTimeoutMs : How long a SQLite connection waits for a lock before giving up.
+const sqliteBusyTimeoutMs = 5000
+
 type Database struct {
 	Db     *gorm.DB
 	logger *loggingtype.Logger
@@ -19,7 +26,7 @@ type Database struct {
 
 func NewDatabase(ctx context.Context, config config.DBConfig) (*Database, error) {
 	var dialector gorm.Dialector
-	logger := loggingtype.GetLogger()
+	logger := loggingtype.ForPackage("database")
 
 	switch config.Driver {
 	case "sqlite":
@@ -39,18 +46,28 @@ func NewDatabase(ctx context.Context, config config.DBConfig) (*Database, error)
 			}
 			logger.Info("SQLite database file created", "path", config.Path)
 		}
-		dialector = sqlite.Open(config.Path)
+		dialector = sqlite.Open(sqliteDSN(config.Path))
 	default:
 		logger.Error("unsupported database driver", "driver", config.Driver)
 		return nil, errors.New("unsupported database driver: " + config.Driver)
 	}
 
-	db, err := gorm.Open(dialector, &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
 	if err != nil {
 		logger.Error("failed to open database connection", "error", err)
 		return nil, errors.New("failed to open database connection: " + err.Error())
 	}
 
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		logger.Error("failed to register database metrics", "error", err)
+		return nil, errors.New("failed to register database metrics: " + err.Error())
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		logger.Error("failed to register database tracing", "error", err)
+		return nil, errors.New("failed to register database tracing: " + err.Error())
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		logger.Error("failed to get database instance", "error", err)
@@ -68,11 +85,9 @@ func NewDatabase(ctx context.Context, config config.DBConfig) (*Database, error)
 	}
 
 	if config.AutoMigrate {
-		if err := database.Db.AutoMigrate(&model.Task{}); err != nil {
-			logger.Error("failed to migrate database schema", "error", err)
-			return nil, errors.New("failed to migrate database schema: " + err.Error())
+		if err := database.Migrate(); err != nil {
+			return nil, err
 		}
-		logger.Info("Database schema migrated successfully")
 	}
 
 	if err := sqlDB.PingContext(ctx); err != nil {
@@ -88,6 +103,25 @@ func NewDatabase(ctx context.Context, config config.DBConfig) (*Database, error)
 	return database, nil
 }
 
+// Migrate : Creates or alters the tables of every model to match its definition.
+func (d *Database) Migrate() error {
+	if err := d.Db.AutoMigrate(&model.Task{}, &model.ChangeSequence{}, &model.ProcessedEvent{}, &model.TaskEventRecord{}, &model.WebhookSubscription{}, &model.WebhookDelivery{}, &model.WebhookOutboxEntry{}, &model.IdempotencyKey{}); err != nil {
+		d.logger.Error("failed to migrate database schema", "error", err)
+		return errors.New("failed to migrate database schema: " + err.Error())
+	}
+	d.logger.Info("Database schema migrated successfully")
+	return nil
+}
+
+// Ping : Checks that a connection to the database can be used.
+func (d *Database) Ping(ctx context.Context) error {
+	sqlDB, err := d.Db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.Db.DB()
 	if err != nil {
@@ -103,3 +137,13 @@ func (d *Database) Close() error {
 	d.logger.Info("Database connection closed")
 	return nil
 }
+
+// sqliteDSN : Makes connections wait for a lock held by another connection, such as the consumer's
+// transaction, instead of failing straight away with SQLITE_BUSY.
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_busy_timeout=" + strconv.Itoa(sqliteBusyTimeoutMs)
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithinTransaction : Runs fn in a transaction carried by the returned context. Repositories that
+// resolve their connection through Conn join it, so their writes commit or roll back together.
+func (d *Database) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, d.Db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// Conn : Returns the transaction stored in ctx, or db bound to ctx when there is none.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
@@ -1,26 +1,68 @@
 package dependency
 
 import (
+	"alle-task-manager-gunish/internal/api/graphapi"
+	"alle-task-manager-gunish/internal/api/grpcapi"
 	"alle-task-manager-gunish/internal/api/handler"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/openapi"
 	"alle-task-manager-gunish/internal/common/config"
 	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/common/health"
 	"alle-task-manager-gunish/internal/common/kafka"
 	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/common/ratelimit"
+	"alle-task-manager-gunish/internal/domain/model"
 	"alle-task-manager-gunish/internal/domain/repository"
 	"alle-task-manager-gunish/internal/service"
 	"context"
 	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"sync"
+	"time"
 )
 
 type Container struct {
 	config         *config.Config
 	database       *database.Database
 	taskRepository repository.TaskRepository
+	processedRepo  repository.ProcessedEventRepository
+	idemKeyRepo    repository.IdempotencyKeyRepository
+	idempotency    *service.IdempotencyService
+	taskEventStore repository.TaskEventStore
+	projectionSvc  *service.TaskProjectionService
+	webhookRepo    repository.WebhookRepository
+	webhookSvc     *service.WebhookService
+	webhooks       *service.WebhookDispatcher
+	webhookHandler *handler.WebhookHandler
+	taskStream     *service.TaskStreamBroadcaster
+	streamHandler  *handler.TaskStreamHandler
+	socketHandler  *handler.TaskSocketHandler
+	graphqlHandler *handler.GraphQLHandler
+	docsHandler    *handler.DocsHandler
+	metricsHandler *handler.MetricsHandler
+	health         *health.Checker
+	healthHandler  *handler.HealthHandler
+	validation     gin.HandlerFunc
+	rateLimit      gin.HandlerFunc
+	redisClient    *redis.Client
+	taskGRPCServer *grpcapi.TaskServer
 	taskService    *service.TaskService
 	taskEventSvc   *service.TaskEventService
-	kafkaProducer  *kafka.Producer
+	eventBus       eventbus.Bus
+	eventBusDriver string
 	taskHandler    *handler.TaskHandler
-	kafkaConsumer  *kafka.Consumer
+	dlqReplayer    *kafka.DLQReplayer
+	eventHandlers  *service.TaskEventHandlerRegistry
+	consumerSvc    *service.TaskEventConsumerService
+	adminHandler   *handler.AdminHandler
 }
 
 type Option func(*Container) error
@@ -89,69 +131,405 @@ func (c *Container) initializeRepositories() error {
 		c.taskRepository = repo
 	}
 
-	return nil
-}
+	if c.processedRepo == nil {
+		repo, err := repository.NewGormProcessedEventRepository(c.database.Db)
+		if err != nil {
+			return err
+		}
+		c.processedRepo = repo
+	}
 
-func (c *Container) initializeKafka() error {
-	if c.config == nil {
-		return errors.New("config is required to initialize Kafka")
+	if c.idemKeyRepo == nil {
+		repo, err := repository.NewGormIdempotencyKeyRepository(c.database.Db)
+		if err != nil {
+			return err
+		}
+		c.idemKeyRepo = repo
 	}
 
-	if c.kafkaProducer == nil {
-		producer, err := kafka.NewProducer(c.config.Kafka.Brokers)
+	if c.webhookRepo == nil {
+		repo, err := repository.NewGormWebhookRepository(c.database.Db)
 		if err != nil {
 			return err
 		}
-		c.kafkaProducer = producer
+		c.webhookRepo = repo
+	}
+
+	if c.taskEventStore == nil && c.config.EventSourcing.Enabled {
+		store, err := repository.NewGormTaskEventStore(c.database.Db)
+		if err != nil {
+			return err
+		}
+		c.taskEventStore = store
+	}
+
+	return nil
+}
+
+func (c *Container) retryPolicy() eventbus.RetryPolicy {
+	return eventbus.RetryPolicy{
+		MaxRetries:     c.config.Kafka.MaxRetries,
+		InitialBackoff: c.config.Kafka.RetryBackoff,
+		MaxBackoff:     c.config.Kafka.RetryMaxBackoff,
+		Multiplier:     2,
+	}
+}
+
+// initializeEventBus : Connects the configured event bus. A broker that cannot be reached disables
+// event delivery instead of failing startup, so the HTTP API keeps serving.
+func (c *Container) initializeEventBus() error {
+	if c.config == nil {
+		return errors.New("config is required to initialize the event bus")
+	}
+	if c.eventBus != nil {
+		return nil
 	}
 
-	consumerService := service.NewTaskEventConsumerService()
-	consumer, err := kafka.NewConsumer(
-		c.config.Kafka.Brokers,
-		"task-management-group",
-		[]string{"task-events"},
-		consumerService.HandleMessage,
-	)
+	driver, err := eventbus.ParseDriver(c.config.EventBus.Driver)
 	if err != nil {
 		return err
 	}
-	c.kafkaConsumer = consumer
 
+	bus, err := c.newEventBus(driver)
+	if err != nil {
+		loggingtype.GetLogger().Error("Failed to connect event bus, event delivery is disabled", "driver", driver, "error", err)
+		bus, driver = eventbus.NewNoopBus(), eventbus.DriverNone
+	}
+	c.eventBus = bus
+	c.eventBusDriver = driver
+	loggingtype.GetLogger().Info("Event bus initialized", "driver", driver)
 	return nil
 }
 
+func (c *Container) newEventBus(driver string) (eventbus.Bus, error) {
+	switch driver {
+	case eventbus.DriverKafka:
+		producer, err := kafka.NewProducer(c.config.Kafka.Brokers)
+		if err != nil {
+			return nil, err
+		}
+		replayer, err := kafka.NewDLQReplayer(
+			c.config.Kafka.Brokers,
+			c.config.Kafka.GroupID+"-dlq-replay",
+			c.config.Kafka.DLQTopic,
+			c.config.Kafka.Topic,
+			producer,
+		)
+		if err != nil {
+			_ = producer.Close()
+			return nil, err
+		}
+		c.dlqReplayer = replayer
+		return kafka.NewBus(
+			c.config.Kafka.Brokers,
+			producer,
+			kafka.WithRetryPolicy(c.retryPolicy()),
+			kafka.WithDeadLetterQueue(kafka.NewDeadLetterQueue(producer, c.config.Kafka.DLQTopic)),
+		), nil
+	case eventbus.DriverNATS:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return eventbus.NewNATSBus(ctx, c.config.NATS.URL, c.config.NATS.Stream, []string{c.config.Kafka.Topic}, c.retryPolicy())
+	case eventbus.DriverMemory:
+		return eventbus.NewMemoryBus(c.config.EventBus.MemoryBufferSize, c.retryPolicy()), nil
+	default:
+		return eventbus.NewNoopBus(), nil
+	}
+}
+
 func (c *Container) initializeServices() error {
-	if err := c.initializeKafka(); err != nil {
+	if err := c.initializeEventBus(); err != nil {
 		return err
 	}
 
+	if c.webhooks == nil {
+		cfg := c.config.Webhook
+		c.webhooks = service.NewWebhookDispatcher(
+			c.webhookRepo,
+			service.WithWebhookHTTPClient(service.NewWebhookHTTPClient(cfg.Timeout, cfg.AllowPrivateNetworks)),
+			service.WithWebhookRetryPolicy(eventbus.RetryPolicy{
+				MaxRetries:     cfg.MaxRetries,
+				InitialBackoff: cfg.RetryBackoff,
+				MaxBackoff:     cfg.RetryMaxBackoff,
+				Multiplier:     2,
+			}),
+			service.WithMaxConsecutiveFailures(cfg.MaxConsecutiveFailures),
+			service.WithWebhookWorkers(cfg.Workers),
+			service.WithWebhookPollInterval(cfg.PollInterval),
+		)
+	}
+
+	if c.webhookSvc == nil {
+		var options []service.WebhookServiceOption
+		if c.config.Webhook.AllowPrivateNetworks {
+			options = append(options, service.WithWebhookPrivateNetworks())
+		}
+		c.webhookSvc = service.NewWebhookService(c.webhookRepo, options...)
+	}
+
+	if c.idempotency == nil {
+		c.idempotency = service.NewIdempotencyService(c.idemKeyRepo, c.config.Idempotency.TTL, c.config.Idempotency.LockTimeout)
+	}
+
+	if c.eventHandlers == nil {
+		c.eventHandlers = service.NewTaskEventHandlerRegistry()
+		c.eventHandlers.Register(events.EventTypeTaskCreated, service.OnTaskCreated(service.LogTaskCreated))
+		c.eventHandlers.Register(events.EventTypeTaskUpdated, service.OnTaskUpdated(service.LogTaskUpdated))
+		c.eventHandlers.Register(events.EventTypeTaskDeleted, service.OnTaskDeleted(service.LogTaskDeleted))
+		for _, eventType := range []string{events.EventTypeTaskCreated, events.EventTypeTaskUpdated, events.EventTypeTaskDeleted} {
+			c.eventHandlers.Register(eventType, c.webhooks.Handler(eventType))
+		}
+	}
+
+	if c.consumerSvc == nil {
+		c.consumerSvc = service.NewTaskEventConsumerService(
+			c.eventHandlers,
+			service.WithDeduplication(c.processedRepo, c.database),
+		)
+	}
+
+	if c.taskStream == nil {
+		c.taskStream = service.NewTaskStreamBroadcaster(c.config.TaskStream.ReplayBufferSize, c.config.TaskStream.SubscriberBuffer)
+	}
+
 	if c.taskEventSvc == nil {
-		c.taskEventSvc = service.NewTaskEventService(c.kafkaProducer)
+		mode, err := events.ParseMode(c.config.Kafka.CloudEventsMode)
+		if err != nil {
+			return err
+		}
+		// Without a bus nothing comes back from a consumer, so the stream is fed directly.
+		var publisher eventbus.Publisher = c.eventBus
+		if c.eventBusDriver == eventbus.DriverNone {
+			publisher = c.taskStream
+		}
+		c.taskEventSvc = service.NewTaskEventService(publisher, service.WithTopic(c.config.Kafka.Topic), service.WithContentMode(mode))
 	}
 
 	if c.taskService == nil {
-		c.taskService = service.NewTaskService(c.taskRepository, c.taskEventSvc)
+		var options []service.TaskServiceOption
+		if c.taskEventStore != nil {
+			options = append(options, service.WithEventSourcing(c.taskEventStore, c.database))
+		}
+		c.taskService = service.NewTaskService(c.taskRepository, c.taskEventSvc, options...)
+	}
+
+	if c.projectionSvc == nil && c.taskEventStore != nil {
+		writer, ok := c.taskRepository.(repository.TaskProjectionWriter)
+		if !ok {
+			return errors.New("task repository does not support projection rebuilds")
+		}
+		c.projectionSvc = service.NewTaskProjectionService(c.taskRepository, writer, c.taskEventStore, c.database)
 	}
 
 	return nil
 }
 
 func (c *Container) initializeHandlers() error {
+	if c.docsHandler == nil || c.validation == nil {
+		doc, err := openapi.Load()
+		if err != nil {
+			return err
+		}
+		if c.docsHandler, err = handler.NewDocsHandler(doc); err != nil {
+			return err
+		}
+		if c.validation, err = middleware.RequestValidation(doc); err != nil {
+			return err
+		}
+	}
+
+	if c.rateLimit == nil && c.config.RateLimit.Enabled {
+		if err := c.initializeRateLimit(); err != nil {
+			return err
+		}
+	}
+
 	if c.taskHandler == nil {
-		c.taskHandler = handler.NewTaskHandler(c.taskService)
+		c.taskHandler = handler.NewTaskHandler(c.taskService, handler.WithIdempotency(c.idempotency))
 	}
 
+	if c.metricsHandler == nil {
+		// The task gauges read this container's database, so they get a registry of their own next to the
+		// process-wide default one.
+		registry := prometheus.NewRegistry()
+		statuses := []string{string(model.Pending), string(model.InProgress), string(model.Completed)}
+		if err := registry.Register(metrics.NewTaskCollector(c.taskRepository.CountByStatus, statuses)); err != nil {
+			return err
+		}
+		c.metricsHandler = handler.NewMetricsHandler(prometheus.Gatherers{prometheus.DefaultGatherer, registry})
+	}
+
+	if c.health == nil {
+		c.health = health.NewChecker(c.config.Health.CheckTimeout)
+		c.health.Register("database", c.database.Ping)
+		c.health.Register("outbox", c.webhooks.BacklogCheck(c.config.Health.OutboxMaxBacklog, c.config.Health.OutboxMaxDelay))
+		// Only buses that talk to a broker are checked; a bus that could not connect at startup has been
+		// replaced by the noop bus and the API serves without event delivery.
+		if pinger, ok := c.eventBus.(eventbus.Pinger); ok {
+			c.health.Register(c.eventBusDriver, pinger.Ping)
+		}
+	}
+
+	if c.healthHandler == nil {
+		c.healthHandler = handler.NewHealthHandler(c.health)
+	}
+
+	if c.streamHandler == nil {
+		c.streamHandler = handler.NewTaskStreamHandler(c.taskStream, c.config.TaskStream.HeartbeatInterval)
+	}
+
+	if c.socketHandler == nil {
+		c.socketHandler = handler.NewTaskSocketHandler(c.taskService, c.taskStream, c.config.WebSocket.AllowedOrigins, c.config.WebSocket.SendBuffer)
+	}
+
+	if c.graphqlHandler == nil {
+		limits := graphapi.Limits{MaxDepth: c.config.GraphQL.MaxDepth, MaxComplexity: c.config.GraphQL.MaxComplexity}
+		c.graphqlHandler = handler.NewGraphQLHandler(c.taskService, c.taskStream, limits, c.config.WebSocket.AllowedOrigins)
+	}
+
+	if c.taskGRPCServer == nil {
+		c.taskGRPCServer = grpcapi.NewTaskServer(c.taskService, c.taskStream)
+	}
+
+	if c.webhookHandler == nil {
+		c.webhookHandler = handler.NewWebhookHandler(c.webhookSvc)
+	}
+
+	if c.adminHandler == nil {
+		var replayer handler.DeadLetterReplayer
+		if c.dlqReplayer != nil {
+			replayer = c.dlqReplayer
+		}
+		c.adminHandler = handler.NewAdminHandler(replayer, c.consumerSvc, handler.WithAdminKeys(c.config.Auth.AdminAPIKeys))
+	}
+
+	return nil
+}
+
+// initializeRateLimit : The Redis client connects lazily, so an unreachable Redis doesn't stop the service;
+// the limiter lets requests through until it is back.
+func (c *Container) initializeRateLimit() error {
+	cfg := c.config.RateLimit
+	rules, err := ratelimit.ParseRules(cfg.Default, cfg.Routes)
+	if err != nil {
+		return err
+	}
+	kind, err := ratelimit.ParseStore(cfg.Store)
+	if err != nil {
+		return err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if kind == ratelimit.StoreRedis {
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return fmt.Errorf("parsing RATE_LIMIT_REDIS_URL: %w", err)
+		}
+		c.redisClient = redis.NewClient(options)
+		store = ratelimit.NewRedisStore(c.redisClient, "task-manager:ratelimit:")
+	}
+	c.rateLimit = middleware.RateLimit(rules, store, middleware.NewAPIKeys(c.config.Auth.APIKeys))
 	return nil
 }
 
-func (c *Container) InitializeKafkaConsumer(ctx context.Context) (*kafka.Consumer, error) {
-	return kafka.NewConsumer(c.config.Kafka.Brokers, "task-management-group", []string{"task-events"}, service.NewTaskEventConsumerService().HandleMessage)
+// RunEventConsumers : Subscribes the consumer service and the task stream to the task event topic and blocks
+// until ctx is cancelled. The stream uses a group of its own per instance (KAFKA_STREAM_GROUP_ID), so every
+// instance sees every event.
+func (c *Container) RunEventConsumers(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		errs[0] = c.eventBus.Subscribe(ctx, c.config.Kafka.Topic, c.config.Kafka.GroupID, c.consumerSvc.HandleMessage)
+	}()
+	go func() {
+		defer wg.Done()
+		errs[1] = c.eventBus.Subscribe(ctx, c.config.Kafka.Topic, c.config.Kafka.StreamGroupID, c.taskStream.Publish, eventbus.Transient())
+	}()
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *Container) EventBus() eventbus.Bus {
+	return c.eventBus
+}
+
+func (c *Container) EventBusDriver() string {
+	return c.eventBusDriver
+}
+
+func (c *Container) TaskEventConsumerService() *service.TaskEventConsumerService {
+	return c.consumerSvc
+}
+
+func (c *Container) TaskEventHandlers() *service.TaskEventHandlerRegistry {
+	return c.eventHandlers
+}
+
+func (c *Container) WebhookDispatcher() *service.WebhookDispatcher {
+	return c.webhooks
+}
+
+// Middleware : Request middleware shared by every authenticated route. Requests are rate limited after
+// authentication, so they are counted against their API key, and before validation, so invalid ones count too.
+func (c *Container) Middleware() []gin.HandlerFunc {
+	middlewares := []gin.HandlerFunc{middleware.APIKeyAuth(c.config.Auth.APIKeys)}
+	if c.rateLimit != nil {
+		middlewares = append(middlewares, c.rateLimit)
+	}
+	return append(middlewares, c.validation)
+}
+
+// GRPCServer : A new gRPC server serving the task API, with the same API keys as the HTTP routes.
+func (c *Container) GRPCServer() *grpc.Server {
+	return grpcapi.NewServer(c.taskGRPCServer, middleware.NewAPIKeys(c.config.Auth.APIKeys))
+}
+
+func (c *Container) TaskSocketHandler() *handler.TaskSocketHandler {
+	return c.socketHandler
+}
+
+// Health : The readiness checks. Call Shutdown on it when the service starts shutting down.
+func (c *Container) Health() *health.Checker {
+	return c.health
+}
+
+// HealthHandler : Serves /healthz and /readyz without authentication.
+func (c *Container) HealthHandler() *handler.HealthHandler {
+	return c.healthHandler
+}
+
+// MetricsHandler : Serves Prometheus metrics without authentication.
+func (c *Container) MetricsHandler() *handler.MetricsHandler {
+	return c.metricsHandler
+}
+
+// DocsHandler : Serves the OpenAPI document and Swagger UI without authentication.
+func (c *Container) DocsHandler() *handler.DocsHandler {
+	return c.docsHandler
+}
+
+func (c *Container) GraphQLHandler() *handler.GraphQLHandler {
+	return c.graphqlHandler
+}
+
+func (c *Container) TaskStreamHandler() *handler.TaskStreamHandler {
+	return c.streamHandler
+}
+
+func (c *Container) WebhookHandler() *handler.WebhookHandler {
+	return c.webhookHandler
 }
 
 func (c *Container) TaskHandler() *handler.TaskHandler {
 	return c.taskHandler
 }
 
+func (c *Container) AdminHandler() *handler.AdminHandler {
+	return c.adminHandler
+}
+
 func (c *Container) Config() *config.Config {
 	return c.config
 }
@@ -164,6 +542,15 @@ func (c *Container) TaskService() *service.TaskService {
 	return c.taskService
 }
 
+// TaskProjectionService : Returns nil unless event sourcing is enabled.
+func (c *Container) TaskProjectionService() *service.TaskProjectionService {
+	return c.projectionSvc
+}
+
+func (c *Container) IdempotencyService() *service.IdempotencyService {
+	return c.idempotency
+}
+
 func (c *Container) TaskRepository() repository.TaskRepository {
 	return c.taskRepository
 }
@@ -176,15 +563,21 @@ func (c *Container) Close() {
 		}
 	}
 
-	if c.kafkaProducer != nil {
-		if err := c.kafkaProducer.Close(); err != nil {
-			logger.Error("Failed to close Kafka producer", "error", err)
+	if c.eventBus != nil {
+		if err := c.eventBus.Close(); err != nil {
+			logger.Error("Failed to close event bus", "error", err)
+		}
+	}
+
+	if c.dlqReplayer != nil {
+		if err := c.dlqReplayer.Close(); err != nil {
+			logger.Error("Failed to close Kafka DLQ replayer", "error", err)
 		}
 	}
 
-	if c.kafkaConsumer != nil {
-		if err := c.kafkaConsumer.Close(); err != nil {
-			logger.Error("Failed to close Kafka consumer", "error", err)
+	if c.redisClient != nil {
+		if err := c.redisClient.Close(); err != nil {
+			logger.Error("Failed to close Redis client", "error", err)
 		}
 	}
 }
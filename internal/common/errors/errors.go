@@ -8,4 +8,8 @@ var (
 	ErrNotFound        = errors.New("entity not found")
 	ErrDuplicateEntity = errors.New("entity already exists")
 	ErrInvalidStatus   = errors.New("invalid status")
+	ErrConflict        = errors.New("concurrent modification")
+	ErrValidation      = errors.New("validation failed")
+	// ErrEventSourcingDisabled is returned by reads that need the event store when it is not enabled.
+	ErrEventSourcingDisabled = errors.New("event sourcing is not enabled")
 )
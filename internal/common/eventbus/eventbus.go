@@ -0,0 +1,99 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	DriverKafka  = "kafka"
+	DriverNATS   = "nats"
+	DriverMemory = "memory"
+	DriverNone   = "none"
+)
+
+const (
+	HeaderDLQOriginalTopic = "x-dlq-original-topic"
+	HeaderDLQError         = "x-dlq-error"
+	HeaderDLQErrorType     = "x-dlq-error-type"
+	HeaderDLQAttempts      = "x-dlq-attempts"
+	HeaderDLQFailedAt      = "x-dlq-failed-at"
+	HeaderDLQReplayCount   = "x-dlq-replay-count"
+
+	DLQHeaderPrefix = "x-dlq-"
+	// DLQSuffix is appended to a topic to name its dead-letter topic.
+	DLQSuffix = ".dlq"
+
+	ErrorTypePermanent = "permanent"
+	ErrorTypeRetryable = "retryable"
+)
+
+var ErrClosed = errors.New("event bus is closed")
+
+type Message struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Value   []byte
+}
+
+type Handler func(ctx context.Context, msg *Message) error
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+type Bus interface {
+	Publisher
+	// Subscribe consumes topic as a member of group and blocks until ctx is cancelled. Every group
+	// receives each message once; subscribers sharing a group split the messages between them.
+	Subscribe(ctx context.Context, topic, group string, handler Handler, options ...SubscribeOption) error
+	Close() error
+}
+
+// Pinger : Implemented by buses that talk to a broker, to check that it can be reached.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type SubscribeOptions struct {
+	Transient bool
+}
+
+type SubscribeOption func(*SubscribeOptions)
+
+// Transient : Marks the group as private to this process. It starts at the latest message instead of
+// the oldest, and backends may discard it once the subscriber is gone. Messages its handler gives up on
+// are dropped rather than dead-lettered, since the durable groups already park the failures they see.
+func Transient() SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.Transient = true
+	}
+}
+
+func ApplySubscribeOptions(options ...SubscribeOption) SubscribeOptions {
+	var applied SubscribeOptions
+	for _, option := range options {
+		option(&applied)
+	}
+	return applied
+}
+
+func ParseDriver(value string) (string, error) {
+	switch driver := strings.ToLower(value); driver {
+	case DriverKafka, DriverNATS, DriverMemory, DriverNone:
+		return driver, nil
+	default:
+		return "", fmt.Errorf("unsupported event bus driver %q", value)
+	}
+}
+
+// ErrorType : Classifies a handler error for dead-letter headers.
+func ErrorType(err error) string {
+	if IsPermanent(err) {
+		return ErrorTypePermanent
+	}
+	return ErrorTypeRetryable
+}
@@ -0,0 +1,162 @@
+package eventbus
+
+import (
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemoryBufferSize = 256
+	// memoryPublishTimeout : How long Publish waits for room in a group's buffer before giving up, so a
+	// stalled subscriber can't hold up publishers.
+	memoryPublishTimeout = 100 * time.Millisecond
+)
+
+var ErrBufferFull = errors.New("event bus subscriber buffer is full")
+
+// MemoryBus : In-process bus for local development and tests. Messages published before a group
+// subscribes are not delivered to it, and nothing survives a restart.
+type MemoryBus struct {
+	mu          sync.RWMutex
+	topics      map[string]map[string]chan *Message
+	bufferSize  int
+	retryPolicy RetryPolicy
+	closed      bool
+}
+
+var _ Bus = (*MemoryBus)(nil)
+
+func NewMemoryBus(bufferSize int, retryPolicy RetryPolicy) *MemoryBus {
+	if bufferSize < 1 {
+		bufferSize = defaultMemoryBufferSize
+	}
+	return &MemoryBus{
+		topics:      make(map[string]map[string]chan *Message),
+		bufferSize:  bufferSize,
+		retryPolicy: retryPolicy,
+	}
+}
+
+// Publish : Queues the message for every group subscribed to its topic. The lock is released before
+// sending, so a slow subscriber doesn't block Close or other publishers. When a group's buffer stays full
+// for memoryPublishTimeout, the message is dropped for that group and ErrBufferFull is returned;
+// the other groups still receive it.
+func (b *MemoryBus) Publish(ctx context.Context, msg *Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	queues := make([]chan *Message, 0, len(b.topics[msg.Topic]))
+	for _, queue := range b.topics[msg.Topic] {
+		queues = append(queues, queue)
+	}
+	b.mu.RUnlock()
+
+	timer := time.NewTimer(memoryPublishTimeout)
+	defer timer.Stop()
+	expired := false
+	dropped := 0
+	for _, queue := range queues {
+		if expired {
+			// Out of time: only groups with room still get the message.
+			select {
+			case queue <- cloneMessage(msg):
+			default:
+				dropped++
+			}
+			continue
+		}
+		select {
+		case queue <- cloneMessage(msg):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			expired = true
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		return fmt.Errorf("%w: message on %s dropped for %d group(s)", ErrBufferFull, msg.Topic, dropped)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, topic, group string, handler Handler, _ ...SubscribeOption) error {
+	queue, err := b.queue(topic, group)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case msg := <-queue:
+			b.deliver(ctx, msg, handler)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (b *MemoryBus) queue(topic, group string) (chan *Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	groups, ok := b.topics[topic]
+	if !ok {
+		groups = make(map[string]chan *Message)
+		b.topics[topic] = groups
+	}
+	queue, ok := groups[group]
+	if !ok {
+		queue = make(chan *Message, b.bufferSize)
+		groups[group] = queue
+	}
+	return queue, nil
+}
+
+func (b *MemoryBus) deliver(ctx context.Context, msg *Message, handler Handler) {
+	logger := loggingtype.ForPackage("eventbus")
+	attempt := 0
+	for {
+		err := handler(ctx, msg)
+		if err == nil {
+			return
+		}
+		attempt++
+
+		if IsPermanent(err) || attempt > b.retryPolicy.MaxRetries {
+			logger.ErrorContext(ctx, "Dropping message after failed delivery", "topic", msg.Topic, "key", msg.Key, "attempts", attempt, "error", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.retryPolicy.Backoff(attempt - 1)):
+		}
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+func cloneMessage(msg *Message) *Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	value := make([]byte, len(msg.Value))
+	copy(value, msg.Value)
+	return &Message{Topic: msg.Topic, Key: msg.Key, Headers: headers, Value: value}
+}
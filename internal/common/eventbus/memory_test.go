@@ -0,0 +1,148 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+
+// subscribeAsync : Starts a subscription and waits until the bus has registered it.
+func subscribeAsync(t *testing.T, ctx context.Context, bus *MemoryBus, topic, group string, handler Handler) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, bus.Subscribe(ctx, topic, group, handler))
+	}()
+	require.Eventually(t, func() bool {
+		bus.mu.RLock()
+		defer bus.mu.RUnlock()
+		_, ok := bus.topics[topic][group]
+		return ok
+	}, time.Second, time.Millisecond)
+	return &wg
+}
+
+func TestMemoryBus_DeliversToEveryGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewMemoryBus(8, testRetryPolicy)
+
+	received := make(chan string, 4)
+	for _, group := range []string{"group-a", "group-b"} {
+		group := group
+		subscribeAsync(t, ctx, bus, "task-events", group, func(_ context.Context, msg *Message) error {
+			received <- group + ":" + msg.Key + ":" + msg.Headers["ce_type"]
+			return nil
+		})
+	}
+
+	require.NoError(t, bus.Publish(ctx, &Message{
+		Topic:   "task-events",
+		Key:     "task-1",
+		Headers: map[string]string{"ce_type": "TASK_CREATED"},
+		Value:   []byte(`{}`),
+	}))
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "other-topic", Key: "ignored"}))
+
+	got := []string{<-received, <-received}
+	assert.ElementsMatch(t, []string{"group-a:task-1:TASK_CREATED", "group-b:task-1:TASK_CREATED"}, got)
+	assert.Never(t, func() bool { return len(received) > 0 }, 20*time.Millisecond, time.Millisecond)
+}
+
+func TestMemoryBus_RetriesFailedDeliveries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewMemoryBus(8, testRetryPolicy)
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	done := make(chan string, 2)
+	subscribeAsync(t, ctx, bus, "task-events", "group", func(_ context.Context, msg *Message) error {
+		mu.Lock()
+		attempts[msg.Key]++
+		count := attempts[msg.Key]
+		mu.Unlock()
+
+		switch {
+		case msg.Key == "permanent":
+			done <- msg.Key
+			return Permanent(errors.New("bad payload"))
+		case count < 3:
+			return errors.New("temporarily unavailable")
+		}
+		done <- msg.Key
+		return nil
+	})
+
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "task-events", Key: "transient"}))
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "task-events", Key: "permanent"}))
+
+	assert.Equal(t, "transient", <-done)
+	assert.Equal(t, "permanent", <-done)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, attempts["transient"])
+	assert.Equal(t, 1, attempts["permanent"])
+}
+
+func TestMemoryBus_SubscribeReturnsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := NewMemoryBus(1, testRetryPolicy)
+
+	wg := subscribeAsync(t, ctx, bus, "task-events", "group", func(context.Context, *Message) error { return nil })
+	cancel()
+	wg.Wait()
+
+	require.NoError(t, bus.Close())
+	assert.ErrorIs(t, bus.Publish(context.Background(), &Message{Topic: "task-events"}), ErrClosed)
+}
+
+func TestMemoryBus_PublishDoesNotBlockOnAFullBuffer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewMemoryBus(1, testRetryPolicy)
+
+	release := make(chan struct{})
+	var received atomic.Int32
+	stalled := subscribeAsync(t, ctx, bus, "task-events", "stalled", func(context.Context, *Message) error {
+		<-release
+		return nil
+	})
+	quick := subscribeAsync(t, ctx, bus, "task-events", "quick", func(context.Context, *Message) error {
+		received.Add(1)
+		return nil
+	})
+
+	// One message is held by the stalled handler and one fills its buffer.
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "task-events"}))
+	require.Eventually(t, func() bool { return bus.Publish(ctx, &Message{Topic: "task-events"}) == nil }, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	err := bus.Publish(ctx, &Message{Topic: "task-events"})
+	assert.ErrorIs(t, err, ErrBufferFull)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The full group doesn't stop Close or delivery to the other group.
+	closed := make(chan error, 1)
+	go func() { closed <- bus.Close() }()
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind a full subscriber")
+	}
+	require.Eventually(t, func() bool { return received.Load() >= 3 }, time.Second, time.Millisecond)
+
+	close(release)
+	cancel()
+	stalled.Wait()
+	quick.Wait()
+}
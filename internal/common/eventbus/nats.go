@@ -0,0 +1,185 @@
+package eventbus
+
+import (
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"errors"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderMessageKey = "x-message-key"
+
+	natsAckWait = 30 * time.Second
+	// natsTransientInactiveThreshold : How long the server keeps a transient consumer after its subscriber disappears.
+	natsTransientInactiveThreshold = 5 * time.Minute
+)
+
+// NATSBus : Bus backed by a NATS JetStream stream. Topics are subjects of the stream and groups are
+// durable consumers, so a group resumes where it left off after a restart.
+type NATSBus struct {
+	conn        *nats.Conn
+	js          jetstream.JetStream
+	stream      string
+	retryPolicy RetryPolicy
+}
+
+var (
+	_ Bus    = (*NATSBus)(nil)
+	_ Pinger = (*NATSBus)(nil)
+)
+
+func NewNATSBus(ctx context.Context, url, stream string, subjects []string, retryPolicy RetryPolicy) (*NATSBus, error) {
+	conn, err := nats.Connect(url, nats.Name("alle-task-manager"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	streamSubjects := make([]string, 0, len(subjects)*2)
+	for _, subject := range subjects {
+		streamSubjects = append(streamSubjects, subject, subject+DLQSuffix)
+	}
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: streamSubjects,
+	}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NATSBus{
+		conn:        conn,
+		js:          js,
+		stream:      stream,
+		retryPolicy: retryPolicy,
+	}, nil
+}
+
+func (b *NATSBus) Publish(ctx context.Context, msg *Message) error {
+	natsMsg := nats.NewMsg(msg.Topic)
+	for key, value := range msg.Headers {
+		natsMsg.Header.Set(key, value)
+	}
+	if msg.Key != "" {
+		natsMsg.Header.Set(HeaderMessageKey, msg.Key)
+	}
+	natsMsg.Data = msg.Value
+
+	ack, err := b.js.PublishMsg(ctx, natsMsg)
+	if err != nil {
+		loggingtype.ForPackage("eventbus").ErrorContext(ctx, "failed to publish message to NATS", "subject", msg.Topic, "error", err)
+		return err
+	}
+	loggingtype.ForPackage("eventbus").InfoContext(ctx, "Message published", "subject", msg.Topic, "stream", ack.Stream, "sequence", ack.Sequence)
+	return nil
+}
+
+func (b *NATSBus) Subscribe(ctx context.Context, topic, group string, handler Handler, options ...SubscribeOption) error {
+	consumerConfig := jetstream.ConsumerConfig{
+		Durable:       group,
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+	}
+	transient := ApplySubscribeOptions(options...).Transient
+	if transient {
+		consumerConfig.DeliverPolicy = jetstream.DeliverNewPolicy
+		consumerConfig.InactiveThreshold = natsTransientInactiveThreshold
+	}
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, consumerConfig)
+	if err != nil {
+		return err
+	}
+
+	consumeContext, err := consumer.Consume(func(natsMsg jetstream.Msg) {
+		b.deliver(ctx, natsMsg, handler, transient)
+	})
+	if err != nil {
+		return err
+	}
+	defer consumeContext.Stop()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *NATSBus) deliver(ctx context.Context, natsMsg jetstream.Msg, handler Handler, transient bool) {
+	logger := loggingtype.ForPackage("eventbus")
+	msg := fromNATSMessage(natsMsg)
+
+	attempt := 1
+	if metadata, err := natsMsg.Metadata(); err == nil {
+		attempt = int(metadata.NumDelivered)
+	}
+
+	err := handler(ctx, msg)
+	if err == nil {
+		if ackErr := natsMsg.Ack(); ackErr != nil {
+			logger.ErrorContext(ctx, "Failed to ack NATS message", "subject", msg.Topic, "error", ackErr)
+		}
+		return
+	}
+
+	if !IsPermanent(err) && attempt <= b.retryPolicy.MaxRetries {
+		backoff := b.retryPolicy.Backoff(attempt - 1)
+		logger.WarnContext(ctx, "Retrying message", "subject", msg.Topic, "attempt", attempt, "backoff", backoff.String(), "error", err)
+		_ = natsMsg.NakWithDelay(backoff)
+		return
+	}
+
+	logger.ErrorContext(ctx, "Giving up on message", "subject", msg.Topic, "attempts", attempt, "permanent", IsPermanent(err), "error", err)
+	if transient {
+		_ = natsMsg.Term()
+		return
+	}
+	if dlqErr := b.Publish(ctx, deadLetter(msg, err, attempt)); dlqErr != nil {
+		logger.ErrorContext(ctx, "Failed to forward message to dead-letter subject", "subject", msg.Topic, "error", dlqErr)
+		_ = natsMsg.NakWithDelay(b.retryPolicy.MaxBackoff)
+		return
+	}
+	_ = natsMsg.Term()
+}
+
+// Ping : Looks up the stream, which needs both the server and JetStream to answer.
+func (b *NATSBus) Ping(ctx context.Context) error {
+	_, err := b.js.Stream(ctx, b.stream)
+	return err
+}
+
+func (b *NATSBus) Close() error {
+	if err := b.conn.Drain(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return err
+	}
+	return nil
+}
+
+func fromNATSMessage(natsMsg jetstream.Msg) *Message {
+	headers := make(map[string]string, len(natsMsg.Headers()))
+	for key := range natsMsg.Headers() {
+		headers[key] = natsMsg.Headers().Get(key)
+	}
+	key := headers[HeaderMessageKey]
+	delete(headers, HeaderMessageKey)
+	return &Message{Topic: natsMsg.Subject(), Key: key, Headers: headers, Value: natsMsg.Data()}
+}
+
+func deadLetter(msg *Message, cause error, attempts int) *Message {
+	headers := make(map[string]string, len(msg.Headers)+5)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[HeaderDLQOriginalTopic] = msg.Topic
+	headers[HeaderDLQError] = cause.Error()
+	headers[HeaderDLQErrorType] = ErrorType(cause)
+	headers[HeaderDLQAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+	return &Message{Topic: msg.Topic + DLQSuffix, Key: msg.Key, Headers: headers, Value: msg.Value}
+}
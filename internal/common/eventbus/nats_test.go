@@ -0,0 +1,116 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+func newTestNATSBus(t *testing.T) *NATSBus {
+	srv := runJetStreamServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bus, err := NewNATSBus(ctx, srv.ClientURL(), "TASK_EVENTS", []string{"task-events"}, testRetryPolicy)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	return bus
+}
+
+func TestNATSBus_PublishSubscribe(t *testing.T) {
+	bus := newTestNATSBus(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, bus.Publish(ctx, &Message{
+		Topic:   "task-events",
+		Key:     "task-1",
+		Headers: map[string]string{"ce_type": "TASK_CREATED"},
+		Value:   []byte(`{"title":"Task"}`),
+	}))
+
+	received := make(chan *Message, 1)
+	go func() {
+		_ = bus.Subscribe(ctx, "task-events", "task-management-group", func(_ context.Context, msg *Message) error {
+			received <- msg
+			return nil
+		})
+	}()
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "task-events", msg.Topic)
+		assert.Equal(t, "task-1", msg.Key)
+		assert.Equal(t, "TASK_CREATED", msg.Headers["ce_type"])
+		assert.JSONEq(t, `{"title":"Task"}`, string(msg.Value))
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+}
+
+func TestNATSBus_RetriesThenDeadLetters(t *testing.T) {
+	bus := newTestNATSBus(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts atomic.Int32
+	go func() {
+		_ = bus.Subscribe(ctx, "task-events", "task-management-group", func(context.Context, *Message) error {
+			attempts.Add(1)
+			return errors.New("still unavailable")
+		})
+	}()
+	deadLetters := make(chan *Message, 1)
+	go func() {
+		_ = bus.Subscribe(ctx, "task-events"+DLQSuffix, "dlq-inspector", func(_ context.Context, msg *Message) error {
+			deadLetters <- msg
+			return nil
+		})
+	}()
+
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "task-events", Key: "task-1", Value: []byte(`{}`)}))
+
+	select {
+	case msg := <-deadLetters:
+		assert.Equal(t, "task-1", msg.Key)
+		assert.Equal(t, "task-events", msg.Headers[HeaderDLQOriginalTopic])
+		assert.Equal(t, ErrorTypeRetryable, msg.Headers[HeaderDLQErrorType])
+		assert.Equal(t, "3", msg.Headers[HeaderDLQAttempts])
+		assert.Equal(t, "still unavailable", msg.Headers[HeaderDLQError])
+	case <-time.After(10 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestNATSBus_Ping(t *testing.T) {
+	srv := runJetStreamServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bus, err := NewNATSBus(ctx, srv.ClientURL(), "TASK_EVENTS", []string{"task-events"}, testRetryPolicy)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+
+	require.NoError(t, bus.Ping(ctx))
+
+	srv.Shutdown()
+	pingCtx, pingCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer pingCancel()
+	assert.Error(t, bus.Ping(pingCtx))
+}
@@ -0,0 +1,29 @@
+package eventbus
+
+import (
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+)
+
+// NoopBus : Used when event delivery is disabled. Published messages are dropped and subscriptions idle.
+type NoopBus struct{}
+
+var _ Bus = NoopBus{}
+
+func NewNoopBus() NoopBus {
+	return NoopBus{}
+}
+
+func (NoopBus) Publish(ctx context.Context, msg *Message) error {
+	loggingtype.ForPackage("eventbus").DebugContext(ctx, "Event delivery disabled, dropping message", "topic", msg.Topic, "key", msg.Key)
+	return nil
+}
+
+func (NoopBus) Subscribe(ctx context.Context, _, _ string, _ Handler, _ ...SubscribeOption) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (NoopBus) Close() error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(0))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, time.Second, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(10))
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad payload")
+
+	assert.Nil(t, Permanent(nil))
+	assert.False(t, IsPermanent(cause))
+	assert.True(t, IsPermanent(Permanent(cause)))
+	assert.True(t, IsPermanent(fmt.Errorf("wrapped: %w", Permanent(cause))))
+	assert.ErrorIs(t, Permanent(cause), cause)
+}
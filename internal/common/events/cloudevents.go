@@ -0,0 +1,167 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	CloudEventsSource      = "/alle-task-manager/tasks"
+
+	ContentTypeJSON            = "application/json"
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+
+	HeaderContentType = "content-type"
+	// HeaderPrefix is the attribute prefix of the CloudEvents Kafka protocol binding in binary mode.
+	HeaderPrefix = "ce_"
+	// HeaderCorrelationID carries TaskEvent.CorrelationID in both modes, so consumers can read it without
+	// decoding the payload.
+	HeaderCorrelationID = "x-correlation-id"
+)
+
+type Mode string
+
+const (
+	ModeStructured Mode = "structured"
+	ModeBinary     Mode = "binary"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(strings.ToLower(value)) {
+	case ModeStructured:
+		return ModeStructured, nil
+	case ModeBinary:
+		return ModeBinary, nil
+	default:
+		return "", fmt.Errorf("unsupported CloudEvents mode %q", value)
+	}
+}
+
+// CloudEvent : CloudEvents 1.0 envelope around a task event payload.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func NewCloudEvent(base TaskEvent, data interface{}) (*CloudEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              base.EventID,
+		Source:          CloudEventsSource,
+		Type:            base.EventType,
+		Subject:         base.TaskID,
+		Time:            base.Timestamp.UTC(),
+		DataContentType: ContentTypeJSON,
+		DataSchema:      DataSchema(base.EventType),
+		Data:            raw,
+	}, nil
+}
+
+// Encode : Serialises the event as message headers and body for the given content mode.
+func (e *CloudEvent) Encode(mode Mode) (map[string]string, []byte, error) {
+	switch mode {
+	case ModeBinary:
+		headers := map[string]string{
+			HeaderPrefix + "specversion": e.SpecVersion,
+			HeaderPrefix + "id":          e.ID,
+			HeaderPrefix + "source":      e.Source,
+			HeaderPrefix + "type":        e.Type,
+			HeaderPrefix + "time":        e.Time.Format(time.RFC3339Nano),
+			HeaderContentType:            e.DataContentType,
+		}
+		if e.Subject != "" {
+			headers[HeaderPrefix+"subject"] = e.Subject
+		}
+		if e.DataSchema != "" {
+			headers[HeaderPrefix+"dataschema"] = e.DataSchema
+		}
+		return headers, e.Data, nil
+	case ModeStructured, "":
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{HeaderContentType: ContentTypeCloudEventsJSON}, body, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported CloudEvents mode %q", mode)
+	}
+}
+
+// DecodeCloudEvent : Reads an event in binary or structured mode. Bodies without an envelope are
+// treated as a bare task event, which is how events were published before the envelope existed.
+func DecodeCloudEvent(headers map[string]string, body []byte) (*CloudEvent, error) {
+	if specVersion, ok := headers[HeaderPrefix+"specversion"]; ok {
+		event := &CloudEvent{
+			SpecVersion:     specVersion,
+			ID:              headers[HeaderPrefix+"id"],
+			Source:          headers[HeaderPrefix+"source"],
+			Type:            headers[HeaderPrefix+"type"],
+			Subject:         headers[HeaderPrefix+"subject"],
+			DataContentType: headers[HeaderContentType],
+			DataSchema:      headers[HeaderPrefix+"dataschema"],
+			Data:            body,
+		}
+		if value := headers[HeaderPrefix+"time"]; value != "" {
+			eventTime, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %stime header: %w", HeaderPrefix, err)
+			}
+			event.Time = eventTime
+		}
+		return event, event.validate()
+	}
+
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return nil, err
+	}
+	if probe.SpecVersion != "" || strings.HasPrefix(headers[HeaderContentType], ContentTypeCloudEventsJSON) {
+		var event CloudEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, err
+		}
+		return &event, event.validate()
+	}
+
+	var legacy TaskEvent
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              legacy.EventID,
+		Source:          CloudEventsSource,
+		Type:            legacy.EventType,
+		Subject:         legacy.TaskID,
+		Time:            legacy.Timestamp,
+		DataContentType: ContentTypeJSON,
+		Data:            body,
+	}, nil
+}
+
+func (e *CloudEvent) validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("unsupported CloudEvents specversion %q", e.SpecVersion)
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return errors.New("CloudEvent is missing a required attribute (id, source or type)")
+	}
+	return nil
+}
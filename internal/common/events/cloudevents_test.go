@@ -0,0 +1,80 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestCreatedEvent(t *testing.T) *CloudEvent {
+	base := TaskEvent{EventID: "event-1", TaskID: "task-1", EventType: EventTypeTaskCreated, Timestamp: time.Now()}
+	event, err := NewCloudEvent(base, &TaskCreatedEvent{TaskEvent: base, Title: "Title", Description: "Description", Status: "pending"})
+	require.NoError(t, err)
+	return event
+}
+
+func TestCloudEvent_RoundTrip(t *testing.T) {
+	event := newTestCreatedEvent(t)
+
+	for _, mode := range []Mode{ModeStructured, ModeBinary} {
+		t.Run(string(mode), func(t *testing.T) {
+			headers, body, err := event.Encode(mode)
+			require.NoError(t, err)
+
+			decoded, err := DecodeCloudEvent(headers, body)
+			require.NoError(t, err)
+
+			assert.Equal(t, event.ID, decoded.ID)
+			assert.Equal(t, event.Type, decoded.Type)
+			assert.Equal(t, event.Subject, decoded.Subject)
+			assert.Equal(t, event.DataSchema, decoded.DataSchema)
+			assert.True(t, event.Time.Equal(decoded.Time))
+			assert.JSONEq(t, string(event.Data), string(decoded.Data))
+		})
+	}
+}
+
+func TestCloudEvent_EncodeBinaryUsesKafkaHeaders(t *testing.T) {
+	headers, body, err := newTestCreatedEvent(t).Encode(ModeBinary)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.0", headers["ce_specversion"])
+	assert.Equal(t, EventTypeTaskCreated, headers["ce_type"])
+	assert.Equal(t, "urn:alle-task-manager:events:task_created:v1", headers["ce_dataschema"])
+	assert.Equal(t, ContentTypeJSON, headers[HeaderContentType])
+	assert.NoError(t, ValidateData(headers["ce_dataschema"], body))
+}
+
+func TestDecodeCloudEvent_LegacyPayload(t *testing.T) {
+	body, err := json.Marshal(&TaskUpdatedEvent{
+		TaskEvent: TaskEvent{EventID: "event-2", TaskID: "task-1", EventType: EventTypeTaskUpdated, Timestamp: time.Now()},
+		Status:    "completed",
+	})
+	require.NoError(t, err)
+
+	decoded, err := DecodeCloudEvent(nil, body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "event-2", decoded.ID)
+	assert.Equal(t, EventTypeTaskUpdated, decoded.Type)
+	assert.Equal(t, "task-1", decoded.Subject)
+	assert.JSONEq(t, string(body), string(decoded.Data))
+}
+
+func TestDecodeCloudEvent_RejectsMissingAttributes(t *testing.T) {
+	_, err := DecodeCloudEvent(map[string]string{"ce_specversion": "1.0", "ce_type": EventTypeTaskCreated}, []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = DecodeCloudEvent(nil, []byte(`{"specversion":"0.3","id":"1","source":"/x","type":"t"}`))
+	assert.Error(t, err)
+}
+
+func TestValidateData(t *testing.T) {
+	event := newTestCreatedEvent(t)
+	assert.NoError(t, ValidateData(event.DataSchema, event.Data))
+
+	assert.Error(t, ValidateData(event.DataSchema, []byte(`{"event_id":"event-1","status":"archived"}`)))
+	assert.Error(t, ValidateData("urn:unknown", event.Data))
+}
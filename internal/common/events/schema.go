@@ -0,0 +1,87 @@
+package events
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"sync"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// dataSchemas : Current payload version per event type. Older versions stay in schemaFileNames
+// so events already on the topic can still be validated.
+var dataSchemas = map[string]string{
+	EventTypeTaskCreated: "urn:alle-task-manager:events:task_created:v1",
+	EventTypeTaskUpdated: "urn:alle-task-manager:events:task_updated:v2",
+	EventTypeTaskDeleted: "urn:alle-task-manager:events:task_deleted:v1",
+}
+
+var schemaFileNames = map[string]string{
+	"urn:alle-task-manager:events:task_created:v1": "schemas/task_created.v1.json",
+	"urn:alle-task-manager:events:task_updated:v1": "schemas/task_updated.v1.json",
+	"urn:alle-task-manager:events:task_updated:v2": "schemas/task_updated.v2.json",
+	"urn:alle-task-manager:events:task_deleted:v1": "schemas/task_deleted.v1.json",
+}
+
+var (
+	compiledSchemas map[string]*jsonschema.Schema
+	compileOnce     sync.Once
+	compileErr      error
+)
+
+// DataSchema : Returns the dataschema URI of the current payload version for an event type.
+func DataSchema(eventType string) string {
+	return dataSchemas[eventType]
+}
+
+// ValidateData : Checks an event payload against the JSON Schema its dataschema URI points to.
+func ValidateData(dataSchema string, data []byte) error {
+	compileOnce.Do(compileSchemas)
+	if compileErr != nil {
+		return compileErr
+	}
+
+	schema, ok := compiledSchemas[dataSchema]
+	if !ok {
+		return fmt.Errorf("unknown dataschema %q", dataSchema)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return schema.Validate(instance)
+}
+
+func compileSchemas() {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	for uri, name := range schemaFileNames {
+		raw, err := schemaFiles.ReadFile(name)
+		if err != nil {
+			compileErr = err
+			return
+		}
+		document, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+		if err != nil {
+			compileErr = fmt.Errorf("parsing %s: %w", name, err)
+			return
+		}
+		if err := compiler.AddResource(uri, document); err != nil {
+			compileErr = err
+			return
+		}
+	}
+
+	compiledSchemas = make(map[string]*jsonschema.Schema, len(schemaFileNames))
+	for uri := range schemaFileNames {
+		schema, err := compiler.Compile(uri)
+		if err != nil {
+			compileErr = err
+			return
+		}
+		compiledSchemas[uri] = schema
+	}
+}
@@ -9,6 +9,18 @@ type TaskEvent struct {
 	TaskID    string    `json:"task_id"`
 	EventType string    `json:"event_type"`
 	Timestamp time.Time `json:"timestamp"`
+	// CorrelationID is the X-Request-ID of the request that caused the event, empty when there was none.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+type TaskSnapshot struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	DueDate     *time.Time `json:"due_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 type TaskCreatedEvent struct {
@@ -23,9 +35,19 @@ type TaskUpdatedEvent struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Status      string `json:"status"`
+
+	Task          TaskSnapshot           `json:"task"`
+	Previous      map[string]interface{} `json:"previous"`
+	ChangedFields []string               `json:"changed_fields"`
+}
+
+type TaskDeletedEvent struct {
+	TaskEvent
+	Task TaskSnapshot `json:"task"`
 }
 
 const (
 	EventTypeTaskCreated = "TASK_CREATED"
 	EventTypeTaskUpdated = "TASK_UPDATED"
+	EventTypeTaskDeleted = "TASK_DELETED"
 )
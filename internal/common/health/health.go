@@ -0,0 +1,148 @@
+// Package health runs the readiness checks behind /readyz.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFailing      = "failing"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc : Reports whether a dependency is usable. It should give up when ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+type CheckOption func(*check)
+
+// WithTimeout : Overrides the checker's default timeout for one check.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+type check struct {
+	name    string
+	timeout time.Duration
+	run     CheckFunc
+}
+
+// CheckResult : The outcome of one check. Duration is how long it took, or the timeout when it timed out.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report : Status is ok when every check passed, failing when any failed and shutting_down once Shutdown was
+// called, in which case no checks are run.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker : Runs the registered checks concurrently, each with a timeout of its own.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register : Adds a check under name, replacing any check of the same name.
+func (c *Checker) Register(name string, run CheckFunc, options ...CheckOption) {
+	registered := check{name: name, timeout: c.timeout, run: run}
+	for _, option := range options {
+		option(&registered)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.checks {
+		if c.checks[i].name == name {
+			c.checks[i] = registered
+			return
+		}
+	}
+	c.checks = append(c.checks, registered)
+}
+
+// Shutdown : Makes every later report fail, so load balancers stop routing to the instance while it drains.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+		return report
+	}
+
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, registered := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = registered.execute(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for i, registered := range checks {
+		report.Checks[registered.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
+
+// execute : Stops waiting at the timeout even when the check ignores its context.
+func (c check) execute(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- c.run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+	result := CheckResult{Status: StatusOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
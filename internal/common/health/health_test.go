@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("is ready without checks", func(t *testing.T) {
+		report := NewChecker(time.Second).Check(ctx)
+		assert.True(t, report.Ready())
+		assert.Empty(t, report.Checks)
+	})
+
+	t.Run("reports every check and fails when one fails", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("database", func(context.Context) error { return nil })
+		checker.Register("kafka", func(context.Context) error { return errors.New("no brokers available") })
+
+		report := checker.Check(ctx)
+		assert.False(t, report.Ready())
+		assert.Equal(t, StatusFailing, report.Status)
+		assert.Equal(t, StatusOK, report.Checks["database"].Status)
+		assert.Empty(t, report.Checks["database"].Error)
+		assert.Equal(t, StatusFailing, report.Checks["kafka"].Status)
+		assert.Equal(t, "no brokers available", report.Checks["kafka"].Error)
+	})
+
+	t.Run("times out checks that take too long", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("stuck", func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}, WithTimeout(20*time.Millisecond))
+		checker.Register("patient", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithTimeout(20*time.Millisecond))
+
+		start := time.Now()
+		report := checker.Check(ctx)
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "checks run concurrently and are abandoned at their timeout")
+		assert.Equal(t, "timed out after 20ms", report.Checks["stuck"].Error)
+		assert.Equal(t, StatusFailing, report.Checks["patient"].Status)
+	})
+
+	t.Run("turns panics into failures", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("broken", func(context.Context) error { panic("nil connection") })
+		assert.Equal(t, "check panicked: nil connection", checker.Check(ctx).Checks["broken"].Error)
+	})
+
+	t.Run("replaces checks registered under the same name", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		checker.Register("database", func(context.Context) error { return errors.New("down") })
+		checker.Register("database", func(context.Context) error { return nil })
+		report := checker.Check(ctx)
+		assert.True(t, report.Ready())
+		assert.Len(t, report.Checks, 1)
+	})
+
+	t.Run("fails without running checks once shutting down", func(t *testing.T) {
+		checker := NewChecker(time.Second)
+		ran := false
+		checker.Register("database", func(context.Context) error {
+			ran = true
+			return nil
+		})
+		checker.Shutdown()
+
+		report := checker.Check(ctx)
+		assert.Equal(t, StatusShuttingDown, report.Status)
+		assert.False(t, report.Ready())
+		assert.False(t, ran)
+	})
+}
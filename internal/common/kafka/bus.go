@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"context"
+	"errors"
+	"github.com/IBM/sarama"
+	"sync"
+)
+
+// Bus : eventbus.Bus on top of the Kafka producer and one consumer group per subscription.
+type Bus struct {
+	mu        sync.Mutex
+	brokers   []string
+	producer  *Producer
+	options   []ConsumerOption
+	consumers []*Consumer
+}
+
+var (
+	_ eventbus.Bus    = (*Bus)(nil)
+	_ eventbus.Pinger = (*Bus)(nil)
+)
+
+func NewBus(brokers []string, producer *Producer, options ...ConsumerOption) *Bus {
+	return &Bus{
+		brokers:  brokers,
+		producer: producer,
+		options:  options,
+	}
+}
+
+func (b *Bus) Publish(ctx context.Context, msg *eventbus.Message) error {
+	return b.producer.Send(ctx, &sarama.ProducerMessage{
+		Topic:   msg.Topic,
+		Key:     sarama.StringEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: ToRecordHeaders(msg.Headers),
+	})
+}
+
+// Ping : Fetches the cluster metadata through the producer's client.
+func (b *Bus) Ping(ctx context.Context) error {
+	return b.producer.Ping(ctx)
+}
+
+func (b *Bus) Subscribe(ctx context.Context, topic, group string, handler eventbus.Handler, options ...eventbus.SubscribeOption) error {
+	initialOffset := sarama.OffsetOldest
+	consumerOptions := b.options
+	if eventbus.ApplySubscribeOptions(options...).Transient {
+		initialOffset = sarama.OffsetNewest
+		consumerOptions = append(consumerOptions[:len(consumerOptions):len(consumerOptions)], withDroppedFailures())
+	}
+	consumer, err := newConsumer(b.brokers, group, []string{topic}, AdaptHandler(handler), initialOffset, consumerOptions...)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.consumers = append(b.consumers, consumer)
+	b.mu.Unlock()
+
+	return consumer.Start(ctx)
+}
+
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errs []error
+	for _, consumer := range b.consumers {
+		errs = append(errs, consumer.Close())
+	}
+	errs = append(errs, b.producer.Close())
+	return errors.Join(errs...)
+}
+
+func AdaptHandler(handler eventbus.Handler) MessageHandler {
+	return func(ctx context.Context, message *sarama.ConsumerMessage) error {
+		return handler(ctx, &eventbus.Message{
+			Topic:   message.Topic,
+			Key:     string(message.Key),
+			Headers: FromRecordHeaders(message.Headers),
+			Value:   message.Value,
+		})
+	}
+}
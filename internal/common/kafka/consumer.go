@@ -1,65 +1,214 @@
 package kafka
 
 import (
+	"alle-task-manager-gunish/internal/common/eventbus"
 	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/common/tracing"
 	"context"
 	"errors"
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
 )
 
-type MessageHandler func(message *sarama.ConsumerMessage) error
+const consumerRestartDelay = time.Second
+
+type MessageHandler func(ctx context.Context, message *sarama.ConsumerMessage) error
 
 type Consumer struct {
 	consumer sarama.ConsumerGroup
 	topics   []string
-	handler  MessageHandler
+	handler  *ConsumerGroupHandler
+}
+
+type ConsumerOption func(*ConsumerGroupHandler)
+
+func WithRetryPolicy(policy eventbus.RetryPolicy) ConsumerOption {
+	return func(h *ConsumerGroupHandler) {
+		h.retryPolicy = policy
+	}
+}
+
+func WithDeadLetterQueue(dlq *DeadLetterQueue) ConsumerOption {
+	return func(h *ConsumerGroupHandler) {
+		h.deadLetterQueue = dlq
+	}
+}
+
+func NewConsumer(brokers []string, groupID string, topics []string, handler MessageHandler, options ...ConsumerOption) (*Consumer, error) {
+	return newConsumer(brokers, groupID, topics, handler, sarama.OffsetOldest, options...)
 }
 
-func NewConsumer(brokers []string, groupID string, topics []string, handler MessageHandler) (*Consumer, error) {
+func newConsumer(brokers []string, groupID string, topics []string, handler MessageHandler, initialOffset int64, options ...ConsumerOption) (*Consumer, error) {
 	config := sarama.NewConfig()
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.Initial = initialOffset
 
 	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
 	if err != nil {
 		return nil, err
 	}
 
+	groupHandler := NewConsumerGroupHandler(handler, options...)
+	groupHandler.group = groupID
 	return &Consumer{
 		consumer: consumer,
 		topics:   topics,
-		handler:  handler,
+		handler:  groupHandler,
 	}, nil
 }
 
 type ConsumerGroupHandler struct {
-	handler MessageHandler
+	// group labels the consumer metrics.
+	group           string
+	handler         MessageHandler
+	retryPolicy     eventbus.RetryPolicy
+	deadLetterQueue *DeadLetterQueue
+	// dropFailures settles messages the handler gives up on without dead-lettering them.
+	dropFailures bool
+}
+
+// withDroppedFailures : Used for transient subscriptions, whose failures the durable group also sees.
+func withDroppedFailures() ConsumerOption {
+	return func(h *ConsumerGroupHandler) {
+		h.deadLetterQueue = nil
+		h.dropFailures = true
+	}
+}
+
+func NewConsumerGroupHandler(handler MessageHandler, options ...ConsumerOption) *ConsumerGroupHandler {
+	h := &ConsumerGroupHandler{
+		handler:     handler,
+		retryPolicy: eventbus.DefaultRetryPolicy(),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
 }
 
 func (h *ConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
 func (h *ConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
 
 func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	logger := loggingtype.GetLogger()
-	for message := range claim.Messages() {
-		if err := h.handler(message); err != nil {
-			logger.Error("Error processing message", "error", err)
-			continue
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			lag := claim.HighWaterMarkOffset() - message.Offset - 1
+			metrics.KafkaConsumerLag.WithLabelValues(h.group, message.Topic, strconv.Itoa(int(message.Partition))).Set(float64(max(lag, 0)))
+			done, err := h.process(session.Context(), message)
+			if err != nil {
+				return err
+			}
+			if !done {
+				return nil
+			}
+			session.MarkMessage(message, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// process : Runs the handler with retries and parks the message on the dead-letter topic once
+// they are exhausted. It reports false when the session ended before the message was settled.
+// The attempts share a consumer span that continues the trace found in the message headers.
+func (h *ConsumerGroupHandler) process(ctx context.Context, message *sarama.ConsumerMessage) (bool, error) {
+	logger := loggingtype.ForPackage("kafka")
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, consumerCarrier(message.Headers))
+	ctx, span := tracing.Tracer().Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingConsumerGroupName(h.group),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			semconv.MessagingKafkaOffset(int(message.Offset)),
+		),
+	)
+	defer span.End()
+
+	attempt := 0
+	for {
+		err := h.handler(ctx, message)
+		if err == nil {
+			metrics.KafkaConsumed.WithLabelValues(h.group, message.Topic, metrics.ResultSuccess).Inc()
+			return true, nil
+		}
+		metrics.KafkaProcessingErrors.WithLabelValues(h.group, message.Topic).Inc()
+		span.RecordError(err)
+		attempt++
+
+		if eventbus.IsPermanent(err) || attempt > h.retryPolicy.MaxRetries {
+			logger.ErrorContext(ctx, "Giving up on message",
+				"topic", message.Topic,
+				"partition", message.Partition,
+				"offset", message.Offset,
+				"attempts", attempt,
+				"permanent", eventbus.IsPermanent(err),
+				"error", err,
+			)
+			span.SetStatus(codes.Error, err.Error())
+			if h.dropFailures {
+				metrics.KafkaConsumed.WithLabelValues(h.group, message.Topic, metrics.ResultFailure).Inc()
+				return true, nil
+			}
+			if h.deadLetterQueue == nil {
+				metrics.KafkaConsumed.WithLabelValues(h.group, message.Topic, metrics.ResultFailure).Inc()
+				return false, err
+			}
+			if dlqErr := h.deadLetterQueue.Send(ctx, message, err, attempt); dlqErr != nil {
+				metrics.KafkaConsumed.WithLabelValues(h.group, message.Topic, metrics.ResultFailure).Inc()
+				logger.ErrorContext(ctx, "Failed to forward message to dead-letter topic", "offset", message.Offset, "error", dlqErr)
+				return false, dlqErr
+			}
+			metrics.KafkaConsumed.WithLabelValues(h.group, message.Topic, metrics.ResultDeadLettered).Inc()
+			return true, nil
+		}
+
+		backoff := h.retryPolicy.Backoff(attempt - 1)
+		logger.WarnContext(ctx, "Retrying message",
+			"topic", message.Topic,
+			"partition", message.Partition,
+			"offset", message.Offset,
+			"attempt", attempt,
+			"backoff", backoff.String(),
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-time.After(backoff):
 		}
-		session.MarkMessage(message, "")
 	}
-	return nil
 }
 
+// Start : Blocks consuming the configured topics until ctx is cancelled or the group is closed.
 func (c *Consumer) Start(ctx context.Context) error {
-	handler := &ConsumerGroupHandler{handler: c.handler}
-	logger := loggingtype.GetLogger()
+	logger := loggingtype.ForPackage("kafka")
 	for {
-		err := c.consumer.Consume(ctx, c.topics, handler)
-		if err != nil {
-			if errors.Is(err, context.Canceled) {
+		if err := c.consumer.Consume(ctx, c.topics, c.handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
 				return nil
 			}
-			logger.Error("Error from consumer:", "error", err)
+			logger.ErrorContext(ctx, "Error from consumer", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(consumerRestartDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
 		}
 	}
 }
@@ -0,0 +1,227 @@
+package kafka
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"errors"
+	"github.com/IBM/sarama"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderDLQOriginalPartition = "x-dlq-original-partition"
+	HeaderDLQOriginalOffset    = "x-dlq-original-offset"
+)
+
+type DeadLetterQueue struct {
+	producer *Producer
+	topic    string
+}
+
+func NewDeadLetterQueue(producer *Producer, topic string) *DeadLetterQueue {
+	return &DeadLetterQueue{producer: producer, topic: topic}
+}
+
+func (q *DeadLetterQueue) Send(ctx context.Context, message *sarama.ConsumerMessage, cause error, attempts int) error {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+7)
+	for _, header := range message.Headers {
+		if header == nil {
+			continue
+		}
+		if key := string(header.Key); strings.HasPrefix(key, eventbus.DLQHeaderPrefix) && key != eventbus.HeaderDLQReplayCount {
+			continue
+		}
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		recordHeader(eventbus.HeaderDLQOriginalTopic, message.Topic),
+		recordHeader(HeaderDLQOriginalPartition, strconv.FormatInt(int64(message.Partition), 10)),
+		recordHeader(HeaderDLQOriginalOffset, strconv.FormatInt(message.Offset, 10)),
+		recordHeader(eventbus.HeaderDLQError, cause.Error()),
+		recordHeader(eventbus.HeaderDLQErrorType, eventbus.ErrorType(cause)),
+		recordHeader(eventbus.HeaderDLQAttempts, strconv.Itoa(attempts)),
+		recordHeader(eventbus.HeaderDLQFailedAt, time.Now().UTC().Format(time.RFC3339Nano)),
+	)
+
+	return q.producer.Send(ctx, &sarama.ProducerMessage{
+		Topic:   q.topic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	})
+}
+
+func recordHeader(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+// dlqReplayIdleTimeout : How long replay waits for the next message of a partition before treating
+// the rest of it as a gap in the offsets.
+const dlqReplayIdleTimeout = 2 * time.Second
+
+// DLQReplayer : Moves parked messages from the dead-letter topic back onto the main topic.
+// Progress is tracked with its own consumer group so a message is only replayed once.
+type DLQReplayer struct {
+	mu          sync.Mutex
+	client      sarama.Client
+	consumer    sarama.Consumer
+	offsets     sarama.OffsetManager
+	producer    *Producer
+	dlqTopic    string
+	targetTopic string
+}
+
+func NewDLQReplayer(brokers []string, groupID, dlqTopic, targetTopic string, producer *Producer) (*DLQReplayer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	offsets, err := sarama.NewOffsetManagerFromClient(groupID, client)
+	if err != nil {
+		_ = consumer.Close()
+		_ = client.Close()
+		return nil, err
+	}
+
+	return &DLQReplayer{
+		client:      client,
+		consumer:    consumer,
+		offsets:     offsets,
+		producer:    producer,
+		dlqTopic:    dlqTopic,
+		targetTopic: targetTopic,
+	}, nil
+}
+
+// Replay : Republishes up to limit dead-lettered messages that arrived before the call.
+func (r *DLQReplayer) Replay(ctx context.Context, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	partitions, err := r.client.Partitions(r.dlqTopic)
+	if err != nil {
+		if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	replayed := 0
+	for _, partition := range partitions {
+		if replayed >= limit {
+			break
+		}
+		count, err := r.replayPartition(ctx, partition, limit-replayed)
+		replayed += count
+		if err != nil {
+			r.offsets.Commit()
+			return replayed, err
+		}
+	}
+	r.offsets.Commit()
+
+	loggingtype.ForPackage("kafka").InfoContext(ctx, "Dead-letter messages replayed", "topic", r.dlqTopic, "target_topic", r.targetTopic, "count", replayed)
+	return replayed, nil
+}
+
+func (r *DLQReplayer) replayPartition(ctx context.Context, partition int32, limit int) (int, error) {
+	highWaterMark, err := r.client.GetOffset(r.dlqTopic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, err
+	}
+
+	partitionOffsets, err := r.offsets.ManagePartition(r.dlqTopic, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer partitionOffsets.AsyncClose()
+
+	next, _ := partitionOffsets.NextOffset()
+	if next < 0 {
+		if next, err = r.client.GetOffset(r.dlqTopic, partition, sarama.OffsetOldest); err != nil {
+			return 0, err
+		}
+	}
+	if next >= highWaterMark {
+		return 0, nil
+	}
+
+	partitionConsumer, err := r.consumer.ConsumePartition(r.dlqTopic, partition, next)
+	if err != nil {
+		return 0, err
+	}
+	defer partitionConsumer.AsyncClose()
+
+	// Offsets below the high water mark can be missing, removed by compaction or taken by transaction
+	// markers, so the partition is also done once no message arrives for a while.
+	idle := time.NewTimer(dlqReplayIdleTimeout)
+	defer idle.Stop()
+
+	replayed := 0
+	for replayed < limit && next < highWaterMark {
+		select {
+		case message := <-partitionConsumer.Messages():
+			if message.Offset >= highWaterMark {
+				// Arrived after the call; left for the next replay.
+				return replayed, nil
+			}
+			if err := r.producer.Send(ctx, r.replayMessage(message)); err != nil {
+				return replayed, err
+			}
+			next = message.Offset + 1
+			partitionOffsets.MarkOffset(next, "")
+			replayed++
+			idle.Reset(dlqReplayIdleTimeout)
+		case consumerErr := <-partitionConsumer.Errors():
+			return replayed, consumerErr
+		case <-idle.C:
+			return replayed, nil
+		case <-ctx.Done():
+			return replayed, ctx.Err()
+		}
+	}
+	return replayed, nil
+}
+
+func (r *DLQReplayer) replayMessage(message *sarama.ConsumerMessage) *sarama.ProducerMessage {
+	replayCount := 0
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+1)
+	for _, header := range message.Headers {
+		if header == nil {
+			continue
+		}
+		key := string(header.Key)
+		if key == eventbus.HeaderDLQReplayCount {
+			replayCount, _ = strconv.Atoi(string(header.Value))
+			continue
+		}
+		if strings.HasPrefix(key, eventbus.DLQHeaderPrefix) {
+			continue
+		}
+		headers = append(headers, *header)
+	}
+	headers = append(headers, recordHeader(eventbus.HeaderDLQReplayCount, strconv.Itoa(replayCount+1)))
+
+	return &sarama.ProducerMessage{
+		Topic:   r.targetTopic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+}
+
+func (r *DLQReplayer) Close() error {
+	return errors.Join(r.offsets.Close(), r.consumer.Close(), r.client.Close())
+}
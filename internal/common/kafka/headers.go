@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"github.com/IBM/sarama"
+	"sort"
+)
+
+func ToRecordHeaders(headers map[string]string) []sarama.RecordHeader {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]sarama.RecordHeader, 0, len(headers))
+	for _, key := range keys {
+		records = append(records, recordHeader(key, headers[key]))
+	}
+	return records
+}
+
+func FromRecordHeaders(records []*sarama.RecordHeader) map[string]string {
+	headers := make(map[string]string, len(records))
+	for _, record := range records {
+		if record != nil {
+			headers[string(record.Key)] = string(record.Value)
+		}
+	}
+	return headers
+}
+
+// producerCarrier : Writes trace context into the headers of a message about to be sent, replacing the
+// trace context it was consumed with when a message is forwarded.
+type producerCarrier struct {
+	message *sarama.ProducerMessage
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, header := range c.message.Headers {
+		if string(header.Key) == key {
+			c.message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.message.Headers = append(c.message.Headers, recordHeader(key, value))
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.message.Headers))
+	for _, header := range c.message.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+// consumerCarrier : Reads trace context from the headers of a consumed message.
+type consumerCarrier []*sarama.RecordHeader
+
+func (c consumerCarrier) Get(key string) string {
+	for _, header := range c {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, header := range c {
+		if header != nil {
+			keys = append(keys, string(header.Key))
+		}
+	}
+	return keys
+}
@@ -2,8 +2,17 @@ package kafka
 
 import (
 	loggingtype "alle-task-manager-gunish/internal/common/logging"
-	"encoding/json"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/common/tracing"
+	"context"
+	"errors"
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
 )
 
 type SyncProducer interface {
@@ -13,6 +22,8 @@ type SyncProducer interface {
 
 type Producer struct {
 	Producer SyncProducer
+	// client is the connection Producer sends through, nil when Producer was built around another SyncProducer.
+	client sarama.Client
 }
 
 func NewProducer(brokers []string) (*Producer, error) {
@@ -21,36 +32,71 @@ func NewProducer(brokers []string) (*Producer, error) {
 	config.Producer.Retry.Max = 5
 	config.Producer.Return.Successes = true
 
-	producer, err := sarama.NewSyncProducer(brokers, config)
+	client, err := sarama.NewClient(brokers, config)
 	if err != nil {
 		return nil, err
 	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
 
-	return &Producer{Producer: producer}, nil
+	return &Producer{Producer: producer, client: client}, nil
 }
 
-func (p *Producer) PublishMessage(topic string, key string, value interface{}) error {
-	jsonValue, err := json.Marshal(value)
-	if err != nil {
-		loggingtype.GetLogger().Error("failed to marshal message: ", "error", err)
+// Ping : Fetches the cluster metadata from the brokers. Sarama can't be cancelled, so a fetch that outlives
+// ctx carries on in the background.
+func (p *Producer) Ping(ctx context.Context) error {
+	if p.client == nil {
+		return errors.New("producer has no Kafka client")
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- p.client.RefreshMetadata()
+	}()
+	select {
+	case err := <-done:
 		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.StringEncoder(key),
-		Value: sarama.StringEncoder(jsonValue),
-	}
+// Send : Runs in a producer span that is a child of the span in ctx, and writes its trace context into the
+// message headers so consumers continue the trace.
+func (p *Producer) Send(ctx context.Context, msg *sarama.ProducerMessage) error {
+	ctx, span := tracing.Tracer().Start(ctx, msg.Topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(msg.Topic),
+		),
+	)
+	defer span.End()
+	otel.GetTextMapPropagator().Inject(ctx, producerCarrier{message: msg})
 
+	start := time.Now()
 	partition, offset, err := p.Producer.SendMessage(msg)
+	metrics.KafkaPublishDuration.WithLabelValues(msg.Topic).Observe(time.Since(start).Seconds())
 	if err != nil {
-		loggingtype.GetLogger().Error("failed to send message:", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.KafkaPublished.WithLabelValues(msg.Topic, metrics.ResultFailure).Inc()
+		loggingtype.ForPackage("kafka").ErrorContext(ctx, "failed to send message:", "topic", msg.Topic, "error", err)
 		return err
 	}
-	loggingtype.GetLogger().Info("Message published", "topic", topic, "partition", partition, "offset", offset)
+	span.SetAttributes(semconv.MessagingDestinationPartitionID(strconv.Itoa(int(partition))), semconv.MessagingKafkaOffset(int(offset)))
+	metrics.KafkaPublished.WithLabelValues(msg.Topic, metrics.ResultSuccess).Inc()
+	loggingtype.ForPackage("kafka").InfoContext(ctx, "Message published", "topic", msg.Topic, "partition", partition, "offset", offset)
 	return nil
 }
 
 func (p *Producer) Close() error {
-	return p.Producer.Close()
+	err := p.Producer.Close()
+	if p.client != nil && !p.client.Closed() {
+		err = errors.Join(err, p.client.Close())
+	}
+	return err
 }
@@ -0,0 +1,134 @@
+package loggingtype
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// levelRegistry : The global level and the per-package overrides, read on every log call and changed at
+// runtime through the admin API.
+type levelRegistry struct {
+	mu       sync.RWMutex
+	global   slog.Level
+	packages map[string]slog.Level
+}
+
+var levels = &levelRegistry{global: slog.LevelInfo, packages: map[string]slog.Level{}}
+
+// Packages : The names the service's packages log under with ForPackage.
+var Packages = []string{"database", "eventbus", "http", "kafka", "repository", "service"}
+
+// KnownPackage : Reports whether name is one of Packages.
+func KnownPackage(name string) bool {
+	return slices.Contains(Packages, name)
+}
+
+func (r *levelRegistry) level(pkg string) slog.Level {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if level, ok := r.packages[pkg]; ok {
+		return level
+	}
+	return r.global
+}
+
+func (r *levelRegistry) reset(global slog.Level, packages map[string]slog.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.global = global
+	r.packages = packages
+}
+
+// ParseLevel : debug, info, warn or error, in any case.
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported log level %q, expected debug, info, warn or error", value)
+	}
+}
+
+// ParsePackageLevels : Parses package=level pairs such as repository=warn.
+func ParsePackageLevels(values []string) (map[string]slog.Level, error) {
+	packages := make(map[string]slog.Level, len(values))
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid package log level %q, expected package=level", value)
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", name, err)
+		}
+		packages[name] = level
+	}
+	return packages, nil
+}
+
+// LevelName : The lower-case name ParseLevel accepts.
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// Levels : The global level and a copy of the per-package overrides.
+func Levels() (slog.Level, map[string]slog.Level) {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	packages := make(map[string]slog.Level, len(levels.packages))
+	for name, level := range levels.packages {
+		packages[name] = level
+	}
+	return levels.global, packages
+}
+
+// SetLevel : Changes the level of every package without an override of its own.
+func SetLevel(level slog.Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.global = level
+}
+
+func SetPackageLevel(pkg string, level slog.Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.packages[pkg] = level
+}
+
+// ResetPackageLevel : Makes the package follow the global level again.
+func ResetPackageLevel(pkg string) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	delete(levels.packages, pkg)
+}
+
+// levelHandler : Drops records below the current level of its package.
+type levelHandler struct {
+	slog.Handler
+	pkg string
+}
+
+func (h levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= levels.level(h.pkg)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs), pkg: h.pkg}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name), pkg: h.pkg}
+}
@@ -1,9 +1,27 @@
+// Package loggingtype holds the process-wide structured logger. Log with the *Context methods (InfoContext,
+// ErrorContext, ...) wherever a context is at hand: they add the request ID and the trace of the context to
+// the record, which is what lets a request be followed through the repositories and into the consumers.
+//
+// Packages log through ForPackage, so their level can be raised or lowered on its own with SetPackageLevel.
 package loggingtype
 
 import (
+	"alle-task-manager-gunish/internal/common/config"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"log/slog"
+	"math"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
 type Logger struct {
@@ -11,27 +29,229 @@ type Logger struct {
 }
 
 var (
-	instance *Logger
-	once     sync.Once
+	mu      sync.Mutex
+	loggers = map[string]*Logger{}
+	// sink : The handler every logger ends in; it redacts and writes the formatted records. Setup swaps it
+	// while other goroutines may be logging, so loggers reach it through sinkHandler.
+	sink atomic.Pointer[slog.Handler]
 )
 
+func init() {
+	setSink(newSink(os.Stdout, FormatJSON, defaultRedactor()))
+}
+
+type requestIDKey struct{}
+
+// NewLogger : Same as GetLogger.
 func NewLogger() *Logger {
-	once.Do(func() {
-		opts := &slog.HandlerOptions{
-			Level: slog.LevelInfo,
+	return GetLogger()
+}
+
+// GetLogger : The logger for code that doesn't belong to a package with a level of its own.
+func GetLogger() *Logger {
+	return ForPackage("")
+}
+
+// ForPackage : The logger of the named package. Its records carry the name as "logger", and they are
+// written when they reach the package's level, or the global level when the package has none.
+func ForPackage(name string) *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	if logger, ok := loggers[name]; ok {
+		return logger
+	}
+	logger := &Logger{Logger: newSlogLogger(name)}
+	loggers[name] = logger
+	return logger
+}
+
+// With : A logger that adds the request ID and trace of ctx to every record, for passing to code that
+// logs without a context. A context given to the *Context methods takes precedence.
+func (l *Logger) With(ctx context.Context) *Logger {
+	return &Logger{Logger: slog.New(l.Handler().(contextHandler).bind(ctx))}
+}
+
+// Setup : Applies cfg to the loggers handed out so far, including those derived from them, and to later ones.
+// Any text of the secrets, such as the API keys, is redacted wherever it appears. Nothing is applied when cfg
+// is invalid. It is safe to call while other goroutines are logging.
+func Setup(cfg config.LoggingConfig, secrets ...string) error {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	packageLevels, err := ParsePackageLevels(cfg.PackageLevels)
+	if err != nil {
+		return err
+	}
+	format, err := ParseFormat(cfg.Format)
+	if err != nil {
+		return err
+	}
+	sampling, err := newSampler(cfg.SampleInitial, cfg.SampleThereafter, cfg.SampleTick)
+	if err != nil {
+		return err
+	}
+	redact, err := newRedactor(cfg.RedactFields, secrets)
+	if err != nil {
+		return err
+	}
+	output, err := openOutput(cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	levels.reset(level, packageLevels)
+	activeSampler.Store(sampling)
+	setSink(newSink(output, format, redact))
+	return nil
+}
+
+func ParseFormat(value string) (string, error) {
+	switch format := strings.ToLower(value); format {
+	case FormatJSON, FormatText:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported log format %q, expected json or text", value)
+	}
+}
+
+// openOutput : stdout, stderr, or a file that is appended to. The file stays open for the life of the process.
+func openOutput(value string) (io.Writer, error) {
+	switch value {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		file, err := os.OpenFile(value, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening log output: %w", err)
 		}
-		handler := slog.NewJSONHandler(os.Stdout, opts)
-		logger := slog.New(handler)
-		instance = &Logger{
-			Logger: logger,
+		return file, nil
+	}
+}
+
+func newSink(w io.Writer, format string, redact *redactor) slog.Handler {
+	// Levels are checked by levelHandler, so they can change at runtime; the sink writes everything it gets.
+	opts := &slog.HandlerOptions{Level: slog.Level(math.MinInt), ReplaceAttr: redact.replaceAttr}
+	if format == FormatText {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+func defaultRedactor() *redactor {
+	redact, _ := newRedactor(config.DefaultLogRedactFields, nil)
+	return redact
+}
+
+func setSink(handler slog.Handler) {
+	sink.Store(&handler)
+}
+
+func newSlogLogger(name string) *slog.Logger {
+	var handler slog.Handler = samplingHandler{Handler: sinkHandler{cache: new(atomic.Pointer[derivedSink])}}
+	if name != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("logger", name)})
+	}
+	return slog.New(contextHandler{Handler: levelHandler{Handler: handler, pkg: name}})
+}
+
+// WithRequestID : Stores the ID of the request being served, or of the request that caused the event being
+// consumed, in ctx.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID : The request ID stored in ctx, "" when there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler : Adds request_id, trace_id and span_id from the context of the record, or from the bound
+// context when the record's has neither.
+type contextHandler struct {
+	slog.Handler
+	bound context.Context
+}
+
+func (h contextHandler) bind(ctx context.Context) contextHandler {
+	h.bound = ctx
+	return h
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil || (RequestID(ctx) == "" && !trace.SpanContextFromContext(ctx).IsValid()) {
+		if h.bound != nil {
+			ctx = h.bound
+		}
+	}
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			record.AddAttrs(slog.String("request_id", id))
+		}
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
 		}
-	})
+	}
+	return h.Handler.Handle(ctx, record)
+}
 
-	return instance
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs), bound: h.bound}
 }
-func GetLogger() *Logger {
-	if instance == nil {
-		return NewLogger()
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name), bound: h.bound}
+}
+
+// sinkHandler : Writes each record to the sink current at the time. The attributes and groups added with
+// WithAttrs and WithGroup are applied to the sink again after Setup replaces it.
+type sinkHandler struct {
+	derive func(slog.Handler) slog.Handler
+	cache  *atomic.Pointer[derivedSink]
+}
+
+// derivedSink : The result of a sinkHandler's derive on the sink it was computed from.
+type derivedSink struct {
+	base    *slog.Handler
+	handler slog.Handler
+}
+
+func (h sinkHandler) current() slog.Handler {
+	base := sink.Load()
+	if h.derive == nil {
+		return *base
+	}
+	if derived := h.cache.Load(); derived != nil && derived.base == base {
+		return derived.handler
+	}
+	handler := h.derive(*base)
+	h.cache.Store(&derivedSink{base: base, handler: handler})
+	return handler
+}
+
+func (h sinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.current().Enabled(ctx, level)
+}
+
+func (h sinkHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.current().Handle(ctx, record)
+}
+
+func (h sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.then(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h sinkHandler) WithGroup(name string) slog.Handler {
+	return h.then(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h sinkHandler) then(next func(slog.Handler) slog.Handler) sinkHandler {
+	derive := next
+	if previous := h.derive; previous != nil {
+		derive = func(handler slog.Handler) slog.Handler { return next(previous(handler)) }
 	}
-	return instance
+	return sinkHandler{derive: derive, cache: new(atomic.Pointer[derivedSink])}
 }
@@ -0,0 +1,173 @@
+package loggingtype
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestContextHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(contextHandler{Handler: slog.NewJSONHandler(&out, nil)}).With("component", "test")
+	read := func() map[string]interface{} {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+		out.Reset()
+		return record
+	}
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.InfoContext(ctx, "Task updated successfully", "task_id", "task-1")
+	record := read()
+	assert.Equal(t, "req-123", record["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+	assert.Equal(t, "task-1", record["task_id"])
+	assert.Equal(t, "test", record["component"], "attributes added with With are kept")
+
+	logger.Info("Database connection closed")
+	record = read()
+	assert.NotContains(t, record, "request_id")
+	assert.NotContains(t, record, "trace_id")
+
+	assert.Equal(t, "req-123", RequestID(ctx))
+	assert.Empty(t, RequestID(context.Background()))
+}
+
+// setupForTest : Applies cfg and restores the defaults when the test ends.
+func setupForTest(t *testing.T, cfg config.LoggingConfig) {
+	require.NoError(t, Setup(cfg))
+	t.Cleanup(func() {
+		require.NoError(t, Setup(config.LoggingConfig{Level: "info", Format: "json", Output: "stdout"}))
+	})
+}
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestSetup_LevelsAndOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	repository := ForPackage("test-repository")
+	setupForTest(t, config.LoggingConfig{Level: "warn", Format: "json", Output: path, PackageLevels: []string{"test-kafka=debug"}})
+	kafka := ForPackage("test-kafka")
+
+	repository.Info("hidden by the global level")
+	repository.Warn("written at the global level")
+	kafka.Debug("written at the package level")
+	SetPackageLevel("test-repository", slog.LevelDebug)
+	repository.Debug("written after the package level was lowered")
+	ResetPackageLevel("test-repository")
+	repository.Debug("hidden again")
+
+	records := readLines(t, path)
+	require.Len(t, records, 3)
+	assert.Equal(t, "written at the global level", records[0]["msg"])
+	assert.Equal(t, "test-repository", records[0]["logger"], "loggers obtained before Setup are reconfigured")
+	assert.Equal(t, "written at the package level", records[1]["msg"])
+	assert.Equal(t, "test-kafka", records[1]["logger"])
+	assert.Equal(t, "written after the package level was lowered", records[2]["msg"])
+
+	global, packages := Levels()
+	assert.Equal(t, slog.LevelWarn, global)
+	assert.Equal(t, map[string]slog.Level{"test-kafka": slog.LevelDebug}, packages)
+}
+
+func TestSetup_WhileLogging(t *testing.T) {
+	derived := ForPackage("test-service").Logger.With("component", "worker")
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					ForPackage("test-service").Debug("logging during Setup")
+				}
+			}
+		}()
+	}
+	for range 20 {
+		require.NoError(t, Setup(config.LoggingConfig{Level: "error", Format: "text", Output: "stdout"}))
+	}
+	close(stop)
+	wg.Wait()
+
+	path := filepath.Join(t.TempDir(), "service.log")
+	setupForTest(t, config.LoggingConfig{Level: "info", Format: "json", Output: path})
+	derived.Info("derived before Setup")
+
+	records := readLines(t, path)
+	require.Len(t, records, 1)
+	assert.Equal(t, "worker", records[0]["component"], "loggers derived before Setup follow the new output")
+	assert.Equal(t, "test-service", records[0]["logger"])
+}
+
+func TestSetup_TextFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	setupForTest(t, config.LoggingConfig{Level: "info", Format: "TEXT", Output: path})
+
+	GetLogger().Info("Task created successfully", "task_id", "task-1")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `level=INFO msg="Task created successfully" task_id=task-1`)
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	for _, cfg := range []config.LoggingConfig{
+		{Level: "verbose", Format: "json", Output: "stdout"},
+		{Level: "info", Format: "xml", Output: "stdout"},
+		{Level: "info", Format: "json", Output: "stdout", PackageLevels: []string{"kafka"}},
+		{Level: "info", Format: "json", Output: "stdout", PackageLevels: []string{"kafka=loud"}},
+		{Level: "info", Format: "json", Output: "stdout", SampleInitial: 1},
+		{Level: "info", Format: "json", Output: filepath.Join(t.TempDir(), "missing", "service.log")},
+	} {
+		assert.Error(t, Setup(cfg), "%+v", cfg)
+	}
+	global, _ := Levels()
+	assert.Equal(t, slog.LevelInfo, global, "nothing is applied from an invalid configuration")
+}
+
+func TestLogger_With(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	setupForTest(t, config.LoggingConfig{Level: "info", Format: "json", Output: path})
+
+	logger := GetLogger().With(WithRequestID(context.Background(), "bound-id"))
+	logger.Info("uses the bound request ID")
+	logger.InfoContext(WithRequestID(context.Background(), "call-id"), "prefers the context of the call")
+
+	records := readLines(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, "bound-id", records[0]["request_id"])
+	assert.Equal(t, "call-id", records[1]["request_id"])
+}
@@ -0,0 +1,131 @@
+package loggingtype
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Redacted : What a redacted value is replaced with.
+const Redacted = "[REDACTED]"
+
+// redactor : Replaces the value of every attribute whose key matches one of the patterns, including fields
+// of logged structs and maps, and every occurrence of a secret, with Redacted.
+type redactor struct {
+	patterns []string
+	secrets  []string
+}
+
+// ParseRedactFields : Checks path.Match patterns such as *token* and lower-cases them, since they are
+// matched against lower-case keys.
+func ParseRedactFields(values []string) ([]string, error) {
+	patterns := make([]string, 0, len(values))
+	for _, value := range values {
+		pattern := strings.ToLower(strings.TrimSpace(value))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", value, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func newRedactor(fields []string, secrets []string) (*redactor, error) {
+	patterns, err := ParseRedactFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	r := &redactor{patterns: patterns}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r, nil
+}
+
+func (r *redactor) matches(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) scrub(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// replaceAttr : The slog.HandlerOptions.ReplaceAttr hook. Group members are visited one by one, so a group
+// itself is left alone.
+func (r *redactor) replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	builtIn := len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.MessageKey || attr.Key == slog.SourceKey)
+	if !builtIn && r.matches(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(r.scrub(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(r.scrub(err.Error()))
+		} else {
+			attr.Value = r.redactValue(attr.Value.Any())
+		}
+	}
+	return attr
+}
+
+// redactValue : Structs, maps and slices are written as JSON by the handlers, so their fields are redacted
+// on their JSON form. Other values are left as they are.
+func (r *redactor) redactValue(value any) slog.Value {
+	kind := reflect.ValueOf(value).Kind()
+	if kind == reflect.Pointer {
+		kind = reflect.Indirect(reflect.ValueOf(value)).Kind()
+	}
+	switch kind {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return slog.AnyValue(value)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return slog.StringValue(Redacted)
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return slog.StringValue(Redacted)
+	}
+	return slog.AnyValue(r.redactJSON(decoded))
+}
+
+func (r *redactor) redactJSON(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if r.matches(key) {
+				value[key] = Redacted
+			} else {
+				value[key] = r.redactJSON(field)
+			}
+		}
+	case []any:
+		for i := range value {
+			value[i] = r.redactJSON(value[i])
+		}
+	case string:
+		return r.scrub(value)
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package loggingtype
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	redact, err := newRedactor([]string{"description", "*token*", " Authorization "}, []string{"key-123"})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{ReplaceAttr: redact.replaceAttr}))
+
+	type input struct {
+		Title       string            `json:"title"`
+		Description string            `json:"description"`
+		Labels      map[string]string `json:"labels"`
+	}
+	logger.Info("Request with key-123",
+		"description", "customer secret",
+		"sync_token", "abc",
+		"Authorization", "Bearer key-123",
+		"error", errors.New("rejected key-123"),
+		slog.Group("task", "description", "customer secret", "status", "pending"),
+		"input", &input{Title: "Call", Description: "customer secret", Labels: map[string]string{"refresh_token": "xyz"}},
+		"tasks", []input{{Description: "customer secret"}},
+	)
+
+	logged := out.String()
+	assert.NotContains(t, logged, "customer secret")
+	assert.NotContains(t, logged, "key-123")
+	assert.NotContains(t, logged, "xyz")
+	assert.NotContains(t, logged, `"abc"`)
+	assert.Contains(t, logged, `"msg":"Request with [REDACTED]"`)
+	assert.Contains(t, logged, `"error":"rejected [REDACTED]"`)
+	assert.Contains(t, logged, `"task":{"description":"[REDACTED]","status":"pending"}`)
+	assert.Contains(t, logged, `"title":"Call"`)
+
+	_, err = ParseRedactFields([]string{"[token"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package loggingtype
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// activeSampler : nil while sampling is off.
+var activeSampler atomic.Pointer[sampler]
+
+// sampler : Within each tick, writes the first `initial` records with the same level and message, then
+// every `thereafter`-th one. With thereafter 0 the rest of the tick is dropped.
+type sampler struct {
+	initial    int
+	thereafter int
+	tick       time.Duration
+	now        func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+	dropped     atomic.Uint64
+}
+
+// newSampler : Returns nil, turning sampling off, when initial is 0.
+func newSampler(initial, thereafter int, tick time.Duration) (*sampler, error) {
+	if initial == 0 {
+		return nil, nil
+	}
+	if initial < 0 || thereafter < 0 {
+		return nil, errors.New("log sampling counts must not be negative")
+	}
+	if tick <= 0 {
+		return nil, errors.New("log sampling tick must be positive")
+	}
+	return &sampler{initial: initial, thereafter: thereafter, tick: tick, now: time.Now, counts: map[string]int{}}, nil
+}
+
+func (s *sampler) allow(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := s.now(); now.Sub(s.windowStart) >= s.tick {
+		s.windowStart = now
+		clear(s.counts)
+	}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.initial || (s.thereafter > 0 && (n-s.initial)%s.thereafter == 0) {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// SampledOut : How many records sampling has dropped since Setup.
+func SampledOut() uint64 {
+	if s := activeSampler.Load(); s != nil {
+		return s.dropped.Load()
+	}
+	return 0
+}
+
+// samplingHandler : Samples debug and info records. Warnings and errors are always written.
+type samplingHandler struct {
+	slog.Handler
+}
+
+func (h samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if s := activeSampler.Load(); s != nil && record.Level < slog.LevelWarn && !s.allow(record.Level.String()+" "+record.Message) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h samplingHandler) WithGroup(name string) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithGroup(name)}
+}
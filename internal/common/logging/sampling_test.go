@@ -0,0 +1,49 @@
+package loggingtype
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := newSampler(2, 3, time.Second)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+
+	var allowed []int
+	for i := 1; i <= 8; i++ {
+		if s.allow("INFO Task retrieved successfully") {
+			allowed = append(allowed, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, allowed, "the first two, then every third")
+	assert.True(t, s.allow("INFO Task created successfully"), "messages are counted separately")
+	assert.Equal(t, uint64(4), s.dropped.Load())
+
+	now = now.Add(time.Second)
+	assert.True(t, s.allow("INFO Task retrieved successfully"), "counts start over every tick")
+
+	off, err := newSampler(0, 0, 0)
+	require.NoError(t, err)
+	assert.Nil(t, off)
+}
+
+func TestSampling_KeepsWarnings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	setupForTest(t, config.LoggingConfig{Level: "info", Format: "json", Output: path, SampleInitial: 1, SampleTick: time.Hour})
+
+	logger := ForPackage("test-sampling")
+	for i := 0; i < 3; i++ {
+		logger.Info("Task retrieved successfully")
+		logger.Warn("Task not found")
+	}
+
+	records := readLines(t, path)
+	assert.Len(t, records, 4, "one info record and every warning")
+	assert.Equal(t, uint64(2), SampledOut())
+}
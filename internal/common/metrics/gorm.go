@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin : Observes the duration of every create, query, update, delete, row and raw operation. A missing
+// record counts as a success.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", startGorm),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", observeGorm("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", startGorm),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", observeGorm("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", startGorm),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", observeGorm("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", startGorm),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", observeGorm("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", startGorm),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", observeGorm("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", startGorm),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", observeGorm("raw")),
+	)
+}
+
+func startGorm(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func observeGorm(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		result := ResultSuccess
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			result = ResultFailure
+		}
+		DBQueryDuration.WithLabelValues(operation, db.Statement.Table, result).Observe(time.Since(value.(time.Time)).Seconds())
+	}
+}
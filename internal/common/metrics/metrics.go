@@ -0,0 +1,83 @@
+// Package metrics holds the Prometheus collectors of the service. Process-wide instrumentation (HTTP, database,
+// Kafka) is registered on the default registry; collectors that read from a particular database are created
+// per container.
+package metrics
+
+import (
+	"alle-task-manager-gunish/internal/common/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "task_manager"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of GORM operations by operation, table and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table", "result"})
+
+	KafkaPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_published_messages_total",
+		Help:      "Messages sent by the Kafka producer by topic and result (success or failure).",
+	}, []string{"topic", "result"})
+
+	KafkaPublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_publish_duration_seconds",
+		Help:      "Time the Kafka producer waited for the brokers to acknowledge a message, by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages behind the partition's high water mark when the consumer last received one.",
+	}, []string{"group", "topic", "partition"})
+
+	KafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumed_messages_total",
+		Help:      "Messages settled by the consumer by group, topic and result (success, failure or dead_lettered).",
+	}, []string{"group", "topic", "result"})
+
+	KafkaProcessingErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_processing_errors_total",
+		Help:      "Failed attempts to handle a consumed message, retried ones included, by group and topic.",
+	}, []string{"group", "topic"})
+
+	LogsSampledOut = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logs_sampled_out_total",
+		Help:      "Debug and info log records dropped by LOG_SAMPLE_INITIAL and LOG_SAMPLE_THEREAFTER.",
+	}, func() float64 { return float64(loggingtype.SampledOut()) })
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "HTTP requests rejected with 429 by the rate limiter, by method and route template.",
+	}, []string{"method", "route"})
+)
+
+const (
+	ResultSuccess      = "success"
+	ResultFailure      = "failure"
+	ResultDeadLettered = "dead_lettered"
+)
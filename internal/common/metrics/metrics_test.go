@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// querySamples : The number of observations of db_query_duration_seconds with the given labels.
+func querySamples(t *testing.T, operation, table, result string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != namespace+"_db_query_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == operation && labels["table"] == table && labels["result"] == result {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestGormPlugin(t *testing.T) {
+	type widget struct {
+		ID   int
+		Name string
+	}
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+	require.NoError(t, db.AutoMigrate(&widget{}))
+
+	createsBefore := querySamples(t, "create", "widgets", ResultSuccess)
+	queriesBefore := querySamples(t, "query", "widgets", ResultSuccess)
+
+	require.NoError(t, db.Create(&widget{Name: "gear"}).Error)
+	var found widget
+	require.NoError(t, db.First(&found).Error)
+	assert.ErrorIs(t, db.First(&found, "name = ?", "missing").Error, gorm.ErrRecordNotFound)
+
+	assert.Equal(t, createsBefore+1, querySamples(t, "create", "widgets", ResultSuccess))
+	assert.Equal(t, queriesBefore+2, querySamples(t, "query", "widgets", ResultSuccess), "a missing record is not a failure")
+
+	failuresBefore := querySamples(t, "query", "gadgets", ResultFailure)
+	assert.Error(t, db.Table("gadgets").First(&found).Error)
+	assert.Equal(t, failuresBefore+1, querySamples(t, "query", "gadgets", ResultFailure))
+}
+
+func TestTaskCollector(t *testing.T) {
+	statuses := []string{"pending", "in_progress", "completed"}
+	collector := NewTaskCollector(func(context.Context) (map[string]int, error) {
+		return map[string]int{"pending": 3, "completed": 1}, nil
+	}, statuses)
+
+	expected := `
+# HELP task_manager_tasks Live tasks by status.
+# TYPE task_manager_tasks gauge
+task_manager_tasks{status="completed"} 1
+task_manager_tasks{status="in_progress"} 0
+task_manager_tasks{status="pending"} 3
+# HELP task_manager_tasks_count_up Whether the last count of tasks by status succeeded.
+# TYPE task_manager_tasks_count_up gauge
+task_manager_tasks_count_up 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	failing := NewTaskCollector(func(context.Context) (map[string]int, error) {
+		return nil, errors.New("database is locked")
+	}, statuses)
+	assert.NoError(t, testutil.CollectAndCompare(failing, strings.NewReader(`
+# HELP task_manager_tasks_count_up Whether the last count of tasks by status succeeded.
+# TYPE task_manager_tasks_count_up gauge
+task_manager_tasks_count_up 0
+`)))
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const taskCountTimeout = 5 * time.Second
+
+// TaskCounter : Counts the live tasks in each status.
+type TaskCounter func(ctx context.Context) (map[string]int, error)
+
+// TaskCollector : Reports the tasks by status, counted on every scrape. Statuses without tasks are reported
+// as 0 so their series don't disappear.
+type TaskCollector struct {
+	count    TaskCounter
+	statuses []string
+	tasks    *prometheus.Desc
+	up       *prometheus.Desc
+}
+
+func NewTaskCollector(count TaskCounter, statuses []string) *TaskCollector {
+	return &TaskCollector{
+		count:    count,
+		statuses: statuses,
+		tasks:    prometheus.NewDesc(namespace+"_tasks", "Live tasks by status.", []string{"status"}, nil),
+		up:       prometheus.NewDesc(namespace+"_tasks_count_up", "Whether the last count of tasks by status succeeded.", nil, nil),
+	}
+}
+
+func (c *TaskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.tasks
+	ch <- c.up
+}
+
+func (c *TaskCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskCountTimeout)
+	defer cancel()
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	for _, status := range c.statuses {
+		ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// testStoreBucket : The token bucket behaviour every Store shares.
+func testStoreBucket(t *testing.T, store Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take(ctx, "client", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+		assert.Zero(t, res.RetryAfter)
+	}
+
+	res, err := store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	res, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "buckets are per key")
+
+	advance(500 * time.Millisecond)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	advance(500 * time.Millisecond)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "a token refills every second")
+
+	advance(time.Hour)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining, "buckets don't fill past the limit")
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	testStoreBucket(t, store, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestMemoryStore_SweepsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 10, Period: time.Second}
+
+	_, err := store.Take(context.Background(), "a", limit)
+	require.NoError(t, err)
+	now = now.Add(sweepInterval)
+	_, err = store.Take(context.Background(), "b", limit)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
@@ -0,0 +1,142 @@
+// Package ratelimit holds token buckets for the HTTP API. A bucket holds up to Limit.Requests tokens and
+// refills at Limit.Requests per Limit.Period; each request takes one. Buckets live in a Store, in process
+// or in Redis when several instances have to share them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+func ParseStore(value string) (string, error) {
+	switch store := strings.ToLower(value); store {
+	case StoreMemory, StoreRedis:
+		return store, nil
+	default:
+		return "", fmt.Errorf("unsupported rate limit store %q, expected memory or redis", value)
+	}
+}
+
+// Limit : Requests per Period, which is also the largest burst a client can send.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit : Parses limits such as 30/m, 5/s or 1000/1h.
+func ParseLimit(value string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period such as 30/m", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, the number of requests must be a positive integer", value)
+	}
+	var d time.Duration
+	switch period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		if d, err = time.ParseDuration(period); err != nil || d <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q, the period must be s, m, h or a duration such as 10s", value)
+		}
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// refillRate : Tokens added per second.
+func (l Limit) refillRate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Rules : The limit of each route, keyed by method and route template such as "POST /tasks". Routes without
+// a limit of their own share one bucket per client with Default; they are not limited when it is nil.
+type Rules struct {
+	Default *Limit
+	Routes  map[string]Limit
+}
+
+// ParseRules : defaultLimit may be empty. Each route is "METHOD /path=limit", such as "POST /tasks=30/m".
+func ParseRules(defaultLimit string, routes []string) (Rules, error) {
+	rules := Rules{Routes: make(map[string]Limit, len(routes))}
+	if strings.TrimSpace(defaultLimit) != "" {
+		limit, err := ParseLimit(defaultLimit)
+		if err != nil {
+			return Rules{}, err
+		}
+		rules.Default = &limit
+	}
+	for _, route := range routes {
+		if route == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(route, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(name), " ")
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return Rules{}, fmt.Errorf("invalid route rate limit %q, expected METHOD /path=limit", route)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return Rules{}, err
+		}
+		rules.Routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = limit
+	}
+	return rules, nil
+}
+
+// For : The limit of route and the name of the bucket it draws from, false when the route is not limited.
+func (r Rules) For(route string) (Limit, string, bool) {
+	if limit, ok := r.Routes[route]; ok {
+		return limit, route, true
+	}
+	if r.Default != nil {
+		return *r.Default, "default", true
+	}
+	return Limit{}, "", false
+}
+
+// Result : The state of a bucket after a request tried to take a token from it.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, zero when the request was allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+type Store interface {
+	// Take : Takes a token for the request from the bucket under key, creating it full if it doesn't exist.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result : Builds the Result from the tokens left after the request took its token, if it could.
+func result(limit Limit, allowed bool, tokens float64) Result {
+	rate := limit.refillRate()
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Limit
+		err   bool
+	}{
+		{value: "30/m", want: Limit{Requests: 30, Period: time.Minute}},
+		{value: "5/s", want: Limit{Requests: 5, Period: time.Second}},
+		{value: " 1000/h ", want: Limit{Requests: 1000, Period: time.Hour}},
+		{value: "10/15s", want: Limit{Requests: 10, Period: 15 * time.Second}},
+		{value: "30", err: true},
+		{value: "0/m", err: true},
+		{value: "x/m", err: true},
+		{value: "30/day", err: true},
+		{value: "30/-1s", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("100/m", []string{"post /tasks=30/m", "GET /tasks/:id = 5/s", ""})
+	require.NoError(t, err)
+
+	limit, bucket, ok := rules.For("POST /tasks")
+	assert.True(t, ok)
+	assert.Equal(t, "POST /tasks", bucket)
+	assert.Equal(t, Limit{Requests: 30, Period: time.Minute}, limit)
+
+	limit, bucket, ok = rules.For("GET /tasks/:id")
+	assert.True(t, ok)
+	assert.Equal(t, "GET /tasks/:id", bucket)
+	assert.Equal(t, Limit{Requests: 5, Period: time.Second}, limit)
+
+	limit, bucket, ok = rules.For("DELETE /tasks/:id")
+	assert.True(t, ok)
+	assert.Equal(t, "default", bucket)
+	assert.Equal(t, Limit{Requests: 100, Period: time.Minute}, limit)
+
+	rules, err = ParseRules("", []string{"POST /tasks=30/m"})
+	require.NoError(t, err)
+	_, _, ok = rules.For("GET /tasks")
+	assert.False(t, ok)
+
+	for _, routes := range [][]string{{"/tasks=30/m"}, {"POST tasks=30/m"}, {"POST /tasks"}, {"POST /tasks=fast"}} {
+		_, err := ParseRules("", routes)
+		assert.Error(t, err, routes)
+	}
+	_, err = ParseRules("lots", nil)
+	assert.Error(t, err)
+}
+
+func TestParseStore(t *testing.T) {
+	store, err := ParseStore("Redis")
+	require.NoError(t, err)
+	assert.Equal(t, StoreRedis, store)
+	_, err = ParseStore("memcached")
+	assert.Error(t, err)
+}
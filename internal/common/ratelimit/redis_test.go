@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := NewRedisStore(client, "test:")
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	testStoreBucket(t, store, func(d time.Duration) { now = now.Add(d) })
+	assert.True(t, server.Exists("test:client"))
+	assert.Positive(t, server.TTL("test:client"))
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	server.Close()
+
+	_, err := NewRedisStore(client, "test:").Take(t.Context(), "client", Limit{Requests: 1, Period: time.Second})
+	assert.Error(t, err)
+}
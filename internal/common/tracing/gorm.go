@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin : Creates a span for every create, query, update, delete, row and raw operation, as a child of
+// the span in the statement's context. Repositories bind their context with database.Conn, so statements
+// show up under the request or message that caused them. A missing record is not an error.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", startGormSpan("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", endGormSpan),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", startGormSpan("query")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", endGormSpan),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", startGormSpan("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", endGormSpan),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", startGormSpan("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endGormSpan),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", startGormSpan("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", endGormSpan),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", startGormSpan("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", endGormSpan),
+	)
+}
+
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := Tracer().Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endGormSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	// The statement text has placeholders instead of values, so it does not leak task contents.
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()), attribute.Int64("db.rows_affected", db.Statement.RowsAffected))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,117 @@
+// Package tracing sets up OpenTelemetry for the process. HTTP requests are traced by otelgin; this package
+// adds spans for GORM statements and the carriers that move trace context through Kafka headers.
+package tracing
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+	// ExporterMemory keeps spans in memory, where tests read them with Provider.Spans.
+	ExporterMemory = "memory"
+)
+
+// instrumentationName : The name of the tracer that creates this service's own spans.
+const instrumentationName = "alle-task-manager-gunish"
+
+func ParseExporter(value string) (string, error) {
+	switch exporter := strings.ToLower(value); exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP, ExporterMemory:
+		return exporter, nil
+	default:
+		return "", fmt.Errorf("unsupported tracing exporter %q, expected none, stdout, otlp or memory", value)
+	}
+}
+
+// Tracer : The tracer of the global provider. It picks up the provider installed by Setup even when it
+// was obtained before.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Provider : The tracer provider installed by Setup, nil for the none exporter.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	memory   *tracetest.InMemoryExporter
+}
+
+// Setup : Installs the W3C trace context and baggage propagators and a global tracer provider exporting to
+// cfg.Exporter. With the none exporter no spans are recorded, but trace context received over HTTP is still
+// passed on to Kafka.
+func Setup(ctx context.Context, cfg config.TracingConfig) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporterName, err := ParseExporter(cfg.Exporter)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{}
+	var exporter sdktrace.SpanExporter
+	switch exporterName {
+	case ExporterNone:
+		return p, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case ExporterMemory:
+		p.memory = tracetest.NewInMemoryExporter()
+		exporter = p.memory
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", exporterName, err)
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	if p.memory != nil {
+		// Spans are exported as they end, so a test can inspect them right after the traced call returns.
+		options = append(options, sdktrace.WithSyncer(exporter))
+	} else {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	p.provider = sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(p.provider)
+	return p, nil
+}
+
+// Spans : The spans ended so far with the memory exporter, nil with the others.
+func (p *Provider) Spans() tracetest.SpanStubs {
+	if p.memory == nil {
+		return nil
+	}
+	return p.memory.GetSpans()
+}
+
+// Shutdown : Exports the spans still buffered and stops the provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	return p.provider.Shutdown(ctx)
+}
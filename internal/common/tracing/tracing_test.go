@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseExporter(t *testing.T) {
+	exporter, err := ParseExporter("OTLP")
+	require.NoError(t, err)
+	assert.Equal(t, ExporterOTLP, exporter)
+
+	_, err = ParseExporter("zipkin")
+	assert.EqualError(t, err, `unsupported tracing exporter "zipkin", expected none, stdout, otlp or memory`)
+}
+
+func TestSetup_None(t *testing.T) {
+	provider, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.Nil(t, provider.Spans())
+	assert.NoError(t, provider.Shutdown(context.Background()))
+}
+
+func TestGormPlugin(t *testing.T) {
+	type widget struct {
+		ID   int
+		Name string
+	}
+	ctx := context.Background()
+	provider, err := Setup(ctx, config.TracingConfig{Exporter: ExporterMemory, ServiceName: "test", SampleRatio: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Shutdown(ctx) })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tracing.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+	require.NoError(t, db.AutoMigrate(&widget{}))
+
+	parentCtx, parent := Tracer().Start(ctx, "parent")
+	require.NoError(t, db.WithContext(parentCtx).Create(&widget{Name: "gear"}).Error)
+	var found widget
+	assert.ErrorIs(t, db.WithContext(parentCtx).First(&found, "name = ?", "missing").Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.WithContext(parentCtx).Table("gadgets").First(&found).Error)
+	parent.End()
+
+	var names []string
+	byName := map[string]codes.Code{}
+	for _, span := range provider.Spans() {
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			continue
+		}
+		names = append(names, span.Name)
+		byName[span.Name] = span.Status.Code
+	}
+	assert.Equal(t, []string{"create widgets", "query widgets", "query gadgets"}, names)
+	assert.Equal(t, codes.Unset, byName["query widgets"], "a missing record is not an error")
+	assert.Equal(t, codes.Error, byName["query gadgets"])
+}
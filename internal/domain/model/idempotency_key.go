@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// IdempotencyKey : A request made with an Idempotency-Key header and, once it has completed, its response.
+// Key is scoped to the client that sent it. StatusCode is 0 while the request is still being handled.
+type IdempotencyKey struct {
+	Key         string    `gorm:"primaryKey;column:idempotency_key"`
+	Fingerprint string    `gorm:"not null"`
+	StatusCode  int       `gorm:"not null;default:0"`
+	Headers     []byte    `gorm:"type:blob"`
+	Body        []byte    `gorm:"type:blob"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed : Reports whether the response has been stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
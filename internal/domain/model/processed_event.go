@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+type ProcessedEvent struct {
+	EventID     string    `gorm:"primaryKey"`
+	EventType   string    `gorm:"not null"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+func (ProcessedEvent) TableName() string {
+	return "processed_events"
+}
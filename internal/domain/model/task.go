@@ -2,6 +2,7 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -21,6 +22,9 @@ type Task struct {
 	DueDate     *time.Time `json:"due_date,omitempty"`
 	CreatedAt   time.Time  `json:"created_at" gorm:"not null"`
 	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null"`
+	// ChangeSeq is the value of the task change sequence at the task's last write, deletes included.
+	ChangeSeq int64          `json:"-" gorm:"not null;default:0;index"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func NewTask(title, description string) *Task {
@@ -38,3 +42,13 @@ func NewTask(title, description string) *Task {
 func (Task) TableName() string {
 	return "tasks"
 }
+
+// ChangeSequence : A named counter incremented in the same transaction as the writes it orders.
+type ChangeSequence struct {
+	Name  string `gorm:"primaryKey"`
+	Value int64  `gorm:"not null"`
+}
+
+func (ChangeSequence) TableName() string {
+	return "change_sequences"
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	TaskCreatedEventType = "TaskCreated"
+	TaskUpdatedEventType = "TaskUpdated"
+	TaskDeletedEventType = "TaskDeleted"
+)
+
+// TaskEventRecord : A domain event in the task event store. Version orders the events of a single task.
+type TaskEventRecord struct {
+	Sequence    int64     `gorm:"primaryKey;autoIncrement"`
+	AggregateID string    `gorm:"not null;uniqueIndex:idx_task_event_store_aggregate_version"`
+	Version     int       `gorm:"not null;uniqueIndex:idx_task_event_store_aggregate_version"`
+	EventType   string    `gorm:"not null"`
+	Data        []byte    `gorm:"not null"`
+	OccurredAt  time.Time `gorm:"not null;index"`
+}
+
+func (TaskEventRecord) TableName() string {
+	return "task_event_store"
+}
+
+// TaskUpdatedData : Payload of a TaskUpdated event; only the fields that changed are set.
+type TaskUpdatedData struct {
+	Title       *string     `json:"title,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Status      *TaskStatus `json:"status,omitempty"`
+	DueDate     *time.Time  `json:"due_date,omitempty"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TaskDeletedData : Payload of a TaskDeleted event.
+type TaskDeletedData struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// NewTaskCreatedRecord : Builds the first event of a task from its full state.
+func NewTaskCreatedRecord(task *Task) (*TaskEventRecord, error) {
+	return newTaskEventRecord(task.ID, 1, TaskCreatedEventType, task, task.CreatedAt)
+}
+
+// NewTaskUpdatedRecord : Builds an update event carrying the new values of the changed fields.
+func NewTaskUpdatedRecord(task *Task, version int, fields []string) (*TaskEventRecord, error) {
+	data := TaskUpdatedData{UpdatedAt: task.UpdatedAt}
+	for _, field := range fields {
+		switch field {
+		case "title":
+			data.Title = &task.Title
+		case "description":
+			data.Description = &task.Description
+		case "status":
+			data.Status = &task.Status
+		case "due_date":
+			data.DueDate = task.DueDate
+		}
+	}
+	return newTaskEventRecord(task.ID, version, TaskUpdatedEventType, data, task.UpdatedAt)
+}
+
+// NewTaskDeletedRecord : Builds the event that removes a task from the projection.
+func NewTaskDeletedRecord(id string, version int, deletedAt time.Time) (*TaskEventRecord, error) {
+	return newTaskEventRecord(id, version, TaskDeletedEventType, TaskDeletedData{DeletedAt: deletedAt}, deletedAt)
+}
+
+func newTaskEventRecord(id string, version int, eventType string, data interface{}, occurredAt time.Time) (*TaskEventRecord, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskEventRecord{
+		AggregateID: id,
+		Version:     version,
+		EventType:   eventType,
+		Data:        payload,
+		OccurredAt:  occurredAt.UTC(),
+	}, nil
+}
+
+// ApplyTaskEvent : Folds one event into the task state. A nil result means the task does not exist.
+func ApplyTaskEvent(task *Task, record *TaskEventRecord) (*Task, error) {
+	switch record.EventType {
+	case TaskCreatedEventType:
+		var created Task
+		if err := json.Unmarshal(record.Data, &created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	case TaskUpdatedEventType:
+		if task == nil {
+			return nil, fmt.Errorf("task %s updated before it was created (version %d)", record.AggregateID, record.Version)
+		}
+		var data TaskUpdatedData
+		if err := json.Unmarshal(record.Data, &data); err != nil {
+			return nil, err
+		}
+		updated := *task
+		if data.Title != nil {
+			updated.Title = *data.Title
+		}
+		if data.Description != nil {
+			updated.Description = *data.Description
+		}
+		if data.Status != nil {
+			updated.Status = *data.Status
+		}
+		if data.DueDate != nil {
+			updated.DueDate = data.DueDate
+		}
+		updated.UpdatedAt = data.UpdatedAt
+		return &updated, nil
+	case TaskDeletedEventType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown task event type %q", record.EventType)
+	}
+}
+
+// FoldTaskEvents : Replays a task's events in version order and returns the resulting state.
+func FoldTaskEvents(records []*TaskEventRecord) (*Task, error) {
+	var task *Task
+	for _, record := range records {
+		next, err := ApplyTaskEvent(task, record)
+		if err != nil {
+			return nil, err
+		}
+		task = next
+	}
+	return task, nil
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID                  string     `json:"id" gorm:"primaryKey"`
+	URL                 string     `json:"url" gorm:"not null"`
+	Secret              string     `json:"-" gorm:"not null"`
+	EventTypes          []string   `json:"event_types" gorm:"serializer:json"`
+	Active              bool       `json:"active" gorm:"not null;index"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"not null"`
+}
+
+func NewWebhookSubscription(url, secret string, eventTypes []string) *WebhookSubscription {
+	now := time.Now()
+	return &WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Matches : Reports whether the subscription wants events of the given type. An empty filter matches every type.
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, wanted := range s.EventTypes {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery : One delivery attempt of an event to a subscription.
+type WebhookDelivery struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	SubscriptionID string    `json:"subscription_id" gorm:"not null;index"`
+	EventID        string    `json:"event_id" gorm:"not null"`
+	EventType      string    `json:"event_type" gorm:"not null"`
+	Attempt        int       `json:"attempt" gorm:"not null"`
+	StatusCode     int       `json:"status_code"`
+	Success        bool      `json:"success" gorm:"not null"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookOutboxEntry : A delivery of an event to a subscription that has neither succeeded nor been given up
+// on. Entries are written in the transaction that consumes the event, so pending deliveries survive restarts.
+// A worker claims an entry by moving NextAttemptAt past the time its attempt can take.
+type WebhookOutboxEntry struct {
+	ID             string    `gorm:"primaryKey"`
+	SubscriptionID string    `gorm:"not null;uniqueIndex:idx_webhook_outbox_event"`
+	EventID        string    `gorm:"not null;uniqueIndex:idx_webhook_outbox_event"`
+	EventType      string    `gorm:"not null"`
+	Payload        []byte    `gorm:"type:blob;not null"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+func (WebhookOutboxEntry) TableName() string {
+	return "webhook_outbox"
+}
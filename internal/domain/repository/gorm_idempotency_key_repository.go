@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/common/database"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormIdempotencyKeyRepository struct {
+	db     *gorm.DB
+	logger *loggingtype.Logger
+}
+
+func NewGormIdempotencyKeyRepository(db *gorm.DB) (*GormIdempotencyKeyRepository, error) {
+	return &GormIdempotencyKeyRepository{db: db, logger: loggingtype.ForPackage("repository")}, nil
+}
+
+func (r *GormIdempotencyKeyRepository) Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+	result := database.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to reserve idempotency key", "error", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GormIdempotencyKeyRepository) Get(ctx context.Context, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	if err := database.Conn(ctx, r.db).First(&record, "idempotency_key = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrNotFound
+		}
+		r.logger.ErrorContext(ctx, "Failed to get idempotency key", "error", err)
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *GormIdempotencyKeyRepository) Complete(ctx context.Context, record *model.IdempotencyKey) error {
+	result := database.Conn(ctx, r.db).Model(&model.IdempotencyKey{}).
+		Where("idempotency_key = ?", record.Key).
+		Select("status_code", "headers", "body", "expires_at").
+		Updates(record)
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to store idempotent response", "error", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormIdempotencyKeyRepository) Delete(ctx context.Context, key string) error {
+	if err := database.Conn(ctx, r.db).Delete(&model.IdempotencyKey{}, "idempotency_key = ?", key).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete idempotency key", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormIdempotencyKeyRepository) DeleteExpired(ctx context.Context, key string, before time.Time) (int64, error) {
+	query := database.Conn(ctx, r.db).Where("expires_at < ?", before)
+	if key != "" {
+		query = query.Where("idempotency_key = ?", key)
+	}
+	result := query.Delete(&model.IdempotencyKey{})
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete expired idempotency keys", "error", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
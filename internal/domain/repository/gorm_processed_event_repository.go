@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/common/database"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormProcessedEventRepository struct {
+	db     *gorm.DB
+	logger *loggingtype.Logger
+}
+
+func NewGormProcessedEventRepository(db *gorm.DB) (*GormProcessedEventRepository, error) {
+	return &GormProcessedEventRepository{db: db, logger: loggingtype.ForPackage("repository")}, nil
+}
+
+func (r *GormProcessedEventRepository) MarkProcessed(ctx context.Context, eventID, eventType string) (bool, error) {
+	record := &model.ProcessedEvent{
+		EventID:     eventID,
+		EventType:   eventType,
+		ProcessedAt: time.Now(),
+	}
+	result := database.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to record processed event", "event_id", eventID, "error", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GormProcessedEventRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := database.Conn(ctx, r.db).Where("processed_at < ?", before).Delete(&model.ProcessedEvent{})
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete processed events", "before", before, "error", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -1,86 +1,131 @@
 package repository
 
 import (
-	"alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/database"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
 	loggingtype "alle-task-manager-gunish/internal/common/logging"
 	"alle-task-manager-gunish/internal/common/pagination"
 	"alle-task-manager-gunish/internal/domain/model"
 	"context"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
+const (
+	taskChangeSequence = "tasks"
+	// purgedChangeSequence holds the highest change sequence value of a purged tombstone.
+	purgedChangeSequence = "tasks_purged"
+)
+
 type GormTaskRepository struct {
 	db     *gorm.DB
 	logger *loggingtype.Logger
 }
 
 func NewGormTaskRepository(db *gorm.DB) (*GormTaskRepository, error) {
-	return &GormTaskRepository{db: db, logger: loggingtype.GetLogger()}, nil
+	return &GormTaskRepository{db: db, logger: loggingtype.ForPackage("repository")}, nil
 }
 
-func (r *GormTaskRepository) Create(_ context.Context, task *model.Task) error {
-	task.CreatedAt = time.Now()
-	task.UpdatedAt = task.CreatedAt
+// Create : Keeps CreatedAt and UpdatedAt when they are already set, as they are for imported tasks.
+func (r *GormTaskRepository) Create(ctx context.Context, task *model.Task) error {
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = task.CreatedAt
+	}
 
-	result := r.db.Create(task)
-	if result.Error != nil {
-		r.logger.Error("Failed to create task", "error", result.Error)
-		return result.Error
+	err := r.withChangeSeq(ctx, func(tx *gorm.DB, seq int64) error {
+		task.ChangeSeq = seq
+		return tx.Create(task).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperrors.ErrDuplicateEntity
+		}
+		r.logger.ErrorContext(ctx, "Failed to create task", "error", err)
+		return err
 	}
-	r.logger.Info("Task created successfully", "task_id", task.ID)
+	r.logger.InfoContext(ctx, "Task created successfully", "task_id", task.ID)
 	return nil
 }
 
-func (r *GormTaskRepository) GetByID(_ context.Context, id string) (*model.Task, error) {
+func (r *GormTaskRepository) GetByID(ctx context.Context, id string) (*model.Task, error) {
 	var task model.Task
-	result := r.db.First(&task, "id = ?", id)
+	result := database.Conn(ctx, r.db).First(&task, "id = ?", id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			r.logger.Warn("Task not found", "task_id", id)
-			return nil, errors.ErrNotFound
+			r.logger.WarnContext(ctx, "Task not found", "task_id", id)
+			return nil, apperrors.ErrNotFound
 		}
-		r.logger.Error("Failed to get task", "task_id", id, "error", result.Error)
+		r.logger.ErrorContext(ctx, "Failed to get task", "task_id", id, "error", result.Error)
 		return nil, result.Error
 	}
-	r.logger.Info("Task retrieved successfully", "task_id", id)
+	r.logger.DebugContext(ctx, "Task retrieved successfully", "task_id", id)
 	return &task, nil
 }
 
-func (r *GormTaskRepository) Update(_ context.Context, task *model.Task) error {
+func (r *GormTaskRepository) Update(ctx context.Context, task *model.Task) error {
 	task.UpdatedAt = time.Now()
-	result := r.db.Model(&model.Task{}).Where("id = ?", task.ID).Updates(task)
-	if result.Error != nil {
-		r.logger.Error("Failed to update task", "task_id", task.ID, "error", result.Error)
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		r.logger.Warn("No task updated, task not found", "task_id", task.ID)
-		return errors.ErrNotFound
+	err := r.withChangeSeq(ctx, func(tx *gorm.DB, seq int64) error {
+		task.ChangeSeq = seq
+		result := tx.Model(&model.Task{}).Where("id = ?", task.ID).Updates(task)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			r.logger.WarnContext(ctx, "No task updated, task not found", "task_id", task.ID)
+			return err
+		}
+		r.logger.ErrorContext(ctx, "Failed to update task", "task_id", task.ID, "error", err)
+		return err
 	}
-	r.logger.Info("Task updated successfully", "task_id", task.ID)
+	r.logger.InfoContext(ctx, "Task updated successfully", "task_id", task.ID)
 	return nil
 }
 
-func (r *GormTaskRepository) Delete(_ context.Context, id string) error {
-	result := r.db.Delete(&model.Task{}, "id = ?", id)
-	if result.Error != nil {
-		r.logger.Error("Failed to delete task", "task_id", id, "error", result.Error)
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		r.logger.Warn("No task deleted, task not found", "task_id", id)
-		return errors.ErrNotFound
+// Delete : Soft-deletes the task, leaving a tombstone for delta sync.
+func (r *GormTaskRepository) Delete(ctx context.Context, id string) error {
+	err := r.withChangeSeq(ctx, func(tx *gorm.DB, seq int64) error {
+		now := time.Now()
+		result := tx.Model(&model.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"deleted_at": now,
+			"updated_at": now,
+			"change_seq": seq,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			r.logger.WarnContext(ctx, "No task deleted, task not found", "task_id", id)
+			return err
+		}
+		r.logger.ErrorContext(ctx, "Failed to delete task", "task_id", id, "error", err)
+		return err
 	}
-	r.logger.Info("Task deleted successfully", "task_id", id)
+	r.logger.InfoContext(ctx, "Task deleted successfully", "task_id", id)
 	return nil
 }
 
-func (r *GormTaskRepository) List(_ context.Context, filter map[string]interface{}, page *pagination.Page) ([]*model.Task, int, error) {
+func (r *GormTaskRepository) List(ctx context.Context, filter map[string]interface{}, page *pagination.Page) ([]*model.Task, int, error) {
 	var tasks []model.Task
 	var totalCount int64
 
-	query := r.db.Model(&model.Task{})
+	query := database.Conn(ctx, r.db).Model(&model.Task{})
 
 	if filter != nil {
 		if status, ok := filter["status"]; ok {
@@ -89,7 +134,7 @@ func (r *GormTaskRepository) List(_ context.Context, filter map[string]interface
 	}
 
 	if err := query.Count(&totalCount).Error; err != nil {
-		r.logger.Error("Failed to get total count of tasks", "error", err)
+		r.logger.ErrorContext(ctx, "Failed to get total count of tasks", "error", err)
 		return nil, 0, err
 	}
 
@@ -98,11 +143,11 @@ func (r *GormTaskRepository) List(_ context.Context, filter map[string]interface
 	}
 
 	if err := query.Find(&tasks).Error; err != nil {
-		r.logger.Error("Failed to list tasks", "error", err)
+		r.logger.ErrorContext(ctx, "Failed to list tasks", "error", err)
 		return nil, 0, err
 	}
 
-	r.logger.Info("Tasks listed successfully", "count", len(tasks))
+	r.logger.DebugContext(ctx, "Tasks listed successfully", "count", len(tasks))
 	taskPtrs := make([]*model.Task, len(tasks))
 	for i := range tasks {
 		taskPtrs[i] = &tasks[i]
@@ -110,6 +155,127 @@ func (r *GormTaskRepository) List(_ context.Context, filter map[string]interface
 	return taskPtrs, int(totalCount), nil
 }
 
+func (r *GormTaskRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := database.Conn(ctx, r.db).Model(&model.Task{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count tasks by status", "error", err)
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// ListChangedSince : Returns up to limit tasks written after the given change sequence value, in sequence
+// order. Deleted tasks are included as tombstones when includeDeleted is set.
+func (r *GormTaskRepository) ListChangedSince(ctx context.Context, seq int64, limit int, includeDeleted bool) ([]*model.Task, error) {
+	var tasks []*model.Task
+	query := database.Conn(ctx, r.db).Where("change_seq > ?", seq)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	if err := query.Order("change_seq").Limit(limit).Find(&tasks).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list changed tasks", "since", seq, "error", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// PurgeDeleted : Hard-deletes the tombstones of tasks deleted before the given time and raises the purge
+// horizon to the highest change sequence value removed, so sync tokens older than it can be rejected.
+func (r *GormTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	var purged int64
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Unscoped().Model(&model.Task{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		var horizon *int64
+		if err := deleted.Select("MAX(change_seq)").Scan(&horizon).Error; err != nil {
+			return err
+		}
+		if horizon == nil {
+			return nil
+		}
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&model.Task{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.Set{{Column: clause.Column{Name: "value"}, Value: gorm.Expr("MAX(value, ?)", *horizon)}},
+		}).Create(&model.ChangeSequence{Name: purgedChangeSequence, Value: *horizon}).Error
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to purge deleted tasks", "before", before, "error", err)
+		return 0, err
+	}
+	r.logger.InfoContext(ctx, "Deleted tasks purged", "count", purged, "before", before)
+	return int(purged), nil
+}
+
+// PurgedThrough : The highest change sequence value of a purged tombstone, or 0 when none were purged.
+func (r *GormTaskRepository) PurgedThrough(ctx context.Context) (int64, error) {
+	var sequence model.ChangeSequence
+	err := database.Conn(ctx, r.db).Where("name = ?", purgedChangeSequence).Limit(1).Find(&sequence).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to read purge horizon", "error", err)
+		return 0, err
+	}
+	return sequence.Value, nil
+}
+
+func (r *GormTaskRepository) ResetProjection(ctx context.Context) error {
+	result := database.Conn(ctx, r.db).Unscoped().Where("1 = 1").Delete(&model.Task{})
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to reset task projection", "error", result.Error)
+		return result.Error
+	}
+	r.logger.InfoContext(ctx, "Task projection reset", "deleted", result.RowsAffected)
+	return nil
+}
+
+// SaveProjection : Writes the task, or its tombstone when DeletedAt is set, with a new change sequence value
+// so clients syncing across a rebuild receive it again.
+func (r *GormTaskRepository) SaveProjection(ctx context.Context, task *model.Task) error {
+	err := r.withChangeSeq(ctx, func(tx *gorm.DB, seq int64) error {
+		task.ChangeSeq = seq
+		return tx.Unscoped().Save(task).Error
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save task projection", "task_id", task.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// withChangeSeq : Runs fn in a transaction with the next value of the task change sequence. The counter row
+// stays locked until the transaction commits, so values become visible in the order they were handed out.
+func (r *GormTaskRepository) withChangeSeq(ctx context.Context, fn func(tx *gorm.DB, seq int64) error) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.ChangeSequence{Name: taskChangeSequence}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.ChangeSequence{}).
+			Where("name = ?", taskChangeSequence).
+			Update("value", gorm.Expr("value + 1")).Error
+		if err != nil {
+			return err
+		}
+		var sequence model.ChangeSequence
+		if err := tx.First(&sequence, "name = ?", taskChangeSequence).Error; err != nil {
+			return err
+		}
+		return fn(tx, sequence.Value)
+	})
+}
+
 func (r *GormTaskRepository) Close() error {
 	sqlDB, err := r.db.DB()
 	if err != nil {
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/common/database"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const eventStoreBatchSize = 500
+
+type GormTaskEventStore struct {
+	db     *gorm.DB
+	logger *loggingtype.Logger
+}
+
+func NewGormTaskEventStore(db *gorm.DB) (*GormTaskEventStore, error) {
+	return &GormTaskEventStore{db: db, logger: loggingtype.ForPackage("repository")}, nil
+}
+
+func (s *GormTaskEventStore) Append(ctx context.Context, record *model.TaskEventRecord) error {
+	result := database.Conn(ctx, s.db).Create(record)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			s.logger.WarnContext(ctx, "Task event version already exists", "task_id", record.AggregateID, "version", record.Version)
+			return apperrors.ErrConflict
+		}
+		s.logger.ErrorContext(ctx, "Failed to append task event", "task_id", record.AggregateID, "error", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+func (s *GormTaskEventStore) Load(ctx context.Context, aggregateID string, until *time.Time) ([]*model.TaskEventRecord, error) {
+	var records []*model.TaskEventRecord
+	query := database.Conn(ctx, s.db).Where("aggregate_id = ?", aggregateID)
+	if until != nil {
+		query = query.Where("occurred_at <= ?", until.UTC())
+	}
+	if err := query.Order("version").Find(&records).Error; err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load task events", "task_id", aggregateID, "error", err)
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *GormTaskEventStore) LastVersion(ctx context.Context, aggregateID string) (int, error) {
+	var version int
+	err := database.Conn(ctx, s.db).Model(&model.TaskEventRecord{}).
+		Where("aggregate_id = ?", aggregateID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to read task event version", "task_id", aggregateID, "error", err)
+		return 0, err
+	}
+	return version, nil
+}
+
+func (s *GormTaskEventStore) Stream(ctx context.Context, fn func(record *model.TaskEventRecord) error) error {
+	var (
+		lastAggregate string
+		lastVersion   int
+	)
+	for {
+		var batch []*model.TaskEventRecord
+		err := database.Conn(ctx, s.db).
+			Where("aggregate_id > ? OR (aggregate_id = ? AND version > ?)", lastAggregate, lastAggregate, lastVersion).
+			Order("aggregate_id").Order("version").
+			Limit(eventStoreBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to stream task events", "error", err)
+			return err
+		}
+		for _, record := range batch {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		if len(batch) < eventStoreBatchSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		lastAggregate, lastVersion = last.AggregateID, last.Version
+	}
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/common/database"
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormWebhookRepository struct {
+	db     *gorm.DB
+	logger *loggingtype.Logger
+}
+
+func NewGormWebhookRepository(db *gorm.DB) (*GormWebhookRepository, error) {
+	return &GormWebhookRepository{db: db, logger: loggingtype.ForPackage("repository")}, nil
+}
+
+func (r *GormWebhookRepository) CreateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	if err := database.Conn(ctx, r.db).Create(subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperrors.ErrDuplicateEntity
+		}
+		r.logger.ErrorContext(ctx, "Failed to create webhook subscription", "error", err)
+		return err
+	}
+	r.logger.InfoContext(ctx, "Webhook subscription created", "subscription_id", subscription.ID)
+	return nil
+}
+
+func (r *GormWebhookRepository) GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := database.Conn(ctx, r.db).First(&subscription, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrNotFound
+		}
+		r.logger.ErrorContext(ctx, "Failed to get webhook subscription", "subscription_id", id, "error", err)
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *GormWebhookRepository) UpdateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	subscription.UpdatedAt = time.Now()
+	result := database.Conn(ctx, r.db).Model(&model.WebhookSubscription{}).
+		Where("id = ?", subscription.ID).
+		Select("url", "secret", "event_types", "active", "consecutive_failures", "disabled_at", "updated_at").
+		Updates(subscription)
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to update webhook subscription", "subscription_id", subscription.ID, "error", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.WebhookSubscription{}, "id = ?", id)
+		if result.Error != nil {
+			r.logger.ErrorContext(ctx, "Failed to delete webhook subscription", "subscription_id", id, "error", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrNotFound
+		}
+		if err := tx.Delete(&model.WebhookOutboxEntry{}, "subscription_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.WebhookDelivery{}, "subscription_id = ?", id).Error
+	})
+}
+
+func (r *GormWebhookRepository) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	if err := database.Conn(ctx, r.db).Order("created_at").Find(&subscriptions).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list webhook subscriptions", "error", err)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *GormWebhookRepository) ListActiveSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	if err := database.Conn(ctx, r.db).Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list active webhook subscriptions", "error", err)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *GormWebhookRepository) RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := database.Conn(ctx, r.db).Create(delivery).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record webhook delivery", "subscription_id", delivery.SubscriptionID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := database.Conn(ctx, r.db).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list webhook deliveries", "subscription_id", subscriptionID, "error", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *GormWebhookRepository) MarkDeliveryFailed(ctx context.Context, subscriptionID string, maxFailures int) (bool, error) {
+	disabled := false
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&model.WebhookSubscription{}).
+			Where("id = ?", subscriptionID).
+			Updates(map[string]interface{}{
+				"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+				"updated_at":           now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		if maxFailures <= 0 {
+			return nil
+		}
+		result := tx.Model(&model.WebhookSubscription{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", subscriptionID, true, maxFailures).
+			Updates(map[string]interface{}{"active": false, "disabled_at": now})
+		disabled = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record webhook failure", "subscription_id", subscriptionID, "error", err)
+		return false, err
+	}
+	return disabled, nil
+}
+
+func (r *GormWebhookRepository) MarkDeliverySucceeded(ctx context.Context, subscriptionID string) error {
+	err := database.Conn(ctx, r.db).Model(&model.WebhookSubscription{}).
+		Where("id = ? AND consecutive_failures > 0", subscriptionID).
+		Update("consecutive_failures", 0).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reset webhook failures", "subscription_id", subscriptionID, "error", err)
+	}
+	return err
+}
+
+func (r *GormWebhookRepository) EnqueueOutboxEntries(ctx context.Context, entries []*model.WebhookOutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(entries).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to enqueue webhook deliveries", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) ClaimOutboxEntries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookOutboxEntry, error) {
+	conn := database.Conn(ctx, r.db)
+	var due []*model.WebhookOutboxEntry
+	if err := conn.Where("next_attempt_at <= ?", now).Order("next_attempt_at").Limit(limit).Find(&due).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list due webhook deliveries", "error", err)
+		return nil, err
+	}
+
+	claimed := due[:0]
+	until := now.Add(lease)
+	for _, entry := range due {
+		// Another instance may have claimed the entry since it was read; only the update that still finds it
+		// due wins.
+		result := conn.Model(&model.WebhookOutboxEntry{}).
+			Where("id = ? AND next_attempt_at <= ?", entry.ID, now).
+			Update("next_attempt_at", until)
+		if result.Error != nil {
+			r.logger.ErrorContext(ctx, "Failed to claim webhook delivery", "subscription_id", entry.SubscriptionID, "error", result.Error)
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			entry.NextAttemptAt = until
+			claimed = append(claimed, entry)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *GormWebhookRepository) RescheduleOutboxEntry(ctx context.Context, id string, attempts int, next time.Time) error {
+	err := database.Conn(ctx, r.db).Model(&model.WebhookOutboxEntry{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": next}).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reschedule webhook delivery", "error", err)
+	}
+	return err
+}
+
+func (r *GormWebhookRepository) DeleteOutboxEntry(ctx context.Context, id string) error {
+	if err := database.Conn(ctx, r.db).Delete(&model.WebhookOutboxEntry{}, "id = ?", id).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete webhook delivery", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) CountOverdueOutboxEntries(ctx context.Context, dueBefore time.Time) (int64, error) {
+	var count int64
+	if err := database.Conn(ctx, r.db).Model(&model.WebhookOutboxEntry{}).Where("next_attempt_at < ?", dueBefore).Count(&count).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count overdue webhook deliveries", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"time"
+)
+
+type IdempotencyKeyRepository interface {
+	// Reserve inserts the record and reports false when a record with its key exists, expired or not.
+	Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, key string) (*model.IdempotencyKey, error)
+	// Complete stores the response of a reserved key.
+	Complete(ctx context.Context, record *model.IdempotencyKey) error
+	Delete(ctx context.Context, key string) error
+	// DeleteExpired deletes the records that expired before the given time, all of them when key is empty.
+	DeleteExpired(ctx context.Context, key string, before time.Time) (int64, error)
+}
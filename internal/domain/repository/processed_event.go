@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ProcessedEventRepository interface {
+	// MarkProcessed records the event and reports false when it had already been recorded.
+	MarkProcessed(ctx context.Context, eventID, eventType string) (bool, error)
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
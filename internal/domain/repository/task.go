@@ -4,6 +4,7 @@ import (
 	"alle-task-manager-gunish/internal/common/pagination"
 	"alle-task-manager-gunish/internal/domain/model"
 	"context"
+	"time"
 )
 
 type TaskRepository interface {
@@ -12,4 +13,11 @@ type TaskRepository interface {
 	Update(ctx context.Context, task *model.Task) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filter map[string]interface{}, page *pagination.Page) ([]*model.Task, int, error)
+	ListChangedSince(ctx context.Context, seq int64, limit int, includeDeleted bool) ([]*model.Task, error)
+	// PurgeDeleted hard-deletes tasks soft-deleted before the given time and returns how many were removed.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	// PurgedThrough returns the highest change sequence value of a purged task, 0 when nothing was purged.
+	PurgedThrough(ctx context.Context) (int64, error)
+	// CountByStatus returns the number of live tasks in each status that has any.
+	CountByStatus(ctx context.Context) (map[string]int, error)
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"time"
+)
+
+type TaskEventStore interface {
+	// Append stores the event and returns errors.ErrConflict when its version is already taken.
+	Append(ctx context.Context, record *model.TaskEventRecord) error
+	// Load returns a task's events in version order, limited to those that occurred at or before until when it is set.
+	Load(ctx context.Context, aggregateID string, until *time.Time) ([]*model.TaskEventRecord, error)
+	LastVersion(ctx context.Context, aggregateID string) (int, error)
+	// Stream calls fn for every stored event, grouped by task and in version order.
+	Stream(ctx context.Context, fn func(record *model.TaskEventRecord) error) error
+}
+
+// TaskProjectionWriter : Write access to the tasks table used when rebuilding it from the event store.
+type TaskProjectionWriter interface {
+	ResetProjection(ctx context.Context) error
+	SaveProjection(ctx context.Context, task *model.Task) error
+}
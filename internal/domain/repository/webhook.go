@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+	ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+	ListActiveSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+
+	RecordDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*model.WebhookDelivery, error)
+	// MarkDeliveryFailed counts a failed delivery and deactivates the subscription once maxFailures
+	// consecutive deliveries have failed. It reports whether this call deactivated it.
+	MarkDeliveryFailed(ctx context.Context, subscriptionID string, maxFailures int) (bool, error)
+	MarkDeliverySucceeded(ctx context.Context, subscriptionID string) error
+
+	// EnqueueOutboxEntries adds pending deliveries, skipping events already pending for a subscription.
+	EnqueueOutboxEntries(ctx context.Context, entries []*model.WebhookOutboxEntry) error
+	// ClaimOutboxEntries returns up to limit entries due at now and postpones them by lease, so no other
+	// worker takes them while they are delivered.
+	ClaimOutboxEntries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookOutboxEntry, error)
+	RescheduleOutboxEntry(ctx context.Context, id string, attempts int, next time.Time) error
+	DeleteOutboxEntry(ctx context.Context, id string) error
+	// CountOverdueOutboxEntries counts the entries that were due before dueBefore and are not being delivered.
+	CountOverdueOutboxEntries(ctx context.Context, dueBefore time.Time) (int64, error)
+}
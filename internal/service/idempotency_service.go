@@ -0,0 +1,135 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with the idempotency key is in progress")
+)
+
+// IdempotentResponse : The response stored for an idempotency key and replayed on retries.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyService : Remembers the responses of requests sent with an idempotency key for ttl. A key is held
+// for lockTimeout while its request is handled, so a key whose request never completed, e.g. because the
+// instance crashed, can be used again after that.
+type IdempotencyService struct {
+	repo        repository.IdempotencyKeyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewIdempotencyService(repo repository.IdempotencyKeyRepository, ttl, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl, lockTimeout: lockTimeout, now: time.Now}
+}
+
+// Begin : Reserves key for the request with the given fingerprint. It returns the stored response when the
+// request has already been handled, and nil when the caller should handle it and then call Complete or
+// Release. A key used with another fingerprint gives ErrIdempotencyKeyReused, one whose request is still
+// being handled ErrIdempotencyKeyInProgress.
+func (s *IdempotencyService) Begin(ctx context.Context, key, fingerprint string) (*IdempotentResponse, error) {
+	// A second attempt is needed when the record expired or was released between Reserve and Get.
+	for attempt := 0; attempt < 2; attempt++ {
+		now := s.now()
+		reserved, err := s.repo.Reserve(ctx, &model.IdempotencyKey{
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.lockTimeout),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		record, err := s.repo.Get(ctx, key)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.ExpiresAt.Before(now) {
+			if _, err := s.repo.DeleteExpired(ctx, key, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if record.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if !record.Completed() {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		stored := &IdempotentResponse{StatusCode: record.StatusCode, Body: record.Body}
+		if err := json.Unmarshal(record.Headers, &stored.Header); err != nil {
+			return nil, err
+		}
+		return stored, nil
+	}
+	return nil, ErrIdempotencyKeyInProgress
+}
+
+// Complete : Stores the response of a key reserved by Begin. Responses that ask the client to retry, server
+// errors and conflicts, are not stored; the key is released instead so the retry is handled again.
+func (s *IdempotencyService) Complete(ctx context.Context, key string, res *IdempotentResponse) error {
+	if res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusConflict || res.StatusCode == http.StatusTooManyRequests {
+		return s.Release(ctx, key)
+	}
+	headers, err := json.Marshal(res.Header)
+	if err != nil {
+		return err
+	}
+	return s.repo.Complete(ctx, &model.IdempotencyKey{
+		Key:        key,
+		StatusCode: res.StatusCode,
+		Headers:    headers,
+		Body:       res.Body,
+		ExpiresAt:  s.now().Add(s.ttl),
+	})
+}
+
+// Release : Frees a key reserved by Begin without storing a response.
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	return s.repo.Delete(ctx, key)
+}
+
+// RunCleanup : Deletes expired keys every interval until ctx is done.
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	logger := loggingtype.ForPackage("service")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx, "", s.now())
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to clean up idempotency keys", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				logger.InfoContext(ctx, "Cleaned up idempotency keys", "deleted", deleted)
+			}
+		}
+	}
+}
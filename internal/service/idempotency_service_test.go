@@ -0,0 +1,89 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newIdempotencyTestService(t *testing.T) (*IdempotencyService, *time.Time) {
+	repo, err := repository.NewGormIdempotencyKeyRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	svc := NewIdempotencyService(repo, time.Hour, time.Minute)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestIdempotencyService_ReplaysCompletedRequests(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newIdempotencyTestService(t)
+
+	stored, err := svc.Begin(ctx, "key", "fingerprint")
+	require.NoError(t, err)
+	assert.Nil(t, stored, "the first request is handled")
+
+	_, err = svc.Begin(ctx, "key", "fingerprint")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+	_, err = svc.Begin(ctx, "key", "other")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	require.NoError(t, svc.Complete(ctx, "key", &IdempotentResponse{StatusCode: http.StatusCreated, Header: header, Body: []byte(`{"id":"1"}`)}))
+
+	stored, err = svc.Begin(ctx, "key", "fingerprint")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, http.StatusCreated, stored.StatusCode)
+	assert.Equal(t, header, stored.Header)
+	assert.Equal(t, `{"id":"1"}`, string(stored.Body))
+
+	_, err = svc.Begin(ctx, "key", "other")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestIdempotencyService_ReleasesRetryableResponses(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newIdempotencyTestService(t)
+
+	for _, status := range []int{http.StatusInternalServerError, http.StatusConflict, http.StatusTooManyRequests} {
+		stored, err := svc.Begin(ctx, "key", "fingerprint")
+		require.NoError(t, err)
+		require.Nil(t, stored)
+		require.NoError(t, svc.Complete(ctx, "key", &IdempotentResponse{StatusCode: status}))
+	}
+
+	stored, err := svc.Begin(ctx, "key", "other")
+	require.NoError(t, err, "a released key can be used for another request")
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyService_Expiry(t *testing.T) {
+	ctx := context.Background()
+	svc, now := newIdempotencyTestService(t)
+
+	_, err := svc.Begin(ctx, "abandoned", "fingerprint")
+	require.NoError(t, err)
+	*now = now.Add(time.Minute + time.Second)
+	stored, err := svc.Begin(ctx, "abandoned", "fingerprint")
+	require.NoError(t, err, "a key is taken over once its lock times out")
+	assert.Nil(t, stored)
+
+	require.NoError(t, svc.Complete(ctx, "abandoned", &IdempotentResponse{StatusCode: http.StatusOK}))
+	*now = now.Add(59 * time.Minute)
+	stored, err = svc.Begin(ctx, "abandoned", "fingerprint")
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+
+	*now = now.Add(time.Minute + time.Second)
+	deleted, err := svc.repo.DeleteExpired(ctx, "", *now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	stored, err = svc.Begin(ctx, "abandoned", "other")
+	require.NoError(t, err, "an expired key can be used for another request")
+	assert.Nil(t, stored)
+}
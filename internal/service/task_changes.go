@@ -0,0 +1,44 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/domain/model"
+	"time"
+)
+
+type TaskChanges struct {
+	Fields   []string
+	Previous map[string]interface{}
+}
+
+// diffTask : Compares the user-editable fields of two versions of a task. Keys match the task's JSON field names.
+func diffTask(before, after *model.Task) *TaskChanges {
+	changes := &TaskChanges{
+		Fields:   []string{},
+		Previous: map[string]interface{}{},
+	}
+	record := func(field string, previous interface{}) {
+		changes.Fields = append(changes.Fields, field)
+		changes.Previous[field] = previous
+	}
+
+	if before.Title != after.Title {
+		record("title", before.Title)
+	}
+	if before.Description != after.Description {
+		record("description", before.Description)
+	}
+	if before.Status != after.Status {
+		record("status", string(before.Status))
+	}
+	if !sameTime(before.DueDate, after.DueDate) {
+		record("due_date", before.DueDate)
+	}
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDiffTask(t *testing.T) {
+	dueDate := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	before := &model.Task{ID: "task-1", Title: "Title", Description: "Description", Status: model.Pending}
+
+	t.Run("no changes", func(t *testing.T) {
+		after := *before
+		after.UpdatedAt = time.Now()
+
+		changes := diffTask(before, &after)
+
+		assert.Empty(t, changes.Fields)
+		assert.Empty(t, changes.Previous)
+	})
+
+	t.Run("records previous values of changed fields", func(t *testing.T) {
+		after := *before
+		after.Description = "New description"
+		after.Status = model.Completed
+		after.DueDate = &dueDate
+
+		changes := diffTask(before, &after)
+
+		assert.Equal(t, []string{"description", "status", "due_date"}, changes.Fields)
+		assert.Equal(t, "Description", changes.Previous["description"])
+		assert.Equal(t, "pending", changes.Previous["status"])
+		assert.Nil(t, changes.Previous["due_date"])
+	})
+
+	t.Run("due dates are compared by instant", func(t *testing.T) {
+		withDueDate := *before
+		withDueDate.DueDate = &dueDate
+		sameInstant := dueDate.In(time.FixedZone("IST", 5*3600+1800))
+		after := withDueDate
+		after.DueDate = &sameInstant
+
+		assert.Empty(t, diffTask(&withDueDate, &after).Fields)
+	})
+}
@@ -1,28 +1,128 @@
 package service
 
 import (
+	"alle-task-manager-gunish/internal/common/eventbus"
 	"alle-task-manager-gunish/internal/common/events"
 	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
 	"encoding/json"
-	"github.com/IBM/sarama"
+	"fmt"
+	"sync/atomic"
+	"time"
 )
 
 type TaskEventConsumerService struct {
+	registry          *TaskEventHandlerRegistry
+	processedEvents   repository.ProcessedEventRepository
+	transactor        repository.Transactor
+	duplicatesSkipped atomic.Uint64
 }
 
-func NewTaskEventConsumerService() *TaskEventConsumerService {
-	return &TaskEventConsumerService{}
+type ConsumerServiceOption func(*TaskEventConsumerService)
+
+// WithDeduplication : Records every handled event ID so redelivered events are skipped. The record is
+// written in the same transaction the handlers run in, so a failed handler leaves the event unrecorded.
+func WithDeduplication(processedEvents repository.ProcessedEventRepository, transactor repository.Transactor) ConsumerServiceOption {
+	return func(s *TaskEventConsumerService) {
+		s.processedEvents = processedEvents
+		s.transactor = transactor
+	}
+}
+
+func NewTaskEventConsumerService(registry *TaskEventHandlerRegistry, options ...ConsumerServiceOption) *TaskEventConsumerService {
+	s := &TaskEventConsumerService{
+		registry: registry,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
 }
 
-func (s *TaskEventConsumerService) HandleMessage(message *sarama.ConsumerMessage) error {
-	var baseEvent events.TaskEvent
-	if err := json.Unmarshal(message.Value, &baseEvent); err != nil {
-		return err
+func (s *TaskEventConsumerService) HandleMessage(ctx context.Context, message *eventbus.Message) error {
+	cloudEvent, err := events.DecodeCloudEvent(message.Headers, message.Value)
+	if err != nil {
+		return eventbus.Permanent(fmt.Errorf("decoding task event: %w", err))
+	}
+	baseEvent := events.TaskEvent{EventID: cloudEvent.ID, TaskID: cloudEvent.Subject, EventType: cloudEvent.Type, Timestamp: cloudEvent.Time}
+	baseEvent.CorrelationID = correlationID(message.Headers, cloudEvent.Data)
+	if baseEvent.CorrelationID != "" {
+		ctx = loggingtype.WithRequestID(ctx, baseEvent.CorrelationID)
 	}
 
-	switch baseEvent.EventType {
-	default:
-		loggingtype.GetLogger().Error("Unknown event type: ", "event_type", baseEvent.EventType)
+	handlers := s.registry.Handlers(baseEvent.EventType)
+	if len(handlers) == 0 {
+		loggingtype.ForPackage("service").WarnContext(ctx, "No handler registered for event type", "event_type", baseEvent.EventType, "event_id", baseEvent.EventID)
 		return nil
 	}
+
+	if s.processedEvents == nil || baseEvent.EventID == "" {
+		return s.dispatch(ctx, &baseEvent, handlers, cloudEvent.Data)
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		first, err := s.processedEvents.MarkProcessed(ctx, baseEvent.EventID, baseEvent.EventType)
+		if err != nil {
+			return err
+		}
+		if !first {
+			s.duplicatesSkipped.Add(1)
+			loggingtype.ForPackage("service").InfoContext(ctx, "Skipping already processed event", "event_type", baseEvent.EventType, "event_id", baseEvent.EventID)
+			return nil
+		}
+		return s.dispatch(ctx, &baseEvent, handlers, cloudEvent.Data)
+	})
+}
+
+// correlationID : Read from the header, or from the payload when the header was dropped on the way, e.g. by
+// a bridge that only copies CloudEvents attributes.
+func correlationID(headers map[string]string, payload []byte) string {
+	if id := headers[events.HeaderCorrelationID]; id != "" {
+		return id
+	}
+	var event events.TaskEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return ""
+	}
+	return event.CorrelationID
+}
+
+func (s *TaskEventConsumerService) dispatch(ctx context.Context, baseEvent *events.TaskEvent, handlers []TaskEventHandler, payload []byte) error {
+	for _, handler := range handlers {
+		if err := handler(ctx, payload); err != nil {
+			return fmt.Errorf("handling %s event %s: %w", baseEvent.EventType, baseEvent.EventID, err)
+		}
+	}
+	return nil
+}
+
+func (s *TaskEventConsumerService) DuplicatesSkipped() uint64 {
+	return s.duplicatesSkipped.Load()
+}
+
+// RunProcessedEventCleanup : Deletes processed-event records older than ttl every interval until ctx is done.
+func (s *TaskEventConsumerService) RunProcessedEventCleanup(ctx context.Context, ttl, interval time.Duration) {
+	if s.processedEvents == nil {
+		return
+	}
+	logger := loggingtype.ForPackage("service")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.processedEvents.DeleteProcessedBefore(ctx, time.Now().Add(-ttl))
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to clean up processed events", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				logger.InfoContext(ctx, "Cleaned up processed events", "deleted", deleted, "ttl", ttl.String())
+			}
+		}
+	}
 }
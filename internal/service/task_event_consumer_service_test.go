@@ -0,0 +1,413 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/common/kafka"
+	"alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/metrics"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type MockConsumerGroupSession struct {
+	mock.Mock
+	ctx context.Context
+}
+
+func (m *MockConsumerGroupSession) Claims() map[string][]int32 { return nil }
+func (m *MockConsumerGroupSession) MemberID() string           { return "test-member" }
+func (m *MockConsumerGroupSession) GenerationID() int32        { return 1 }
+func (m *MockConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (m *MockConsumerGroupSession) Commit() {}
+func (m *MockConsumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+
+func (m *MockConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	m.Called(msg, metadata)
+}
+
+func (m *MockConsumerGroupSession) Context() context.Context {
+	return m.ctx
+}
+
+type MockConsumerGroupClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (m *MockConsumerGroupClaim) Topic() string                            { return TopicTaskEvents }
+func (m *MockConsumerGroupClaim) Partition() int32                         { return 0 }
+func (m *MockConsumerGroupClaim) InitialOffset() int64                     { return 0 }
+func (m *MockConsumerGroupClaim) HighWaterMarkOffset() int64               { return int64(len(m.messages)) }
+func (m *MockConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return m.messages }
+
+func newMockClaim(messages ...*sarama.ConsumerMessage) *MockConsumerGroupClaim {
+	claim := &MockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for _, message := range messages {
+		claim.messages <- message
+	}
+	close(claim.messages)
+	return claim
+}
+
+func newEventMessage(t *testing.T, offset int64, event interface{}) *sarama.ConsumerMessage {
+	value, err := json.Marshal(event)
+	require.NoError(t, err)
+	return &sarama.ConsumerMessage{Topic: TopicTaskEvents, Offset: offset, Value: value}
+}
+
+func newBusMessage(t *testing.T, event interface{}) *eventbus.Message {
+	value, err := json.Marshal(event)
+	require.NoError(t, err)
+	return &eventbus.Message{Topic: TopicTaskEvents, Value: value}
+}
+
+func TestTaskEventConsumerService_HandleMessage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("dispatches to registered handlers by event type", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		var created *events.TaskCreatedEvent
+		var updated *events.TaskUpdatedEvent
+		registry.Register(events.EventTypeTaskCreated, OnTaskCreated(func(_ context.Context, event *events.TaskCreatedEvent) error {
+			created = event
+			return nil
+		}))
+		registry.Register(events.EventTypeTaskUpdated, OnTaskUpdated(func(_ context.Context, event *events.TaskUpdatedEvent) error {
+			updated = event
+			return nil
+		}))
+		service := NewTaskEventConsumerService(registry)
+
+		err := service.HandleMessage(ctx, newBusMessage(t, &events.TaskCreatedEvent{
+			TaskEvent: events.TaskEvent{EventID: "event-1", TaskID: "task-1", EventType: events.EventTypeTaskCreated, Timestamp: time.Now()},
+			Title:     "Created Task",
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.Equal(t, "task-1", created.TaskID)
+		assert.Equal(t, "Created Task", created.Title)
+		assert.Nil(t, updated)
+
+		err = service.HandleMessage(ctx, newBusMessage(t, &events.TaskUpdatedEvent{
+			TaskEvent: events.TaskEvent{EventID: "event-2", TaskID: "task-1", EventType: events.EventTypeTaskUpdated, Timestamp: time.Now()},
+			Status:    "completed",
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, updated)
+		assert.Equal(t, "completed", updated.Status)
+	})
+
+	t.Run("decodes CloudEvents in binary mode", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		var created *events.TaskCreatedEvent
+		registry.Register(events.EventTypeTaskCreated, OnTaskCreated(func(_ context.Context, event *events.TaskCreatedEvent) error {
+			created = event
+			return nil
+		}))
+		service := NewTaskEventConsumerService(registry)
+
+		base := events.TaskEvent{EventID: "event-3", TaskID: "task-3", EventType: events.EventTypeTaskCreated, Timestamp: time.Now()}
+		cloudEvent, err := events.NewCloudEvent(base, &events.TaskCreatedEvent{TaskEvent: base, Title: "Binary"})
+		require.NoError(t, err)
+		headers, body, err := cloudEvent.Encode(events.ModeBinary)
+		require.NoError(t, err)
+		message := &eventbus.Message{Topic: TopicTaskEvents, Headers: headers, Value: body}
+
+		require.NoError(t, service.HandleMessage(ctx, message))
+		require.NotNil(t, created)
+		assert.Equal(t, "Binary", created.Title)
+	})
+
+	t.Run("restores the correlation ID as request ID", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		var requestID string
+		registry.Register(events.EventTypeTaskCreated, func(ctx context.Context, _ []byte) error {
+			requestID = loggingtype.RequestID(ctx)
+			return nil
+		})
+		service := NewTaskEventConsumerService(registry)
+
+		message := newBusMessage(t, &events.TaskCreatedEvent{
+			TaskEvent: events.TaskEvent{EventID: "event-4", TaskID: "task-4", EventType: events.EventTypeTaskCreated, Timestamp: time.Now(), CorrelationID: "from-payload"},
+		})
+		require.NoError(t, service.HandleMessage(ctx, message))
+		assert.Equal(t, "from-payload", requestID, "the payload is used when the header is missing")
+
+		message.Headers = map[string]string{events.HeaderCorrelationID: "from-header"}
+		require.NoError(t, service.HandleMessage(ctx, message))
+		assert.Equal(t, "from-header", requestID)
+	})
+
+	t.Run("ignores unknown event types", func(t *testing.T) {
+		service := NewTaskEventConsumerService(NewTaskEventHandlerRegistry())
+
+		err := service.HandleMessage(ctx, newBusMessage(t, &events.TaskEvent{EventID: "event-1", EventType: "TASK_ARCHIVED"}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns handler errors", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		handlerErr := errors.New("downstream unavailable")
+		registry.Register(events.EventTypeTaskCreated, func(context.Context, []byte) error { return handlerErr })
+		service := NewTaskEventConsumerService(registry)
+
+		err := service.HandleMessage(ctx, newBusMessage(t, &events.TaskEvent{EventID: "event-1", EventType: events.EventTypeTaskCreated}))
+		assert.ErrorIs(t, err, handlerErr)
+	})
+
+	t.Run("rejects malformed payloads", func(t *testing.T) {
+		service := NewTaskEventConsumerService(NewTaskEventHandlerRegistry())
+
+		err := service.HandleMessage(ctx, &eventbus.Message{Value: []byte("not-json")})
+		assert.Error(t, err)
+	})
+}
+
+func TestConsumerGroupHandler_ConsumeClaim(t *testing.T) {
+	registry := NewTaskEventHandlerRegistry()
+	var handled []string
+	registry.Register(events.EventTypeTaskCreated, OnTaskCreated(func(_ context.Context, event *events.TaskCreatedEvent) error {
+		handled = append(handled, event.EventID)
+		return nil
+	}))
+	registry.Register(events.EventTypeTaskUpdated, OnTaskUpdated(func(_ context.Context, event *events.TaskUpdatedEvent) error {
+		return eventbus.Permanent(errors.New("update handler failed"))
+	}))
+	handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(registry).HandleMessage))
+
+	created := newEventMessage(t, 0, &events.TaskCreatedEvent{
+		TaskEvent: events.TaskEvent{EventID: "event-1", TaskID: "task-1", EventType: events.EventTypeTaskCreated},
+	})
+	updated := newEventMessage(t, 1, &events.TaskUpdatedEvent{
+		TaskEvent: events.TaskEvent{EventID: "event-2", TaskID: "task-1", EventType: events.EventTypeTaskUpdated},
+	})
+
+	session := &MockConsumerGroupSession{ctx: context.Background()}
+	session.On("MarkMessage", created, "").Once()
+
+	err := handler.ConsumeClaim(session, newMockClaim(created, updated))
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"event-1"}, handled)
+	session.AssertExpectations(t)
+	session.AssertNotCalled(t, "MarkMessage", updated, "")
+}
+
+func TestConsumerGroupHandler_ConsumeClaimStopsOnCancelledSession(t *testing.T) {
+	handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(NewTaskEventHandlerRegistry()).HandleMessage))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	session := &MockConsumerGroupSession{ctx: ctx}
+	claim := &MockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage)}
+
+	err := handler.ConsumeClaim(session, claim)
+	assert.NoError(t, err)
+	session.AssertNotCalled(t, "MarkMessage", mock.Anything, mock.Anything)
+}
+
+func headerValue(headers []sarama.RecordHeader, key string) string {
+	for _, header := range headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func TestConsumerGroupHandler_RetryAndDeadLetter(t *testing.T) {
+	retryPolicy := eventbus.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+	event := &events.TaskCreatedEvent{
+		TaskEvent: events.TaskEvent{EventID: "event-1", TaskID: "task-1", EventType: events.EventTypeTaskCreated},
+	}
+
+	t.Run("retries transient failures until the handler succeeds", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		attempts := 0
+		registry.Register(events.EventTypeTaskCreated, func(context.Context, []byte) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("temporarily unavailable")
+			}
+			return nil
+		})
+		mockSyncProducer := new(MockSyncProducer)
+		dlq := kafka.NewDeadLetterQueue(&kafka.Producer{Producer: mockSyncProducer}, "task-events.dlq")
+		handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(registry).HandleMessage),
+			kafka.WithRetryPolicy(retryPolicy), kafka.WithDeadLetterQueue(dlq))
+
+		message := newEventMessage(t, 7, event)
+		session := &MockConsumerGroupSession{ctx: context.Background()}
+		session.On("MarkMessage", message, "").Once()
+
+		require.NoError(t, handler.ConsumeClaim(session, newMockClaim(message)))
+
+		assert.Equal(t, 3, attempts)
+		session.AssertExpectations(t)
+		mockSyncProducer.AssertNotCalled(t, "SendMessage", mock.Anything)
+	})
+
+	t.Run("forwards messages to the dead-letter topic after exhausting retries", func(t *testing.T) {
+		registry := NewTaskEventHandlerRegistry()
+		attempts := 0
+		registry.Register(events.EventTypeTaskCreated, func(context.Context, []byte) error {
+			attempts++
+			return errors.New("still unavailable")
+		})
+		mockSyncProducer := new(MockSyncProducer)
+		var dlqMessage *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			dlqMessage = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(0), nil).Once()
+		dlq := kafka.NewDeadLetterQueue(&kafka.Producer{Producer: mockSyncProducer}, "task-events.dlq")
+		handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(registry).HandleMessage),
+			kafka.WithRetryPolicy(retryPolicy), kafka.WithDeadLetterQueue(dlq))
+
+		message := newEventMessage(t, 7, event)
+		message.Partition = 2
+		session := &MockConsumerGroupSession{ctx: context.Background()}
+		session.On("MarkMessage", message, "").Once()
+		deadLettered := metrics.KafkaConsumed.WithLabelValues("", TopicTaskEvents, metrics.ResultDeadLettered)
+		processingErrors := metrics.KafkaProcessingErrors.WithLabelValues("", TopicTaskEvents)
+		deadLetteredBefore, processingErrorsBefore := testutil.ToFloat64(deadLettered), testutil.ToFloat64(processingErrors)
+
+		require.NoError(t, handler.ConsumeClaim(session, newMockClaim(message)))
+
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, deadLetteredBefore+1, testutil.ToFloat64(deadLettered))
+		assert.Equal(t, processingErrorsBefore+3, testutil.ToFloat64(processingErrors))
+		session.AssertExpectations(t)
+		mockSyncProducer.AssertExpectations(t)
+		require.NotNil(t, dlqMessage)
+		assert.Equal(t, "task-events.dlq", dlqMessage.Topic)
+		value, err := dlqMessage.Value.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, message.Value, value)
+		assert.Equal(t, TopicTaskEvents, headerValue(dlqMessage.Headers, eventbus.HeaderDLQOriginalTopic))
+		assert.Equal(t, "2", headerValue(dlqMessage.Headers, kafka.HeaderDLQOriginalPartition))
+		assert.Equal(t, "7", headerValue(dlqMessage.Headers, kafka.HeaderDLQOriginalOffset))
+		assert.Equal(t, "3", headerValue(dlqMessage.Headers, eventbus.HeaderDLQAttempts))
+		assert.Equal(t, "retryable", headerValue(dlqMessage.Headers, eventbus.HeaderDLQErrorType))
+		assert.Contains(t, headerValue(dlqMessage.Headers, eventbus.HeaderDLQError), "still unavailable")
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		mockSyncProducer := new(MockSyncProducer)
+		var dlqMessage *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			dlqMessage = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(0), nil).Once()
+		dlq := kafka.NewDeadLetterQueue(&kafka.Producer{Producer: mockSyncProducer}, "task-events.dlq")
+		handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(NewTaskEventHandlerRegistry()).HandleMessage),
+			kafka.WithRetryPolicy(retryPolicy), kafka.WithDeadLetterQueue(dlq))
+
+		message := &sarama.ConsumerMessage{Topic: TopicTaskEvents, Offset: 3, Value: []byte("not-json")}
+		session := &MockConsumerGroupSession{ctx: context.Background()}
+		session.On("MarkMessage", message, "").Once()
+
+		require.NoError(t, handler.ConsumeClaim(session, newMockClaim(message)))
+
+		session.AssertExpectations(t)
+		require.NotNil(t, dlqMessage)
+		assert.Equal(t, "permanent", headerValue(dlqMessage.Headers, eventbus.HeaderDLQErrorType))
+		assert.Equal(t, "1", headerValue(dlqMessage.Headers, eventbus.HeaderDLQAttempts))
+	})
+
+	t.Run("leaves the offset unmarked when the dead-letter topic is unavailable", func(t *testing.T) {
+		mockSyncProducer := new(MockSyncProducer)
+		mockSyncProducer.On("SendMessage", mock.Anything).Return(int32(0), int64(0), errors.New("broker down")).Once()
+		dlq := kafka.NewDeadLetterQueue(&kafka.Producer{Producer: mockSyncProducer}, "task-events.dlq")
+		handler := kafka.NewConsumerGroupHandler(kafka.AdaptHandler(NewTaskEventConsumerService(NewTaskEventHandlerRegistry()).HandleMessage),
+			kafka.WithRetryPolicy(retryPolicy), kafka.WithDeadLetterQueue(dlq))
+
+		message := &sarama.ConsumerMessage{Topic: TopicTaskEvents, Offset: 3, Value: []byte("not-json")}
+		session := &MockConsumerGroupSession{ctx: context.Background()}
+
+		err := handler.ConsumeClaim(session, newMockClaim(message))
+
+		assert.Error(t, err)
+		session.AssertNotCalled(t, "MarkMessage", mock.Anything, mock.Anything)
+	})
+}
+
+func newTestDatabase(t *testing.T) *database.Database {
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestTaskEventConsumerService_Deduplication(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	processedRepo, err := repository.NewGormProcessedEventRepository(db.Db)
+	require.NoError(t, err)
+
+	registry := NewTaskEventHandlerRegistry()
+	calls := 0
+	failNext := false
+	registry.Register(events.EventTypeTaskCreated, func(context.Context, []byte) error {
+		if failNext {
+			failNext = false
+			return errors.New("handler failed")
+		}
+		calls++
+		return nil
+	})
+	service := NewTaskEventConsumerService(registry, WithDeduplication(processedRepo, db))
+
+	message := newBusMessage(t, &events.TaskCreatedEvent{
+		TaskEvent: events.TaskEvent{EventID: "event-1", TaskID: "task-1", EventType: events.EventTypeTaskCreated},
+	})
+
+	t.Run("skips redelivered events", func(t *testing.T) {
+		require.NoError(t, service.HandleMessage(ctx, message))
+		require.NoError(t, service.HandleMessage(ctx, message))
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, uint64(1), service.DuplicatesSkipped())
+	})
+
+	t.Run("does not record events whose handlers failed", func(t *testing.T) {
+		retried := newBusMessage(t, &events.TaskCreatedEvent{
+			TaskEvent: events.TaskEvent{EventID: "event-2", TaskID: "task-2", EventType: events.EventTypeTaskCreated},
+		})
+		failNext = true
+
+		require.Error(t, service.HandleMessage(ctx, retried))
+		require.NoError(t, service.HandleMessage(ctx, retried))
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, uint64(1), service.DuplicatesSkipped())
+	})
+
+	t.Run("forgets events older than the ttl", func(t *testing.T) {
+		deleted, err := processedRepo.DeleteProcessedBefore(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		require.NoError(t, service.HandleMessage(ctx, message))
+		assert.Equal(t, 3, calls)
+	})
+}
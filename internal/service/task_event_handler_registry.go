@@ -0,0 +1,82 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type TaskEventHandler func(ctx context.Context, payload []byte) error
+
+type TaskEventHandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string][]TaskEventHandler
+}
+
+func NewTaskEventHandlerRegistry() *TaskEventHandlerRegistry {
+	return &TaskEventHandlerRegistry{
+		handlers: make(map[string][]TaskEventHandler),
+	}
+}
+
+func (r *TaskEventHandlerRegistry) Register(eventType string, handler TaskEventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = append(r.handlers[eventType], handler)
+}
+
+func (r *TaskEventHandlerRegistry) Handlers(eventType string) []TaskEventHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handlers := make([]TaskEventHandler, len(r.handlers[eventType]))
+	copy(handlers, r.handlers[eventType])
+	return handlers
+}
+
+func OnTaskCreated(fn func(ctx context.Context, event *events.TaskCreatedEvent) error) TaskEventHandler {
+	return func(ctx context.Context, payload []byte) error {
+		var event events.TaskCreatedEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return eventbus.Permanent(err)
+		}
+		return fn(ctx, &event)
+	}
+}
+
+func OnTaskUpdated(fn func(ctx context.Context, event *events.TaskUpdatedEvent) error) TaskEventHandler {
+	return func(ctx context.Context, payload []byte) error {
+		var event events.TaskUpdatedEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return eventbus.Permanent(err)
+		}
+		return fn(ctx, &event)
+	}
+}
+
+func OnTaskDeleted(fn func(ctx context.Context, event *events.TaskDeletedEvent) error) TaskEventHandler {
+	return func(ctx context.Context, payload []byte) error {
+		var event events.TaskDeletedEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return eventbus.Permanent(err)
+		}
+		return fn(ctx, &event)
+	}
+}
+
+func LogTaskCreated(ctx context.Context, event *events.TaskCreatedEvent) error {
+	loggingtype.ForPackage("service").InfoContext(ctx, "Task created event consumed", "event_id", event.EventID, "task_id", event.TaskID)
+	return nil
+}
+
+func LogTaskUpdated(ctx context.Context, event *events.TaskUpdatedEvent) error {
+	loggingtype.ForPackage("service").InfoContext(ctx, "Task updated event consumed", "event_id", event.EventID, "task_id", event.TaskID, "status", event.Status)
+	return nil
+}
+
+func LogTaskDeleted(ctx context.Context, event *events.TaskDeletedEvent) error {
+	loggingtype.ForPackage("service").InfoContext(ctx, "Task deleted event consumed", "event_id", event.EventID, "task_id", event.TaskID)
+	return nil
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"alle-task-manager-gunish/internal/common/eventbus"
 	"alle-task-manager-gunish/internal/common/events"
-	"alle-task-manager-gunish/internal/common/kafka"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
 	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"fmt"
 	"github.com/google/uuid"
 	"time"
 )
@@ -13,50 +16,147 @@ const (
 )
 
 type TaskEventPublisher interface {
-	PublishTaskCreated(task *model.Task) error
-	PublishTaskUpdated(task *model.Task) error
+	PublishTaskCreated(ctx context.Context, task *model.Task) error
+	PublishTaskUpdated(ctx context.Context, task *model.Task, changes *TaskChanges) error
+	PublishTaskDeleted(ctx context.Context, task *model.Task) error
 }
 
 type TaskEventService struct {
-	producer *kafka.Producer
+	publisher       eventbus.Publisher
+	topic           string
+	mode            events.Mode
+	validateSchemas bool
 }
 
 var _ TaskEventPublisher = (*TaskEventService)(nil)
 
-func NewTaskEventService(producer *kafka.Producer) *TaskEventService {
-	return &TaskEventService{
-		producer: producer,
+type TaskEventServiceOption func(*TaskEventService)
+
+func WithTopic(topic string) TaskEventServiceOption {
+	return func(s *TaskEventService) {
+		s.topic = topic
+	}
+}
+
+// WithContentMode : Selects structured or binary CloudEvents encoding for published events.
+func WithContentMode(mode events.Mode) TaskEventServiceOption {
+	return func(s *TaskEventService) {
+		s.mode = mode
 	}
 }
 
-func (s *TaskEventService) PublishTaskCreated(task *model.Task) error {
+// WithSchemaValidation : Rejects events whose payload does not match their dataschema before publishing.
+func WithSchemaValidation() TaskEventServiceOption {
+	return func(s *TaskEventService) {
+		s.validateSchemas = true
+	}
+}
+
+func NewTaskEventService(publisher eventbus.Publisher, options ...TaskEventServiceOption) *TaskEventService {
+	s := &TaskEventService{
+		publisher: publisher,
+		topic:     TopicTaskEvents,
+		mode:      events.ModeStructured,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (s *TaskEventService) PublishTaskCreated(ctx context.Context, task *model.Task) error {
 	event := &events.TaskCreatedEvent{
 		TaskEvent: events.TaskEvent{
-			EventID:   uuid.New().String(),
-			TaskID:    task.ID,
-			EventType: events.EventTypeTaskCreated,
-			Timestamp: time.Now(),
+			EventID:       uuid.New().String(),
+			TaskID:        task.ID,
+			EventType:     events.EventTypeTaskCreated,
+			Timestamp:     time.Now(),
+			CorrelationID: loggingtype.RequestID(ctx),
 		},
 		Title:       task.Title,
 		Description: task.Description,
 		Status:      string(task.Status),
 	}
 
-	return s.producer.PublishMessage(TopicTaskEvents, task.ID, event)
+	return s.publish(ctx, task.ID, event.TaskEvent, event)
 }
 
-func (s *TaskEventService) PublishTaskUpdated(task *model.Task) error {
+func (s *TaskEventService) PublishTaskUpdated(ctx context.Context, task *model.Task, changes *TaskChanges) error {
+	if changes == nil {
+		changes = &TaskChanges{Fields: []string{}, Previous: map[string]interface{}{}}
+	}
 	event := &events.TaskUpdatedEvent{
 		TaskEvent: events.TaskEvent{
-			EventID:   uuid.New().String(),
-			TaskID:    task.ID,
-			EventType: events.EventTypeTaskUpdated,
-			Timestamp: time.Now(),
+			EventID:       uuid.New().String(),
+			TaskID:        task.ID,
+			EventType:     events.EventTypeTaskUpdated,
+			Timestamp:     time.Now(),
+			CorrelationID: loggingtype.RequestID(ctx),
 		},
+		Title:         task.Title,
+		Description:   task.Description,
+		Status:        string(task.Status),
+		Task:          snapshotOf(task),
+		Previous:      changes.Previous,
+		ChangedFields: changes.Fields,
+	}
+
+	return s.publish(ctx, task.ID, event.TaskEvent, event)
+}
+
+func (s *TaskEventService) PublishTaskDeleted(ctx context.Context, task *model.Task) error {
+	event := &events.TaskDeletedEvent{
+		TaskEvent: events.TaskEvent{
+			EventID:       uuid.New().String(),
+			TaskID:        task.ID,
+			EventType:     events.EventTypeTaskDeleted,
+			Timestamp:     time.Now(),
+			CorrelationID: loggingtype.RequestID(ctx),
+		},
+		Task: snapshotOf(task),
+	}
+
+	return s.publish(ctx, task.ID, event.TaskEvent, event)
+}
+
+func snapshotOf(task *model.Task) events.TaskSnapshot {
+	return events.TaskSnapshot{
+		ID:          task.ID,
 		Title:       task.Title,
 		Description: task.Description,
 		Status:      string(task.Status),
+		DueDate:     task.DueDate,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+	}
+}
+
+// publish : ctx carries the trace of the request that changed the task, which the event bus propagates to
+// consumers. The request ID travels as the event's correlation ID, in the payload and in a header.
+func (s *TaskEventService) publish(ctx context.Context, key string, base events.TaskEvent, data interface{}) error {
+	cloudEvent, err := events.NewCloudEvent(base, data)
+	if err != nil {
+		return err
 	}
 
-	return s.producer.PublishMessage(TopicTaskEvents, task.ID, event)
+	if s.validateSchemas {
+		if err := events.ValidateData(cloudEvent.DataSchema, cloudEvent.Data); err != nil {
+			loggingtype.ForPackage("service").ErrorContext(ctx, "event payload does not match its schema", "event_type", cloudEvent.Type, "dataschema", cloudEvent.DataSchema, "error", err)
+			return fmt.Errorf("validating %s payload: %w", cloudEvent.Type, err)
+		}
+	}
+
+	headers, body, err := cloudEvent.Encode(s.mode)
+	if err != nil {
+		return err
+	}
+	if base.CorrelationID != "" {
+		headers[events.HeaderCorrelationID] = base.CorrelationID
+	}
+	return s.publisher.Publish(ctx, &eventbus.Message{
+		Topic:   s.topic,
+		Key:     key,
+		Headers: headers,
+		Value:   body,
+	})
 }
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"alle-task-manager-gunish/internal/common/events"
 	"alle-task-manager-gunish/internal/common/kafka"
+	"alle-task-manager-gunish/internal/common/logging"
 	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"encoding/json"
 	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -29,10 +34,26 @@ func (m *MockSyncProducer) Close() error {
 	return args.Error(0)
 }
 
+// validatedMessage : Decodes a produced message and checks its payload against the event's JSON Schema.
+func validatedMessage(t *testing.T, msg *sarama.ProducerMessage) *events.CloudEvent {
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	event, err := events.DecodeCloudEvent(headers, value)
+	require.NoError(t, err)
+	require.NotEmpty(t, event.DataSchema)
+	require.NoError(t, events.ValidateData(event.DataSchema, event.Data))
+	return event
+}
+
 func TestTaskEventService(t *testing.T) {
 	mockSyncProducer := new(MockSyncProducer)
 	producer := &kafka.Producer{Producer: mockSyncProducer}
-	service := NewTaskEventService(producer)
+	service := NewTaskEventService(kafka.NewBus(nil, producer), WithSchemaValidation())
 
 	t.Run("PublishTaskCreated", func(t *testing.T) {
 		task := &model.Task{
@@ -44,13 +65,18 @@ func TestTaskEventService(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		mockSyncProducer.On("SendMessage", mock.Anything).
-			Return(int32(0), int64(1), nil).Once()
+		var sent *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(1), nil).Once()
 
-		err := service.PublishTaskCreated(task)
+		err := service.PublishTaskCreated(context.Background(), task)
 		require.NoError(t, err)
 
 		mockSyncProducer.AssertExpectations(t)
+		event := validatedMessage(t, sent)
+		assert.Equal(t, events.EventTypeTaskCreated, event.Type)
+		assert.Equal(t, task.ID, event.Subject)
 	})
 
 	t.Run("PublishTaskUpdated", func(t *testing.T) {
@@ -63,13 +89,80 @@ func TestTaskEventService(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		mockSyncProducer.On("SendMessage", mock.Anything).
-			Return(int32(0), int64(0), nil)
+		var sent *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(0), nil).Once()
 
-		err := service.PublishTaskUpdated(task)
+		changes := &TaskChanges{
+			Fields:   []string{"title", "status"},
+			Previous: map[string]interface{}{"title": "Test Task", "status": "pending"},
+		}
+		err := service.PublishTaskUpdated(context.Background(), task, changes)
 		require.NoError(t, err)
 
 		mockSyncProducer.AssertExpectations(t)
+		event := validatedMessage(t, sent)
+		assert.Equal(t, events.EventTypeTaskUpdated, event.Type)
+		assert.Equal(t, "urn:alle-task-manager:events:task_updated:v2", event.DataSchema)
+
+		var payload events.TaskUpdatedEvent
+		require.NoError(t, json.Unmarshal(event.Data, &payload))
+		assert.Equal(t, task.ID, payload.Task.ID)
+		assert.Equal(t, "Updated Task", payload.Task.Title)
+		assert.Equal(t, "in_progress", payload.Task.Status)
+		assert.True(t, task.CreatedAt.Equal(payload.Task.CreatedAt))
+		assert.Equal(t, []string{"title", "status"}, payload.ChangedFields)
+		assert.Equal(t, map[string]interface{}{"title": "Test Task", "status": "pending"}, payload.Previous)
+	})
+
+	t.Run("PublishTaskUpdated in binary mode", func(t *testing.T) {
+		binaryService := NewTaskEventService(kafka.NewBus(nil, producer), WithContentMode(events.ModeBinary), WithSchemaValidation())
+		task := &model.Task{ID: "test-id", Title: "Binary Task", Status: model.Completed}
+
+		var sent *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(0), nil).Once()
+
+		err := binaryService.PublishTaskUpdated(context.Background(), task, nil)
+		require.NoError(t, err)
+
+		event := validatedMessage(t, sent)
+		assert.Equal(t, events.EventTypeTaskUpdated, event.Type)
+		assert.Equal(t, events.DataSchema(events.EventTypeTaskUpdated), event.DataSchema)
+	})
+
+	t.Run("rejects payloads that violate the schema", func(t *testing.T) {
+		task := &model.Task{ID: "test-id", Title: "", Status: model.Pending}
+
+		err := service.PublishTaskCreated(context.Background(), task)
+		require.Error(t, err)
+		mockSyncProducer.AssertNumberOfCalls(t, "SendMessage", 3)
+	})
+
+	t.Run("carries the request ID as correlation ID", func(t *testing.T) {
+		task := &model.Task{ID: "test-id", Title: "Correlated", Status: model.Pending}
+
+		var sent *sarama.ProducerMessage
+		mockSyncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(*sarama.ProducerMessage)
+		}).Return(int32(0), int64(0), nil).Once()
+
+		ctx := loggingtype.WithRequestID(context.Background(), "req-42")
+		require.NoError(t, service.PublishTaskDeleted(ctx, task))
+
+		event := validatedMessage(t, sent)
+		var payload events.TaskDeletedEvent
+		require.NoError(t, json.Unmarshal(event.Data, &payload))
+		assert.Equal(t, "req-42", payload.CorrelationID)
+		var header string
+		for _, h := range sent.Headers {
+			if string(h.Key) == events.HeaderCorrelationID {
+				header = string(h.Value)
+			}
+		}
+		assert.Equal(t, "req-42", header)
 	})
 
 }
@@ -0,0 +1,132 @@
+package service
+
+import (
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+type TaskProjectionService struct {
+	repo       repository.TaskRepository
+	writer     repository.TaskProjectionWriter
+	eventStore repository.TaskEventStore
+	transactor repository.Transactor
+}
+
+func NewTaskProjectionService(repo repository.TaskRepository, writer repository.TaskProjectionWriter, eventStore repository.TaskEventStore, transactor repository.Transactor) *TaskProjectionService {
+	return &TaskProjectionService{
+		repo:       repo,
+		writer:     writer,
+		eventStore: eventStore,
+		transactor: transactor,
+	}
+}
+
+// Rebuild : Replaces the tasks table with the state folded from the event store and returns the number of
+// live tasks written; deleted tasks are written as tombstones. Rows that have no events yet are first
+// recorded as TaskCreated snapshots, followed by a TaskDeleted event for soft-deleted ones, so they survive.
+func (s *TaskProjectionService) Rebuild(ctx context.Context) (int, error) {
+	rebuilt := 0
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		adopted, err := s.adoptUntracked(ctx)
+		if err != nil {
+			return err
+		}
+		if adopted > 0 {
+			loggingtype.ForPackage("service").InfoContext(ctx, "Recorded snapshots for tasks without events", "count", adopted)
+		}
+
+		if err := s.writer.ResetProjection(ctx); err != nil {
+			return err
+		}
+
+		var (
+			current string
+			task    *model.Task
+			last    *model.Task
+			deleted time.Time
+		)
+		flush := func() error {
+			if task == nil {
+				if last == nil {
+					return nil
+				}
+				// Keep the tombstone of a deleted task for delta sync clients.
+				tombstone := *last
+				tombstone.DeletedAt = gorm.DeletedAt{Time: deleted, Valid: true}
+				return s.writer.SaveProjection(ctx, &tombstone)
+			}
+			rebuilt++
+			return s.writer.SaveProjection(ctx, task)
+		}
+		err = s.eventStore.Stream(ctx, func(record *model.TaskEventRecord) error {
+			if record.AggregateID != current {
+				if err := flush(); err != nil {
+					return err
+				}
+				current, task, last = record.AggregateID, nil, nil
+			}
+			next, err := model.ApplyTaskEvent(task, record)
+			if err != nil {
+				return fmt.Errorf("folding event %d: %w", record.Sequence, err)
+			}
+			if task != nil {
+				last = task
+			}
+			if next == nil {
+				deleted = record.OccurredAt
+			}
+			task = next
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil {
+		return 0, err
+	}
+	loggingtype.ForPackage("service").InfoContext(ctx, "Task projections rebuilt", "tasks", rebuilt)
+	return rebuilt, nil
+}
+
+func (s *TaskProjectionService) adoptUntracked(ctx context.Context) (int, error) {
+	// Every row, soft-deleted ones included: the reset removes them all.
+	tasks, err := s.repo.ListChangedSince(ctx, -1, -1, true)
+	if err != nil {
+		return 0, err
+	}
+	adopted := 0
+	for _, task := range tasks {
+		version, err := s.eventStore.LastVersion(ctx, task.ID)
+		if err != nil {
+			return 0, err
+		}
+		if version > 0 {
+			continue
+		}
+		record, err := model.NewTaskCreatedRecord(task)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.eventStore.Append(ctx, record); err != nil {
+			return 0, err
+		}
+		if task.DeletedAt.Valid {
+			deleted, err := model.NewTaskDeletedRecord(task.ID, record.Version+1, task.DeletedAt.Time)
+			if err != nil {
+				return 0, err
+			}
+			if err := s.eventStore.Append(ctx, deleted); err != nil {
+				return 0, err
+			}
+		}
+		adopted++
+	}
+	return adopted, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newEventSourcedService(t *testing.T) (*TaskService, *TaskProjectionService, *repository.GormTaskRepository, *repository.GormTaskEventStore) {
+	db := newTestDatabase(t)
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+	store, err := repository.NewGormTaskEventStore(db.Db)
+	require.NoError(t, err)
+
+	publisher := new(MockTaskEventService)
+	publisher.On("PublishTaskCreated", mock.Anything, mock.Anything).Return(nil)
+	publisher.On("PublishTaskUpdated", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	publisher.On("PublishTaskDeleted", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewTaskService(repo, publisher, WithEventSourcing(store, db))
+	projections := NewTaskProjectionService(repo, repo, store, db)
+	return svc, projections, repo, store
+}
+
+func TestTaskService_EventSourcing(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, store := newEventSourcedService(t)
+
+	task, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Write report"})
+	require.NoError(t, err)
+	afterCreate := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	status := string(model.InProgress)
+	title := "Write quarterly report"
+	_, err = svc.UpdateTask(ctx, task.ID, UpdateTaskInput{Title: &title, Status: &status})
+	require.NoError(t, err)
+	afterUpdate := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	require.NoError(t, svc.DeleteTask(ctx, task.ID))
+
+	records, err := store.Load(ctx, task.ID, nil)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, model.TaskCreatedEventType, records[0].EventType)
+	assert.Equal(t, model.TaskUpdatedEventType, records[1].EventType)
+	assert.Equal(t, model.TaskDeletedEventType, records[2].EventType)
+	assert.Equal(t, 3, records[2].Version)
+
+	t.Run("as of creation", func(t *testing.T) {
+		past, err := svc.GetTaskAsOf(ctx, task.ID, afterCreate)
+		require.NoError(t, err)
+		assert.Equal(t, "Write report", past.Title)
+		assert.Equal(t, model.Pending, past.Status)
+	})
+
+	t.Run("as of update", func(t *testing.T) {
+		past, err := svc.GetTaskAsOf(ctx, task.ID, afterUpdate)
+		require.NoError(t, err)
+		assert.Equal(t, title, past.Title)
+		assert.Equal(t, model.InProgress, past.Status)
+	})
+
+	t.Run("after deletion", func(t *testing.T) {
+		_, err := svc.GetTaskAsOf(ctx, task.ID, time.Now())
+		assert.Equal(t, errors.ErrNotFound, err)
+	})
+
+	t.Run("before creation", func(t *testing.T) {
+		_, err := svc.GetTaskAsOf(ctx, task.ID, afterCreate.Add(-time.Hour))
+		assert.Equal(t, errors.ErrNotFound, err)
+	})
+}
+
+func TestTaskService_GetTaskAsOfRequiresEventSourcing(t *testing.T) {
+	svc := NewTaskService(new(MockTaskRepository), new(MockTaskEventService))
+
+	_, err := svc.GetTaskAsOf(context.Background(), "task-id", time.Now())
+	assert.Equal(t, errors.ErrEventSourcingDisabled, err)
+}
+
+func TestTaskService_EventSourcingVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	svc, _, repo, store := newEventSourcedService(t)
+
+	task, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Contended"})
+	require.NoError(t, err)
+
+	record, err := model.NewTaskCreatedRecord(task)
+	require.NoError(t, err)
+	assert.Equal(t, errors.ErrConflict, store.Append(ctx, record))
+
+	stored, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Contended", stored.Title)
+}
+
+func TestTaskProjectionService_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	svc, projections, repo, store := newEventSourcedService(t)
+
+	kept, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Kept"})
+	require.NoError(t, err)
+	completed := string(model.Completed)
+	_, err = svc.UpdateTask(ctx, kept.ID, UpdateTaskInput{Status: &completed})
+	require.NoError(t, err)
+
+	deleted, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Deleted"})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTask(ctx, deleted.ID))
+
+	// A row written before event sourcing was enabled has no events yet.
+	legacy := model.NewTask("Legacy", "")
+	require.NoError(t, repo.Create(ctx, legacy))
+	legacyDeleted := model.NewTask("Legacy, deleted", "")
+	require.NoError(t, repo.Create(ctx, legacyDeleted))
+	require.NoError(t, repo.Delete(ctx, legacyDeleted.ID))
+
+	// Drift the projection away from the event store.
+	drifted := *kept
+	drifted.Title = "Drifted"
+	require.NoError(t, repo.SaveProjection(ctx, &drifted))
+
+	count, err := projections.Rebuild(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	rebuilt, err := repo.GetByID(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Kept", rebuilt.Title)
+	assert.Equal(t, model.Completed, rebuilt.Status)
+
+	_, err = repo.GetByID(ctx, deleted.ID)
+	assert.Equal(t, errors.ErrNotFound, err)
+
+	_, err = repo.GetByID(ctx, legacy.ID)
+	require.NoError(t, err)
+	version, err := store.LastVersion(ctx, legacy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = repo.GetByID(ctx, legacyDeleted.ID)
+	assert.Equal(t, errors.ErrNotFound, err)
+	version, err = store.LastVersion(ctx, legacyDeleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	tombstones, err := repo.ListChangedSince(ctx, 0, 10, true)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(tombstones))
+	for _, task := range tombstones {
+		if task.DeletedAt.Valid {
+			ids = append(ids, task.ID)
+		}
+	}
+	assert.ElementsMatch(t, []string{deleted.ID, legacyDeleted.ID}, ids, "soft-deleted rows without events keep their tombstones")
+
+	title := "Legacy, updated"
+	_, err = svc.UpdateTask(ctx, legacy.ID, UpdateTaskInput{Title: &title})
+	require.NoError(t, err)
+	past, err := svc.GetTaskAsOf(ctx, legacy.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, title, past.Title)
+}
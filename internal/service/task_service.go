@@ -7,6 +7,8 @@ import (
 	"alle-task-manager-gunish/internal/domain/model"
 	"alle-task-manager-gunish/internal/domain/repository"
 	"context"
+	"fmt"
+	"gorm.io/gorm"
 	"strings"
 	"time"
 )
@@ -14,13 +16,74 @@ import (
 type TaskService struct {
 	repo           repository.TaskRepository
 	eventPublisher TaskEventPublisher
+	eventStore     repository.TaskEventStore
+	transactor     repository.Transactor
 }
 
-func NewTaskService(repo repository.TaskRepository, eventPublisher TaskEventPublisher) *TaskService {
-	return &TaskService{
+type TaskServiceOption func(*TaskService)
+
+// WithEventSourcing : Appends a domain event for every mutation. The event and the tasks table row are
+// written in one transaction, so the table stays a projection of the event store.
+func WithEventSourcing(eventStore repository.TaskEventStore, transactor repository.Transactor) TaskServiceOption {
+	return func(s *TaskService) {
+		s.eventStore = eventStore
+		s.transactor = transactor
+	}
+}
+
+func NewTaskService(repo repository.TaskRepository, eventPublisher TaskEventPublisher, options ...TaskServiceOption) *TaskService {
+	s := &TaskService{
 		repo:           repo,
 		eventPublisher: eventPublisher,
 	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// adoptTask : Records a TaskCreated snapshot for a task written before event sourcing was enabled, so its
+// later events have a starting state to fold onto. Returns the version the next event should use.
+func (s *TaskService) adoptTask(ctx context.Context, task *model.Task, version int) (int, error) {
+	if s.eventStore == nil || version != 1 {
+		return version, nil
+	}
+	if err := s.appendEvent(ctx, func() (*model.TaskEventRecord, error) {
+		return model.NewTaskCreatedRecord(task)
+	}); err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+// inTransaction : Runs fn in a transaction when event sourcing is enabled, and directly otherwise.
+func (s *TaskService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.eventStore == nil {
+		return fn(ctx)
+	}
+	return s.transactor.WithinTransaction(ctx, fn)
+}
+
+func (s *TaskService) appendEvent(ctx context.Context, build func() (*model.TaskEventRecord, error)) error {
+	if s.eventStore == nil {
+		return nil
+	}
+	record, err := build()
+	if err != nil {
+		return err
+	}
+	return s.eventStore.Append(ctx, record)
+}
+
+func (s *TaskService) nextVersion(ctx context.Context, id string) (int, error) {
+	if s.eventStore == nil {
+		return 0, nil
+	}
+	version, err := s.eventStore.LastVersion(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
 }
 
 type CreateTaskInput struct {
@@ -34,19 +97,67 @@ func (s *TaskService) CreateTask(ctx context.Context, input CreateTaskInput) (*m
 	if input.DueDate != nil {
 		task.DueDate = input.DueDate
 	}
-	if err := s.repo.Create(ctx, task); err != nil {
+	if err := s.create(ctx, task); err != nil {
 		return nil, err
 	}
-	if err := s.eventPublisher.PublishTaskCreated(task); err != nil {
-		loggingtype.GetLogger().Error("error publishing task event:", "error", err)
-	}
 	return task, nil
 }
 
+// ImportTask : Creates a task exported from another instance, keeping its ID, status and timestamps. Returns
+// errors.ErrDuplicateEntity when a task with the ID exists, including a deleted one.
+func (s *TaskService) ImportTask(ctx context.Context, task *model.Task) error {
+	if task.ID == "" || strings.TrimSpace(task.Title) == "" {
+		return fmt.Errorf("%w: id and title are required", errors.ErrValidation)
+	}
+	if task.Status != model.Pending && task.Status != model.InProgress && task.Status != model.Completed {
+		return errors.ErrInvalidStatus
+	}
+	imported := *task
+	imported.ChangeSeq, imported.DeletedAt = 0, gorm.DeletedAt{}
+	return s.create(ctx, &imported)
+}
+
+func (s *TaskService) create(ctx context.Context, task *model.Task) error {
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, task); err != nil {
+			return err
+		}
+		return s.appendEvent(ctx, func() (*model.TaskEventRecord, error) {
+			return model.NewTaskCreatedRecord(task)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.eventPublisher.PublishTaskCreated(ctx, task); err != nil {
+		loggingtype.ForPackage("service").ErrorContext(ctx, "error publishing task event:", "error", err)
+	}
+	return nil
+}
+
 func (s *TaskService) GetTask(ctx context.Context, id string) (*model.Task, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
+// GetTaskAsOf : Rebuilds a task from the events that occurred at or before asOf.
+func (s *TaskService) GetTaskAsOf(ctx context.Context, id string, asOf time.Time) (*model.Task, error) {
+	if s.eventStore == nil {
+		return nil, errors.ErrEventSourcingDisabled
+	}
+	records, err := s.eventStore.Load(ctx, id, &asOf)
+	if err != nil {
+		return nil, err
+	}
+	task, err := model.FoldTaskEvents(records)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, errors.ErrNotFound
+	}
+	return task, nil
+}
+
 type UpdateTaskInput struct {
 	Title       *string    `json:"title,omitempty"`
 	Description *string    `json:"description,omitempty"`
@@ -55,10 +166,44 @@ type UpdateTaskInput struct {
 }
 
 func (s *TaskService) UpdateTask(ctx context.Context, id string, input UpdateTaskInput) (*model.Task, error) {
-	task, err := s.repo.GetByID(ctx, id)
+	var (
+		task    *model.Task
+		changes *TaskChanges
+	)
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		version, err := s.nextVersion(ctx, id)
+		if err != nil {
+			return err
+		}
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		before := *current
+		if version, err = s.adoptTask(ctx, &before, version); err != nil {
+			return err
+		}
+		if err := applyUpdate(current, input); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, current); err != nil {
+			return err
+		}
+		task, changes = current, diffTask(&before, current)
+		return s.appendEvent(ctx, func() (*model.TaskEventRecord, error) {
+			return model.NewTaskUpdatedRecord(current, version, changes.Fields)
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
+	if err := s.eventPublisher.PublishTaskUpdated(ctx, task, changes); err != nil {
+		loggingtype.ForPackage("service").ErrorContext(ctx, "error publishing task event:", "error", err)
+	}
+	return task, nil
+}
+
+func applyUpdate(task *model.Task, input UpdateTaskInput) error {
 
 	if input.Title != nil {
 		task.Title = *input.Title
@@ -71,7 +216,7 @@ func (s *TaskService) UpdateTask(ctx context.Context, id string, input UpdateTas
 	if input.Status != nil {
 		status := model.TaskStatus(*input.Status)
 		if status != model.Pending && status != model.InProgress && status != model.Completed {
-			return nil, errors.ErrInvalidStatus
+			return errors.ErrInvalidStatus
 		}
 		task.Status = status
 	}
@@ -81,18 +226,47 @@ func (s *TaskService) UpdateTask(ctx context.Context, id string, input UpdateTas
 	}
 
 	task.UpdatedAt = time.Now()
+	return nil
+}
 
-	if err := s.repo.Update(ctx, task); err != nil {
-		return nil, err
+func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
+	var task *model.Task
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		version, err := s.nextVersion(ctx, id)
+		if err != nil {
+			return err
+		}
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version, err = s.adoptTask(ctx, current, version); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		task = current
+		return s.appendEvent(ctx, func() (*model.TaskEventRecord, error) {
+			return model.NewTaskDeletedRecord(id, version, time.Now())
+		})
+	})
+	if err != nil {
+		return err
 	}
-	if err := s.eventPublisher.PublishTaskUpdated(task); err != nil {
-		loggingtype.GetLogger().Error("error publishing task event:", "error", err)
+	if err := s.eventPublisher.PublishTaskDeleted(ctx, task); err != nil {
+		loggingtype.ForPackage("service").ErrorContext(ctx, "error publishing task event:", "error", err)
 	}
-	return task, nil
+	return nil
 }
 
-func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+// PurgeTrash : Permanently removes tasks deleted more than olderThan ago. Their events stay in the event store,
+// and delta sync clients whose token predates a purged deletion have to sync again from scratch.
+func (s *TaskService) PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan < 0 {
+		return 0, fmt.Errorf("%w: the retention must not be negative", errors.ErrValidation)
+	}
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-olderThan))
 }
 
 func (s *TaskService) ListTasks(ctx context.Context, status string, page *pagination.Page) ([]*model.Task, *pagination.PageInfo, error) {
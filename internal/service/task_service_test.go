@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 type MockTaskRepository struct {
@@ -42,17 +43,45 @@ func (m *MockTaskRepository) List(ctx context.Context, filter map[string]interfa
 	return args.Get(0).([]*model.Task), args.Int(1), args.Error(2)
 }
 
+func (m *MockTaskRepository) ListChangedSince(ctx context.Context, seq int64, limit int, includeDeleted bool) ([]*model.Task, error) {
+	args := m.Called(ctx, seq, limit, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(ctx, before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTaskRepository) PurgedThrough(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
 type MockTaskEventService struct {
 	mock.Mock
 }
 
-func (m *MockTaskEventService) PublishTaskCreated(task *model.Task) error {
-	args := m.Called(task)
+func (m *MockTaskEventService) PublishTaskCreated(ctx context.Context, task *model.Task) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockTaskEventService) PublishTaskUpdated(ctx context.Context, task *model.Task, changes *TaskChanges) error {
+	args := m.Called(ctx, task, changes)
 	return args.Error(0)
 }
 
-func (m *MockTaskEventService) PublishTaskUpdated(task *model.Task) error {
-	args := m.Called(task)
+func (m *MockTaskEventService) PublishTaskDeleted(ctx context.Context, task *model.Task) error {
+	args := m.Called(ctx, task)
 	return args.Error(0)
 }
 
@@ -69,7 +98,7 @@ func TestTaskService_CreateTask(t *testing.T) {
 		}
 
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*model.Task")).Return(nil).Once()
-		mockEventSvc.On("PublishTaskCreated", mock.AnythingOfType("*model.Task")).Return(nil).Once()
+		mockEventSvc.On("PublishTaskCreated", ctx, mock.AnythingOfType("*model.Task")).Return(nil).Once()
 
 		task, err := service.CreateTask(ctx, input)
 
@@ -124,7 +153,10 @@ func TestTaskService_UpdateTask(t *testing.T) {
 
 		mockRepo.On("GetByID", ctx, existingTask.ID).Return(existingTask, nil).Once()
 		mockRepo.On("Update", ctx, mock.AnythingOfType("*model.Task")).Return(nil).Once()
-		mockEventSvc.On("PublishTaskUpdated", mock.AnythingOfType("*model.Task")).Return(nil).Once()
+		var changes *TaskChanges
+		mockEventSvc.On("PublishTaskUpdated", ctx, mock.AnythingOfType("*model.Task"), mock.AnythingOfType("*service.TaskChanges")).
+			Run(func(args mock.Arguments) { changes = args.Get(2).(*TaskChanges) }).
+			Return(nil).Once()
 
 		updatedTask, err := service.UpdateTask(ctx, existingTask.ID, input)
 
@@ -132,6 +164,8 @@ func TestTaskService_UpdateTask(t *testing.T) {
 		assert.NotNil(t, updatedTask)
 		assert.Equal(t, newTitle, updatedTask.Title)
 		assert.Equal(t, model.TaskStatus(newStatus), updatedTask.Status)
+		assert.Equal(t, []string{"title", "status"}, changes.Fields)
+		assert.Equal(t, map[string]interface{}{"title": "Original Title", "status": "pending"}, changes.Previous)
 
 		mockRepo.AssertExpectations(t)
 		mockEventSvc.AssertExpectations(t)
@@ -195,17 +229,21 @@ func TestTaskService_DeleteTask(t *testing.T) {
 
 	t.Run("successful deletion", func(t *testing.T) {
 		taskID := "test-id"
+		task := &model.Task{ID: taskID, Title: "Test Task", Status: model.Pending}
+		mockRepo.On("GetByID", ctx, taskID).Return(task, nil).Once()
 		mockRepo.On("Delete", ctx, taskID).Return(nil).Once()
+		mockEventSvc.On("PublishTaskDeleted", ctx, task).Return(nil).Once()
 
 		err := service.DeleteTask(ctx, taskID)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
+		mockEventSvc.AssertExpectations(t)
 	})
 
 	t.Run("not found error", func(t *testing.T) {
 		taskID := "non-existent-id"
-		mockRepo.On("Delete", ctx, taskID).Return(errors.ErrNotFound).Once()
+		mockRepo.On("GetByID", ctx, taskID).Return(nil, errors.ErrNotFound).Once()
 
 		err := service.DeleteTask(ctx, taskID)
 
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TaskStreamEvent : A task event as pushed to live subscribers. ID is the CloudEvent ID, which clients
+// send back as Last-Event-ID to resume.
+type TaskStreamEvent struct {
+	ID     string
+	Type   string
+	TaskID string
+	// Statuses holds the task's status after the change and, for updates, the status it had before,
+	// so a status filter also sees tasks leaving that status.
+	Statuses []string
+	Data     json.RawMessage
+}
+
+// Task : Decodes the task from the event payload, with the fields an update changed. Update and delete events
+// carry a full snapshot; created events only carry the title, description and status, and the event time
+// as the creation time.
+func (e *TaskStreamEvent) Task() (task *model.Task, changedFields []string, err error) {
+	var payload struct {
+		events.TaskCreatedEvent
+		Task          *events.TaskSnapshot `json:"task"`
+		ChangedFields []string             `json:"changed_fields"`
+	}
+	if err := json.Unmarshal(e.Data, &payload); err != nil {
+		return nil, nil, fmt.Errorf("decoding task event data: %w", err)
+	}
+
+	if snapshot := payload.Task; snapshot != nil {
+		task = &model.Task{
+			ID:          snapshot.ID,
+			Title:       snapshot.Title,
+			Description: snapshot.Description,
+			Status:      model.TaskStatus(snapshot.Status),
+			DueDate:     snapshot.DueDate,
+			CreatedAt:   snapshot.CreatedAt,
+			UpdatedAt:   snapshot.UpdatedAt,
+		}
+	} else {
+		task = &model.Task{
+			ID:          e.TaskID,
+			Title:       payload.Title,
+			Description: payload.Description,
+			Status:      model.TaskStatus(payload.Status),
+			CreatedAt:   payload.Timestamp,
+			UpdatedAt:   payload.Timestamp,
+		}
+	}
+	return task, payload.ChangedFields, nil
+}
+
+type TaskStreamFilter struct {
+	Status string
+}
+
+func (f TaskStreamFilter) Matches(event *TaskStreamEvent) bool {
+	if f.Status == "" {
+		return true
+	}
+	for _, status := range event.Statuses {
+		if strings.EqualFold(status, f.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+type TaskStreamSubscription struct {
+	events chan *TaskStreamEvent
+	filter TaskStreamFilter
+}
+
+// Events : Closed when the subscription ends, including when the subscriber fell too far behind.
+func (s *TaskStreamSubscription) Events() <-chan *TaskStreamEvent {
+	return s.events
+}
+
+// TaskStreamBroadcaster : Fans task events out to live subscribers and keeps the most recent ones in a
+// bounded buffer so reconnecting clients can catch up.
+type TaskStreamBroadcaster struct {
+	mu               sync.Mutex
+	buffer           []*TaskStreamEvent
+	next             int
+	full             bool
+	subscribers      map[*TaskStreamSubscription]struct{}
+	subscriberBuffer int
+}
+
+var _ eventbus.Publisher = (*TaskStreamBroadcaster)(nil)
+
+func NewTaskStreamBroadcaster(replayBufferSize, subscriberBuffer int) *TaskStreamBroadcaster {
+	if replayBufferSize < 1 {
+		replayBufferSize = 1
+	}
+	return &TaskStreamBroadcaster{
+		buffer:           make([]*TaskStreamEvent, replayBufferSize),
+		subscribers:      make(map[*TaskStreamSubscription]struct{}),
+		subscriberBuffer: subscriberBuffer,
+	}
+}
+
+// Subscribe : Registers a subscriber and returns the buffered events it missed after lastEventID. resumed is
+// false when lastEventID is no longer buffered, in which case the client has to reload its state.
+func (b *TaskStreamBroadcaster) Subscribe(lastEventID string, filter TaskStreamFilter) (subscription *TaskStreamSubscription, missed []*TaskStreamEvent, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	resumed = lastEventID == ""
+	if lastEventID != "" {
+		buffered := b.buffered()
+		for i, event := range buffered {
+			if event.ID != lastEventID {
+				continue
+			}
+			resumed = true
+			for _, event := range buffered[i+1:] {
+				if filter.Matches(event) {
+					missed = append(missed, event)
+				}
+			}
+			break
+		}
+	}
+
+	subscription = &TaskStreamSubscription{
+		events: make(chan *TaskStreamEvent, b.subscriberBuffer),
+		filter: filter,
+	}
+	b.subscribers[subscription] = struct{}{}
+	return subscription, missed, resumed
+}
+
+func (b *TaskStreamBroadcaster) Unsubscribe(subscription *TaskStreamSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(subscription)
+}
+
+// Publish : Decodes a task event message and broadcasts it. It is used both as the event bus handler and,
+// without a bus, directly as the task event publisher.
+func (b *TaskStreamBroadcaster) Publish(_ context.Context, msg *eventbus.Message) error {
+	cloudEvent, err := events.DecodeCloudEvent(msg.Headers, msg.Value)
+	if err != nil {
+		return eventbus.Permanent(fmt.Errorf("decoding task event: %w", err))
+	}
+	// SSE data lines can't contain newlines.
+	var data bytes.Buffer
+	if err := json.Compact(&data, cloudEvent.Data); err != nil {
+		return eventbus.Permanent(fmt.Errorf("decoding task event data: %w", err))
+	}
+	b.Broadcast(&TaskStreamEvent{
+		ID:       cloudEvent.ID,
+		Type:     cloudEvent.Type,
+		TaskID:   cloudEvent.Subject,
+		Statuses: statusesOf(cloudEvent.Data),
+		Data:     data.Bytes(),
+	})
+	return nil
+}
+
+// Broadcast : Buffers the event and hands it to every matching subscriber. Subscribers whose queue is full
+// are dropped rather than blocking everyone else; they can reconnect with Last-Event-ID.
+func (b *TaskStreamBroadcaster) Broadcast(event *TaskStreamEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buffer[b.next] = event
+	b.next = (b.next + 1) % len(b.buffer)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for subscription := range b.subscribers {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			loggingtype.ForPackage("service").Warn("Dropping slow task stream subscriber", "event_id", event.ID)
+			b.remove(subscription)
+		}
+	}
+}
+
+func (b *TaskStreamBroadcaster) remove(subscription *TaskStreamSubscription) {
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+}
+
+// buffered : The buffered events, oldest first. Callers must hold mu.
+func (b *TaskStreamBroadcaster) buffered() []*TaskStreamEvent {
+	if !b.full {
+		return append([]*TaskStreamEvent(nil), b.buffer[:b.next]...)
+	}
+	return append(append([]*TaskStreamEvent(nil), b.buffer[b.next:]...), b.buffer[:b.next]...)
+}
+
+func statusesOf(data []byte) []string {
+	var payload struct {
+		Status string `json:"status"`
+		Task   struct {
+			Status string `json:"status"`
+		} `json:"task"`
+		Previous map[string]interface{} `json:"previous"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+
+	var statuses []string
+	add := func(status string) {
+		if status == "" {
+			return
+		}
+		for _, existing := range statuses {
+			if existing == status {
+				return
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	add(payload.Task.Status)
+	add(payload.Status)
+	if previous, ok := payload.Previous["status"].(string); ok {
+		add(previous)
+	}
+	return statuses
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func streamEvent(id, status string) *TaskStreamEvent {
+	return &TaskStreamEvent{ID: id, Type: events.EventTypeTaskUpdated, TaskID: "task-" + id, Statuses: []string{status}, Data: []byte(`{}`)}
+}
+
+func eventIDs(events []*TaskStreamEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestTaskStreamBroadcaster_Resume(t *testing.T) {
+	broadcaster := NewTaskStreamBroadcaster(3, 8)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		broadcaster.Broadcast(streamEvent(id, "pending"))
+	}
+
+	t.Run("replays events after the last seen one", func(t *testing.T) {
+		subscription, missed, resumed := broadcaster.Subscribe("2", TaskStreamFilter{})
+		defer broadcaster.Unsubscribe(subscription)
+		assert.True(t, resumed)
+		assert.Equal(t, []string{"3", "4"}, eventIDs(missed))
+	})
+
+	t.Run("reports events that fell out of the buffer", func(t *testing.T) {
+		subscription, missed, resumed := broadcaster.Subscribe("1", TaskStreamFilter{})
+		defer broadcaster.Unsubscribe(subscription)
+		assert.False(t, resumed)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("new subscribers start live", func(t *testing.T) {
+		subscription, missed, resumed := broadcaster.Subscribe("", TaskStreamFilter{})
+		defer broadcaster.Unsubscribe(subscription)
+		assert.True(t, resumed)
+		assert.Empty(t, missed)
+	})
+}
+
+func TestTaskStreamBroadcaster_Filter(t *testing.T) {
+	broadcaster := NewTaskStreamBroadcaster(10, 8)
+	subscription, _, _ := broadcaster.Subscribe("", TaskStreamFilter{Status: "completed"})
+	defer broadcaster.Unsubscribe(subscription)
+
+	broadcaster.Broadcast(streamEvent("1", "pending"))
+	broadcaster.Broadcast(&TaskStreamEvent{ID: "2", Statuses: []string{"pending", "completed"}})
+	broadcaster.Broadcast(streamEvent("3", "completed"))
+
+	assert.Equal(t, "2", (<-subscription.Events()).ID)
+	assert.Equal(t, "3", (<-subscription.Events()).ID)
+	assert.Empty(t, subscription.Events())
+}
+
+func TestTaskStreamBroadcaster_DropsSlowSubscribers(t *testing.T) {
+	broadcaster := NewTaskStreamBroadcaster(10, 1)
+	slow, _, _ := broadcaster.Subscribe("", TaskStreamFilter{})
+
+	broadcaster.Broadcast(streamEvent("1", "pending"))
+	broadcaster.Broadcast(streamEvent("2", "pending"))
+
+	event, ok := <-slow.Events()
+	require.True(t, ok)
+	assert.Equal(t, "1", event.ID)
+	_, ok = <-slow.Events()
+	assert.False(t, ok, "slow subscriber should have been closed")
+
+	broadcaster.Unsubscribe(slow)
+}
+
+func TestTaskStreamBroadcaster_PublishedEvents(t *testing.T) {
+	broadcaster := NewTaskStreamBroadcaster(10, 8)
+	subscription, _, _ := broadcaster.Subscribe("", TaskStreamFilter{Status: "pending"})
+	defer broadcaster.Unsubscribe(subscription)
+
+	publisher := NewTaskEventService(broadcaster, WithContentMode(events.ModeBinary))
+	task := &model.Task{ID: "task-1", Title: "Stream me", Status: model.InProgress, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, publisher.PublishTaskUpdated(context.Background(), task, &TaskChanges{
+		Fields:   []string{"status"},
+		Previous: map[string]interface{}{"status": "pending"},
+	}))
+	require.NoError(t, publisher.PublishTaskDeleted(context.Background(), task))
+
+	updated := <-subscription.Events()
+	assert.Equal(t, events.EventTypeTaskUpdated, updated.Type)
+	assert.Equal(t, "task-1", updated.TaskID)
+	assert.ElementsMatch(t, []string{"in_progress", "pending"}, updated.Statuses)
+	assert.Empty(t, subscription.Events(), "the delete of an in_progress task doesn't match the pending filter")
+
+	err := broadcaster.Publish(context.Background(), &eventbus.Message{Value: []byte("not json")})
+	assert.True(t, eventbus.IsPermanent(err))
+}
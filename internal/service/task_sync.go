@@ -0,0 +1,96 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/domain/model"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultChangesLimit = 100
+	MaxChangesLimit     = 1000
+
+	syncTokenPrefix = "v1:"
+)
+
+// TaskChange : One entry of a delta sync. Deleted tasks are tombstones that only carry their ID and deletion time.
+type TaskChange struct {
+	ID        string      `json:"id"`
+	Deleted   bool        `json:"deleted"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
+	Task      *model.Task `json:"task,omitempty"`
+}
+
+type TaskChangeSet struct {
+	Changes   []TaskChange `json:"changes"`
+	NextToken string       `json:"next_token"`
+	HasMore   bool         `json:"has_more"`
+}
+
+// EncodeSyncToken : Sync tokens are opaque to clients; they wrap a task change sequence value.
+func EncodeSyncToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+func DecodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil && strings.HasPrefix(string(raw), syncTokenPrefix) {
+		seq, err := strconv.ParseInt(strings.TrimPrefix(string(raw), syncTokenPrefix), 10, 64)
+		if err == nil && seq >= 0 {
+			return seq, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid sync token", apperrors.ErrValidation)
+}
+
+// ListChanges : Returns the tasks written after the sync token, oldest change first, and the token to pass
+// next time. Without a token it returns every live task, since a fresh client has nothing to delete. Tokens
+// older than a purged tombstone are rejected, since the client would never learn about that deletion.
+func (s *TaskService) ListChanges(ctx context.Context, token string, limit int) (*TaskChangeSet, error) {
+	since, err := DecodeSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		purged, err := s.repo.PurgedThrough(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if since < purged {
+			return nil, fmt.Errorf("%w: sync token expired, deleted tasks it has not seen were purged; sync again without a token", apperrors.ErrValidation)
+		}
+	}
+	if limit < 1 || limit > MaxChangesLimit {
+		limit = DefaultChangesLimit
+	}
+
+	tasks, err := s.repo.ListChangedSince(ctx, since, limit+1, token != "")
+	if err != nil {
+		return nil, err
+	}
+
+	changeSet := &TaskChangeSet{Changes: []TaskChange{}, NextToken: EncodeSyncToken(since)}
+	if len(tasks) > limit {
+		tasks, changeSet.HasMore = tasks[:limit], true
+	}
+	for _, task := range tasks {
+		change := TaskChange{ID: task.ID}
+		if task.DeletedAt.Valid {
+			deletedAt := task.DeletedAt.Time
+			change.Deleted, change.DeletedAt = true, &deletedAt
+		} else {
+			change.Task = task
+		}
+		changeSet.Changes = append(changeSet.Changes, change)
+		changeSet.NextToken = EncodeSyncToken(task.ChangeSeq)
+	}
+	return changeSet, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func changeIDs(changeSet *TaskChangeSet) []string {
+	ids := make([]string, len(changeSet.Changes))
+	for i, change := range changeSet.Changes {
+		ids[i] = change.ID
+	}
+	return ids
+}
+
+func TestTaskService_ListChanges(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewGormTaskRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	publisher := new(MockTaskEventService)
+	publisher.On("PublishTaskCreated", mock.Anything, mock.Anything).Return(nil)
+	publisher.On("PublishTaskUpdated", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	publisher.On("PublishTaskDeleted", mock.Anything, mock.Anything).Return(nil)
+	svc := NewTaskService(repo, publisher)
+
+	first, err := svc.CreateTask(ctx, CreateTaskInput{Title: "First"})
+	require.NoError(t, err)
+	second, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Second"})
+	require.NoError(t, err)
+	third, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Third"})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTask(ctx, third.ID))
+
+	initial, err := svc.ListChanges(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID, second.ID}, changeIDs(initial), "a fresh sync has no tombstones")
+	assert.False(t, initial.HasMore)
+
+	title := "First, edited"
+	_, err = svc.UpdateTask(ctx, first.ID, UpdateTaskInput{Title: &title})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTask(ctx, second.ID))
+	fourth, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Fourth"})
+	require.NoError(t, err)
+
+	page, err := svc.ListChanges(ctx, initial.NextToken, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{third.ID, first.ID}, changeIDs(page), "the token predates the third task's deletion")
+	assert.True(t, page.HasMore)
+	assert.True(t, page.Changes[0].Deleted)
+	assert.NotNil(t, page.Changes[0].DeletedAt)
+	assert.Nil(t, page.Changes[0].Task)
+	assert.Equal(t, title, page.Changes[1].Task.Title)
+
+	rest, err := svc.ListChanges(ctx, page.NextToken, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.ID, fourth.ID}, changeIDs(rest))
+	assert.True(t, rest.Changes[0].Deleted)
+	assert.False(t, rest.HasMore)
+
+	empty, err := svc.ListChanges(ctx, rest.NextToken, 2)
+	require.NoError(t, err)
+	assert.Empty(t, empty.Changes)
+	assert.Equal(t, rest.NextToken, empty.NextToken)
+
+	_, err = svc.ListChanges(ctx, "not-a-token", 2)
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+
+	_, err = repo.GetByID(ctx, second.ID)
+	assert.Equal(t, apperrors.ErrNotFound, err)
+	assert.Equal(t, apperrors.ErrNotFound, svc.DeleteTask(ctx, second.ID))
+}
+
+func TestTaskService_PurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewGormTaskRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	publisher := new(MockTaskEventService)
+	publisher.On("PublishTaskCreated", mock.Anything, mock.Anything).Return(nil)
+	publisher.On("PublishTaskDeleted", mock.Anything, mock.Anything).Return(nil)
+	svc := NewTaskService(repo, publisher)
+
+	kept, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Kept"})
+	require.NoError(t, err)
+	trashed, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Trashed"})
+	require.NoError(t, err)
+	stale, err := svc.ListChanges(ctx, "", 10)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTask(ctx, trashed.ID))
+	current, err := svc.ListChanges(ctx, stale.NextToken, 10)
+	require.NoError(t, err)
+
+	purged, err := svc.PurgeTrash(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "the deletion is newer than the retention")
+
+	purged, err = svc.PurgeTrash(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	all, err := repo.ListChangedSince(ctx, 0, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept.ID}, []string{all[0].ID})
+	assert.Len(t, all, 1)
+
+	_, err = svc.ListChanges(ctx, stale.NextToken, 10)
+	assert.ErrorIs(t, err, apperrors.ErrValidation, "the token predates the purged deletion")
+	_, err = svc.ListChanges(ctx, current.NextToken, 10)
+	assert.NoError(t, err)
+	fresh, err := svc.ListChanges(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept.ID}, changeIDs(fresh))
+
+	_, err = svc.PurgeTrash(ctx, -time.Hour)
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+}
+
+func TestTaskService_ImportTask(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, store := newEventSourcedService(t)
+
+	createdAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	task := &model.Task{ID: "imported-1", Title: "Imported", Status: model.Completed, CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)}
+	require.NoError(t, svc.ImportTask(ctx, task))
+
+	got, err := svc.GetTask(ctx, "imported-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.Completed, got.Status)
+	assert.True(t, createdAt.Equal(got.CreatedAt))
+	assert.True(t, createdAt.Add(time.Hour).Equal(got.UpdatedAt))
+	version, err := store.LastVersion(ctx, "imported-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, version, "the import is recorded as the task's creation")
+
+	assert.ErrorIs(t, svc.ImportTask(ctx, task), apperrors.ErrDuplicateEntity)
+	assert.ErrorIs(t, svc.ImportTask(ctx, &model.Task{ID: "imported-2", Title: "Bad", Status: "done"}), apperrors.ErrInvalidStatus)
+	assert.ErrorIs(t, svc.ImportTask(ctx, &model.Task{ID: "imported-3", Status: model.Pending}), apperrors.ErrValidation)
+}
+
+func TestTaskProjectionService_RebuildKeepsTombstones(t *testing.T) {
+	ctx := context.Background()
+	svc, projections, _, _ := newEventSourcedService(t)
+
+	kept, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Kept"})
+	require.NoError(t, err)
+	deleted, err := svc.CreateTask(ctx, CreateTaskInput{Title: "Deleted"})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTask(ctx, deleted.ID))
+	before, err := svc.ListChanges(ctx, "", 10)
+	require.NoError(t, err)
+
+	_, err = projections.Rebuild(ctx)
+	require.NoError(t, err)
+
+	after, err := svc.ListChanges(ctx, before.NextToken, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{kept.ID, deleted.ID}, changeIDs(after))
+	for _, change := range after.Changes {
+		assert.Equal(t, change.ID == deleted.ID, change.Deleted)
+	}
+}
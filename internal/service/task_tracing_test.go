@@ -0,0 +1,82 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/kafka"
+	"alle-task-manager-gunish/internal/common/tracing"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q", name)
+	return tracetest.SpanStub{}
+}
+
+func TestTracing_FromRequestThroughKafkaToConsumer(t *testing.T) {
+	ctx := context.Background()
+	provider, err := tracing.Setup(ctx, config.TracingConfig{Exporter: tracing.ExporterMemory, ServiceName: "task-manager-test", SampleRatio: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Shutdown(ctx) })
+
+	var sent *sarama.ProducerMessage
+	syncProducer := new(MockSyncProducer)
+	syncProducer.On("SendMessage", mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(0).(*sarama.ProducerMessage)
+	}).Return(int32(0), int64(7), nil)
+	bus := kafka.NewBus(nil, &kafka.Producer{Producer: syncProducer})
+
+	repo, err := repository.NewGormTaskRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	svc := NewTaskService(repo, NewTaskEventService(bus))
+
+	requestCtx, request := tracing.Tracer().Start(ctx, "PATCH /tasks/:id")
+	_, err = svc.CreateTask(requestCtx, CreateTaskInput{Title: "Traced"})
+	require.NoError(t, err)
+	request.End()
+	traceID := request.SpanContext().TraceID()
+
+	insert := findSpan(t, provider.Spans(), "create tasks")
+	assert.Equal(t, request.SpanContext().SpanID(), insert.Parent.SpanID(), "statements are children of the request")
+	assert.Equal(t, trace.SpanKindClient, insert.SpanKind)
+
+	send := findSpan(t, provider.Spans(), "task-events send")
+	assert.Equal(t, traceID, send.SpanContext.TraceID())
+	assert.Equal(t, trace.SpanKindProducer, send.SpanKind)
+	require.NotNil(t, sent)
+
+	headers := make([]*sarama.RecordHeader, len(sent.Headers))
+	for i := range sent.Headers {
+		headers[i] = &sent.Headers[i]
+	}
+	value, err := sent.Value.Encode()
+	require.NoError(t, err)
+	message := &sarama.ConsumerMessage{Topic: sent.Topic, Headers: headers, Value: value}
+
+	var handledIn trace.SpanContext
+	handler := kafka.NewConsumerGroupHandler(func(ctx context.Context, _ *sarama.ConsumerMessage) error {
+		handledIn = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+	session := &MockConsumerGroupSession{ctx: ctx}
+	session.On("MarkMessage", message, "").Once()
+	require.NoError(t, handler.ConsumeClaim(session, newMockClaim(message)))
+
+	process := findSpan(t, provider.Spans(), "task-events process")
+	assert.Equal(t, traceID, process.SpanContext.TraceID(), "the consumer continues the trace of the request")
+	assert.Equal(t, send.SpanContext.SpanID(), process.Parent.SpanID())
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind)
+	assert.Equal(t, process.SpanContext.SpanID(), handledIn.SpanID(), "handlers run inside the consumer span")
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	loggingtype "alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderWebhookSignature = "X-Signature"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookEventID   = "X-Event-ID"
+	HeaderWebhookEventType = "X-Event-Type"
+	HeaderWebhookAttempt   = "X-Delivery-Attempt"
+
+	maxWebhookErrorLength = 512
+)
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// WebhookDispatcher : Delivers consumed task events to webhook subscriptions. The consumer handler records
+// pending deliveries in the webhook outbox as part of the event's transaction, and a pool of workers delivers
+// them from there, so a slow endpoint doesn't hold up consumption and a restart doesn't lose deliveries.
+type WebhookDispatcher struct {
+	repo         repository.WebhookRepository
+	client       *http.Client
+	policy       eventbus.RetryPolicy
+	maxFailures  int
+	workers      int
+	pollInterval time.Duration
+	wake         chan struct{}
+}
+
+type WebhookDispatcherOption func(*WebhookDispatcher)
+
+// WithWebhookHTTPClient : The client used for deliveries. Its timeout also bounds how long a claimed
+// delivery is held before another worker may retry it.
+func WithWebhookHTTPClient(client *http.Client) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.client = client
+	}
+}
+
+func WithWebhookRetryPolicy(policy eventbus.RetryPolicy) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.policy = policy
+	}
+}
+
+// WithMaxConsecutiveFailures : Deactivates a subscription after this many events in a row could not be
+// delivered. Zero keeps failing subscriptions active.
+func WithMaxConsecutiveFailures(maxFailures int) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.maxFailures = maxFailures
+	}
+}
+
+func WithWebhookWorkers(workers int) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		if workers > 0 {
+			d.workers = workers
+		}
+	}
+}
+
+// WithWebhookPollInterval : How often idle workers look for due deliveries, which includes retries.
+// New events are picked up straight away.
+func WithWebhookPollInterval(interval time.Duration) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		if interval > 0 {
+			d.pollInterval = interval
+		}
+	}
+}
+
+func NewWebhookDispatcher(repo repository.WebhookRepository, options ...WebhookDispatcherOption) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		repo:         repo,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		policy:       eventbus.DefaultRetryPolicy(),
+		maxFailures:  10,
+		workers:      4,
+		pollInterval: time.Second,
+		wake:         make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// SignWebhookPayload : The X-Signature value for a payload sent at timestamp (unix seconds), "sha256="
+// followed by the hex HMAC-SHA256 of "<timestamp>.<body>". Signing the timestamp lets receivers reject
+// replayed requests.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature : Checks the X-Signature and X-Webhook-Timestamp headers of a delivery, rejecting
+// requests signed more than tolerance away from now.
+func VerifyWebhookSignature(secret, signature, timestamp string, payload []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp %q", ErrInvalidWebhookSignature, timestamp)
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > tolerance || skew < -tolerance {
+		return fmt.Errorf("%w: timestamp is outside the %s tolerance", ErrInvalidWebhookSignature, tolerance)
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignWebhookPayload(secret, seconds, payload))) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidWebhookSignature)
+	}
+	return nil
+}
+
+// Handler : Returns a consumer handler that adds a pending delivery to the outbox for every active
+// subscription that wants the event. It runs in the consumer's transaction, so the deliveries are stored
+// exactly when the event is marked processed.
+func (d *WebhookDispatcher) Handler(eventType string) TaskEventHandler {
+	return func(ctx context.Context, payload []byte) error {
+		var event events.TaskEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return eventbus.Permanent(err)
+		}
+
+		subscriptions, err := d.repo.ListActiveSubscriptions(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var entries []*model.WebhookOutboxEntry
+		for _, subscription := range subscriptions {
+			if !subscription.Matches(eventType) {
+				continue
+			}
+			entries = append(entries, &model.WebhookOutboxEntry{
+				ID:             uuid.New().String(),
+				SubscriptionID: subscription.ID,
+				EventID:        event.EventID,
+				EventType:      eventType,
+				Payload:        payload,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := d.repo.EnqueueOutboxEntries(ctx, entries); err != nil {
+			return err
+		}
+
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+}
+
+// Run : Delivers due outbox entries until ctx is cancelled and the workers have stopped. Entries are only
+// claimed for idle workers, so a claim never outlives its lease waiting in a queue.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	logger := loggingtype.ForPackage("service")
+	jobs := make(chan *model.WebhookOutboxEntry)
+	finished := make(chan struct{}, d.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				d.deliver(ctx, entry)
+				finished <- struct{}{}
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	idle := d.workers
+	for {
+		if idle > 0 {
+			entries, err := d.repo.ClaimOutboxEntries(ctx, time.Now(), d.lease(), idle)
+			if err != nil && ctx.Err() == nil {
+				logger.ErrorContext(ctx, "Failed to claim webhook deliveries", "error", err)
+			}
+			for _, entry := range entries {
+				jobs <- entry
+				idle--
+			}
+			if err == nil && len(entries) > 0 && idle > 0 {
+				// The batch filled every free worker it could; more may be due.
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-finished:
+			idle++
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// BacklogCheck : A readiness check that fails when more than maxBacklog deliveries have been due for longer
+// than maxDelay, which means the workers aren't keeping up or can't reach the database. Deliveries being
+// attempted are not counted.
+func (d *WebhookDispatcher) BacklogCheck(maxBacklog int, maxDelay time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		overdue, err := d.repo.CountOverdueOutboxEntries(ctx, time.Now().Add(-maxDelay))
+		if err != nil {
+			return err
+		}
+		if overdue > int64(maxBacklog) {
+			return fmt.Errorf("%d webhook deliveries have been due for more than %s, limit is %d", overdue, maxDelay, maxBacklog)
+		}
+		return nil
+	}
+}
+
+// lease : How long a claimed entry is hidden from other workers, enough for one attempt to time out.
+func (d *WebhookDispatcher) lease() time.Duration {
+	return d.client.Timeout + time.Minute
+}
+
+// deliver : Makes the next attempt at an outbox entry. Failures are rescheduled with exponential backoff;
+// client errors other than 408 and 429 are not retried since resending the same request won't change the
+// answer. An attempt interrupted by shutdown is not recorded and is retried once its lease expires.
+func (d *WebhookDispatcher) deliver(ctx context.Context, entry *model.WebhookOutboxEntry) {
+	logger := loggingtype.ForPackage("service")
+	store := context.WithoutCancel(ctx)
+
+	subscription, err := d.repo.GetSubscription(ctx, entry.SubscriptionID)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		logger.ErrorContext(ctx, "Failed to load webhook subscription", "subscription_id", entry.SubscriptionID, "error", err)
+		return
+	}
+	if subscription == nil || !subscription.Active {
+		d.discard(store, entry)
+		return
+	}
+
+	attempt := entry.Attempts + 1
+	delivery := d.attempt(ctx, subscription, entry, attempt)
+	if !delivery.Success && ctx.Err() != nil {
+		return
+	}
+	if err := d.repo.RecordDelivery(store, delivery); err != nil {
+		logger.ErrorContext(ctx, "Failed to record webhook delivery", "subscription_id", subscription.ID, "error", err)
+	}
+	if delivery.Success {
+		d.discard(store, entry)
+		if err := d.repo.MarkDeliverySucceeded(store, subscription.ID); err != nil {
+			logger.ErrorContext(ctx, "Failed to reset webhook failures", "subscription_id", subscription.ID, "error", err)
+		}
+		return
+	}
+
+	if attempt <= d.policy.MaxRetries && retryableStatus(delivery.StatusCode) {
+		next := time.Now().Add(d.policy.Backoff(attempt - 1))
+		if err := d.repo.RescheduleOutboxEntry(store, entry.ID, attempt, next); err != nil {
+			logger.ErrorContext(ctx, "Failed to reschedule webhook delivery", "subscription_id", subscription.ID, "error", err)
+		}
+		return
+	}
+
+	logger.WarnContext(ctx, "Giving up on webhook delivery", "subscription_id", subscription.ID, "event_id", entry.EventID)
+	d.discard(store, entry)
+	disabled, err := d.repo.MarkDeliveryFailed(store, subscription.ID, d.maxFailures)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record webhook failure", "subscription_id", subscription.ID, "error", err)
+		return
+	}
+	if disabled {
+		logger.WarnContext(ctx, "Webhook subscription disabled after repeated failures", "subscription_id", subscription.ID, "max_failures", d.maxFailures)
+	}
+}
+
+func (d *WebhookDispatcher) discard(ctx context.Context, entry *model.WebhookOutboxEntry) {
+	if err := d.repo.DeleteOutboxEntry(ctx, entry.ID); err != nil {
+		loggingtype.ForPackage("service").ErrorContext(ctx, "Failed to remove webhook delivery from the outbox", "subscription_id", entry.SubscriptionID, "error", err)
+	}
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, subscription *model.WebhookSubscription, entry *model.WebhookOutboxEntry, attempt int) *model.WebhookDelivery {
+	delivery := &model.WebhookDelivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscription.ID,
+		EventID:        entry.EventID,
+		EventType:      entry.EventType,
+		Attempt:        attempt,
+		CreatedAt:      time.Now(),
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(entry.Payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderWebhookSignature, SignWebhookPayload(subscription.Secret, timestamp, entry.Payload))
+	request.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderWebhookID, subscription.ID)
+	request.Header.Set(HeaderWebhookEventID, entry.EventID)
+	request.Header.Set(HeaderWebhookEventType, entry.EventType)
+	request.Header.Set(HeaderWebhookAttempt, strconv.Itoa(attempt))
+
+	start := time.Now()
+	resp, err := d.client.Do(request)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = truncate(err.Error(), maxWebhookErrorLength)
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorLength))
+		delivery.Error = truncate(fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, body), maxWebhookErrorLength)
+	}
+	return delivery
+}
+
+// retryableStatus : Zero means the request never got a response.
+func retryableStatus(status int) bool {
+	return status == 0 || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"alle-task-manager-gunish/internal/common/eventbus"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newWebhookTestDispatcher(t *testing.T, options ...WebhookDispatcherOption) (*WebhookDispatcher, *repository.GormWebhookRepository) {
+	repo, err := repository.NewGormWebhookRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+
+	options = append([]WebhookDispatcherOption{
+		WithWebhookRetryPolicy(eventbus.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}),
+		WithWebhookPollInterval(5 * time.Millisecond),
+	}, options...)
+	dispatcher := NewWebhookDispatcher(repo, options...)
+	startWebhookDispatcher(t, dispatcher)
+	return dispatcher, repo
+}
+
+func startWebhookDispatcher(t *testing.T, dispatcher *WebhookDispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func createdEventPayload(t *testing.T, eventID string) []byte {
+	payload, err := json.Marshal(events.TaskCreatedEvent{
+		TaskEvent: events.TaskEvent{EventID: eventID, TaskID: "task-1", EventType: events.EventTypeTaskCreated, Timestamp: time.Now()},
+		Title:     "Webhook task",
+		Status:    "pending",
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+func waitForDeliveries(t *testing.T, repo *repository.GormWebhookRepository, subscriptionID string, count int) []*model.WebhookDelivery {
+	var deliveries []*model.WebhookDelivery
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = repo.ListDeliveries(context.Background(), subscriptionID, 100)
+		return err == nil && len(deliveries) >= count
+	}, 2*time.Second, 10*time.Millisecond)
+	return deliveries
+}
+
+func TestWebhookDispatcher_DeliversSignedEvents(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newWebhookTestDispatcher(t)
+	subscription := model.NewWebhookSubscription(server.URL, "s3cret", nil)
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	payload := createdEventPayload(t, "event-1")
+	require.NoError(t, dispatcher.Handler(events.EventTypeTaskCreated)(ctx, payload))
+
+	request := <-received
+	body := <-bodies
+	assert.Equal(t, payload, body)
+	assert.NoError(t, VerifyWebhookSignature("s3cret", request.Header.Get(HeaderWebhookSignature), request.Header.Get(HeaderWebhookTimestamp), body, time.Minute, time.Now()))
+	assert.Equal(t, "event-1", request.Header.Get(HeaderWebhookEventID))
+	assert.Equal(t, events.EventTypeTaskCreated, request.Header.Get(HeaderWebhookEventType))
+	assert.Equal(t, subscription.ID, request.Header.Get(HeaderWebhookID))
+
+	deliveries := waitForDeliveries(t, repo, subscription.ID, 1)
+	assert.True(t, deliveries[0].Success)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].StatusCode)
+	assert.Equal(t, 1, deliveries[0].Attempt)
+}
+
+func TestWebhookDispatcher_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newWebhookTestDispatcher(t)
+	subscription := model.NewWebhookSubscription(server.URL, "s3cret", nil)
+	subscription.ConsecutiveFailures = 1
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	require.NoError(t, dispatcher.Handler(events.EventTypeTaskCreated)(ctx, createdEventPayload(t, "event-1")))
+
+	deliveries := waitForDeliveries(t, repo, subscription.ID, 3)
+	attempts := map[int]bool{}
+	for _, delivery := range deliveries {
+		attempts[delivery.Attempt] = delivery.Success
+	}
+	assert.Equal(t, map[int]bool{1: false, 2: false, 3: true}, attempts)
+
+	require.Eventually(t, func() bool {
+		stored, err := repo.GetSubscription(ctx, subscription.ID)
+		return err == nil && stored.ConsecutiveFailures == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebhookDispatcher_DisablesAfterRepeatedFailures(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newWebhookTestDispatcher(t, WithMaxConsecutiveFailures(2))
+	subscription := model.NewWebhookSubscription(server.URL, "s3cret", nil)
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+	handler := dispatcher.Handler(events.EventTypeTaskCreated)
+
+	require.NoError(t, handler(ctx, createdEventPayload(t, "event-1")))
+	waitForDeliveries(t, repo, subscription.ID, 1)
+	require.NoError(t, handler(ctx, createdEventPayload(t, "event-2")))
+
+	require.Eventually(t, func() bool {
+		stored, err := repo.GetSubscription(ctx, subscription.ID)
+		return err == nil && !stored.Active
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stored, err := repo.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.ConsecutiveFailures)
+	assert.NotNil(t, stored.DisabledAt)
+	// 410 is not retried, so each event was attempted once.
+	assert.Equal(t, int32(2), calls.Load())
+
+	require.NoError(t, handler(ctx, createdEventPayload(t, "event-3")))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWebhookDispatcher_FiltersByEventType(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dispatcher, repo := newWebhookTestDispatcher(t)
+	updatesOnly := model.NewWebhookSubscription(server.URL, "s3cret", []string{events.EventTypeTaskUpdated})
+	require.NoError(t, repo.CreateSubscription(ctx, updatesOnly))
+
+	require.NoError(t, dispatcher.Handler(events.EventTypeTaskCreated)(ctx, createdEventPayload(t, "event-1")))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), calls.Load())
+
+	deliveries, err := repo.ListDeliveries(ctx, updatesOnly.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestWebhookDispatcher_DeliversOutboxAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo, err := repository.NewGormWebhookRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	subscription := model.NewWebhookSubscription(server.URL, "s3cret", nil)
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	// The event is consumed while no dispatcher is running, as if the process stopped right after.
+	stopped := NewWebhookDispatcher(repo)
+	handler := stopped.Handler(events.EventTypeTaskCreated)
+	require.NoError(t, handler(ctx, createdEventPayload(t, "event-1")))
+	require.NoError(t, handler(ctx, createdEventPayload(t, "event-1")))
+	assert.Equal(t, int32(0), calls.Load())
+
+	startWebhookDispatcher(t, NewWebhookDispatcher(repo, WithWebhookPollInterval(5*time.Millisecond)))
+
+	deliveries := waitForDeliveries(t, repo, subscription.ID, 1)
+	assert.True(t, deliveries[0].Success)
+	time.Sleep(50 * time.Millisecond)
+	// Redelivering the same event didn't add a second pending delivery.
+	assert.Equal(t, int32(1), calls.Load())
+
+	pending, err := repo.ClaimOutboxEntries(ctx, time.Now(), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestWebhookOutbox_ClaimsEntryOnce(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewGormWebhookRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	subscription := model.NewWebhookSubscription("https://example.com/hook", "s3cret", nil)
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+
+	now := time.Now()
+	require.NoError(t, repo.EnqueueOutboxEntries(ctx, []*model.WebhookOutboxEntry{{
+		ID: "entry-1", SubscriptionID: subscription.ID, EventID: "event-1", EventType: events.EventTypeTaskCreated,
+		Payload: []byte("{}"), NextAttemptAt: now, CreatedAt: now,
+	}}))
+
+	claimed, err := repo.ClaimOutboxEntries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	again, err := repo.ClaimOutboxEntries(ctx, now.Add(time.Second), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	// Once the lease runs out, the entry is due again.
+	expired, err := repo.ClaimOutboxEntries(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	assert.Len(t, expired, 1)
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	payload := []byte(`{"event_id":"event-1"}`)
+	now := time.Unix(1_700_000_000, 0)
+	signature := SignWebhookPayload("s3cret", now.Unix(), payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.NoError(t, VerifyWebhookSignature("s3cret", signature, timestamp, payload, 5*time.Minute, now.Add(time.Minute)))
+	assert.ErrorIs(t, VerifyWebhookSignature("other", signature, timestamp, payload, 5*time.Minute, now), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("s3cret", signature, timestamp, []byte(`{}`), 5*time.Minute, now), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("s3cret", signature, "1700000001", payload, 5*time.Minute, now), ErrInvalidWebhookSignature)
+	// A captured request can't be replayed once it falls outside the tolerance.
+	assert.ErrorIs(t, VerifyWebhookSignature("s3cret", signature, timestamp, payload, 5*time.Minute, now.Add(10*time.Minute)), ErrInvalidWebhookSignature)
+}
+
+func TestWebhookHTTPClient_RefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := NewWebhookHTTPClient(time.Second, false).Post(server.URL, "application/json", nil)
+	assert.ErrorIs(t, err, ErrWebhookAddressNotAllowed)
+
+	resp, err := NewWebhookHTTPClient(time.Second, true).Post(server.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWebhookDispatcher_BacklogCheck(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewGormWebhookRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	subscription := model.NewWebhookSubscription("https://example.com/hook", "s3cret", nil)
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+	check := NewWebhookDispatcher(repo).BacklogCheck(1, time.Minute)
+
+	enqueue := func(eventID string, due time.Time) {
+		require.NoError(t, repo.EnqueueOutboxEntries(ctx, []*model.WebhookOutboxEntry{{
+			ID: eventID, SubscriptionID: subscription.ID, EventID: eventID, EventType: events.EventTypeTaskCreated,
+			Payload: []byte("{}"), NextAttemptAt: due, CreatedAt: due,
+		}}))
+	}
+
+	enqueue("recent", time.Now())
+	enqueue("overdue-1", time.Now().Add(-2*time.Minute))
+	assert.NoError(t, check(ctx), "one overdue delivery is within the limit")
+
+	enqueue("overdue-2", time.Now().Add(-3*time.Minute))
+	assert.ErrorContains(t, check(ctx), "2 webhook deliveries have been due for more than 1m0s")
+
+	// A claimed delivery is being attempted, not overdue.
+	_, err = repo.ClaimOutboxEntries(ctx, time.Now(), time.Minute, 1)
+	require.NoError(t, err)
+	assert.NoError(t, check(ctx))
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace : 100.64.0.0/10, used for carrier-grade NAT and by some cloud providers internally.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookAddressAllowed : Reports whether deliveries may be sent to addr. Loopback, private, link-local
+// (which covers cloud metadata endpoints), multicast and unspecified addresses are refused.
+func webhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// NewWebhookHTTPClient : The client used to deliver webhooks. Unless allowPrivateNetworks is set, it refuses
+// to connect to addresses webhookAddressAllowed rejects. The check runs on the address actually dialled, so it
+// also covers redirects and hosts whose DNS changes after the subscription was validated.
+func NewWebhookHTTPClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		// A proxy would make the dialled address the proxy's, hiding the real destination.
+		transport.Proxy = nil
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if !webhookAddressAllowed(addrPort.Addr()) {
+					return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, addrPort.Addr())
+				}
+				return nil
+			},
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// checkWebhookHost : Rejects hosts that are, or currently resolve to, disallowed addresses. Hosts that
+// don't resolve are accepted; the delivery client checks again when it connects.
+func checkWebhookHost(ctx context.Context, lookup func(ctx context.Context, host string) ([]netip.Addr, error), host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !webhookAddressAllowed(addr) {
+			return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+		}
+		return nil
+	}
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+	}
+
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookAddressNotAllowed, host, addr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/domain/model"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+var webhookEventTypes = map[string]bool{
+	events.EventTypeTaskCreated: true,
+	events.EventTypeTaskUpdated: true,
+	events.EventTypeTaskDeleted: true,
+}
+
+type WebhookService struct {
+	repo                 repository.WebhookRepository
+	allowPrivateNetworks bool
+	lookup               func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+type WebhookServiceOption func(*WebhookService)
+
+// WithWebhookPrivateNetworks : Accepts subscription URLs on loopback, link-local and private addresses.
+func WithWebhookPrivateNetworks() WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.allowPrivateNetworks = true
+	}
+}
+
+func NewWebhookService(repo repository.WebhookRepository, options ...WebhookServiceOption) *WebhookService {
+	s := &WebhookService{
+		repo: repo,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+type CreateWebhookInput struct {
+	URL        string   `json:"url" binding:"required"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type UpdateWebhookInput struct {
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Active     *bool     `json:"active,omitempty"`
+}
+
+// CreateSubscription : Creates a subscription and returns the signing secret, generating one when the input has none.
+// The secret is only ever returned here.
+func (s *WebhookService) CreateSubscription(ctx context.Context, input CreateWebhookInput) (*model.WebhookSubscription, string, error) {
+	if err := s.validateWebhookURL(ctx, input.URL); err != nil {
+		return nil, "", err
+	}
+	if err := validateEventTypes(input.EventTypes); err != nil {
+		return nil, "", err
+	}
+	secret := input.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, "", err
+		}
+		secret = generated
+	}
+
+	subscription := model.NewWebhookSubscription(input.URL, secret, input.EventTypes)
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, "", err
+	}
+	return subscription, secret, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	return s.repo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+// UpdateSubscription : Applies the given changes. Re-activating a subscription clears its failure count.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id string, input UpdateWebhookInput) (*model.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := s.validateWebhookURL(ctx, *input.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *input.URL
+	}
+	if input.Secret != nil {
+		if *input.Secret == "" {
+			return nil, fmt.Errorf("%w: secret must not be empty", apperrors.ErrValidation)
+		}
+		subscription.Secret = *input.Secret
+	}
+	if input.EventTypes != nil {
+		if err := validateEventTypes(*input.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = *input.EventTypes
+	}
+	if input.Active != nil && *input.Active != subscription.Active {
+		subscription.Active = *input.Active
+		if subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+		}
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries : Returns the most recent delivery attempts for a subscription, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, id string, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+	return s.repo.ListDeliveries(ctx, id, limit)
+}
+
+// validateWebhookURL : Requires an absolute http or https URL and, unless private networks are allowed, one
+// that doesn't point at an internal address.
+func (s *WebhookService) validateWebhookURL(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", apperrors.ErrValidation)
+	}
+	if s.allowPrivateNetworks {
+		return nil
+	}
+	if err := checkWebhookHost(ctx, s.lookup, parsed.Hostname()); err != nil {
+		return fmt.Errorf("%w: %w", apperrors.ErrValidation, err)
+	}
+	return nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("%w: unknown event type %q", apperrors.ErrValidation, eventType)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
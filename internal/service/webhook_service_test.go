@@ -0,0 +1,93 @@
+package service
+
+import (
+	apperrors "alle-task-manager-gunish/internal/common/errors"
+	"alle-task-manager-gunish/internal/common/events"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewGormWebhookRepository(newTestDatabase(t).Db)
+	require.NoError(t, err)
+	svc := NewWebhookService(repo)
+	svc.lookup = func(_ context.Context, host string) ([]netip.Addr, error) {
+		resolved := map[string]string{"example.com": "93.184.215.14", "internal.example.com": "10.0.0.7"}
+		if addr, ok := resolved[host]; ok {
+			return []netip.Addr{netip.MustParseAddr(addr)}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		_, _, err := svc.CreateSubscription(ctx, CreateWebhookInput{URL: "ftp://example.com/hook"})
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+
+		_, _, err = svc.CreateSubscription(ctx, CreateWebhookInput{URL: "https://example.com/hook", EventTypes: []string{"TASK_ARCHIVED"}})
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+
+	t.Run("rejects internal addresses", func(t *testing.T) {
+		for _, raw := range []string{
+			"http://127.0.0.1:8080/hook",
+			"http://localhost/hook",
+			"http://api.localhost/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/hook",
+			"http://[::ffff:10.1.2.3]/hook",
+			"https://192.168.1.10/hook",
+			"https://internal.example.com/hook",
+		} {
+			_, _, err := svc.CreateSubscription(ctx, CreateWebhookInput{URL: raw})
+			assert.ErrorIs(t, err, apperrors.ErrValidation, raw)
+			assert.ErrorIs(t, err, ErrWebhookAddressNotAllowed, raw)
+		}
+
+		// Unresolvable hosts are left to the delivery client, which checks the address it connects to.
+		_, _, err := svc.CreateSubscription(ctx, CreateWebhookInput{URL: "https://unknown.example.com/hook"})
+		assert.NoError(t, err)
+
+		private := NewWebhookService(repo, WithWebhookPrivateNetworks())
+		_, _, err = private.CreateSubscription(ctx, CreateWebhookInput{URL: "http://127.0.0.1:8080/hook"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("generates a secret and re-enables", func(t *testing.T) {
+		subscription, secret, err := svc.CreateSubscription(ctx, CreateWebhookInput{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{events.EventTypeTaskCreated},
+		})
+		require.NoError(t, err)
+		assert.Len(t, secret, 64)
+		assert.True(t, subscription.Active)
+
+		disabled, err := repo.MarkDeliveryFailed(ctx, subscription.ID, 1)
+		require.NoError(t, err)
+		assert.True(t, disabled)
+
+		active := true
+		updated, err := svc.UpdateSubscription(ctx, subscription.ID, UpdateWebhookInput{Active: &active})
+		require.NoError(t, err)
+		assert.True(t, updated.Active)
+		assert.Zero(t, updated.ConsecutiveFailures)
+		assert.Nil(t, updated.DisabledAt)
+
+		stored, err := svc.GetSubscription(ctx, subscription.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Active)
+		assert.Equal(t, secret, stored.Secret)
+		assert.Equal(t, []string{events.EventTypeTaskCreated}, stored.EventTypes)
+	})
+
+	t.Run("unknown subscription", func(t *testing.T) {
+		_, err := svc.ListDeliveries(ctx, "missing", 10)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		assert.ErrorIs(t, svc.DeleteSubscription(ctx, "missing"), apperrors.ErrNotFound)
+	})
+}
@@ -6,9 +6,13 @@ import (
 	"alle-task-manager-gunish/internal/common/database"
 	"alle-task-manager-gunish/internal/common/dependency"
 	"alle-task-manager-gunish/internal/common/logging"
+	"alle-task-manager-gunish/internal/common/tracing"
 	"context"
 	"errors"
 	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,63 +21,198 @@ import (
 )
 
 func main() {
-	logger := loggingtype.GetLogger()
-	logger.Info("Starting Task Management Service")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := newRootCommand().ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		loggingtype.GetLogger().Error("Command failed", "error", err)
+		os.Exit(1)
+	}
+}
 
-	cfg := config.LoadConfig()
+// newRootCommand : Every subcommand reads the same environment variables as the server. Without a
+// subcommand the server is started, as before subcommands existed.
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "task-manager",
+		Short:         "Task Management Service",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(*cobra.Command, []string) {
+			setupLogging(config.LoadConfig())
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return serve(cmd.Context(), config.LoadConfig())
+		},
+	}
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Run the HTTP and gRPC APIs and the event consumers",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return serve(cmd.Context(), config.LoadConfig())
+			},
+		},
+		migrateCommand(),
+		seedCommand(),
+		exportCommand(),
+		importCommand(),
+		purgeTrashCommand(),
+		rebuildProjectionsCommand(),
+		checkConfigCommand(),
+	)
+	return root
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// setupLogging : The API and admin keys are redacted wherever they appear in a log line. Invalid logging settings
+// don't stop the command; the defaults stay in place and check-config reports the problem.
+func setupLogging(cfg *config.Config) {
+	secrets := append(append([]string{}, cfg.Auth.APIKeys...), cfg.Auth.AdminAPIKeys...)
+	if err := loggingtype.Setup(cfg.Logging, secrets...); err != nil {
+		loggingtype.GetLogger().Warn("Logging configuration is invalid, using the defaults", "error", err)
+	}
+}
 
-	db, err := database.NewDatabase(ctx, cfg.Database)
+// withContainer : Sets up tracing, opens the database, builds the container and runs fn with it, closing
+// them afterwards.
+func withContainer(ctx context.Context, cfg *config.Config, fn func(c *dependency.Container) error) error {
+	tracer, err := tracing.Setup(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Error("Failed to initialize database", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("initializing tracing: %w", err)
 	}
-	defer func(db *database.Database) {
-		err := db.Close()
-		if err != nil {
-			logger.Error("Failed to close database", "error", err)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(shutdownCtx); err != nil {
+			loggingtype.GetLogger().Error("Failed to flush traces", "error", err)
 		}
-	}(db)
+	}()
 
+	db, err := database.NewDatabase(ctx, cfg.Database)
+	if err != nil {
+		return fmt.Errorf("initializing database: %w", err)
+	}
 	c, err := dependency.NewContainer(
 		dependency.WithConfig(cfg),
 		dependency.WithDatabase(db),
 	)
 	if err != nil {
-		logger.Error("Failed to initialize container", "error", err)
-		os.Exit(1)
+		_ = db.Close()
+		return fmt.Errorf("initializing container: %w", err)
 	}
 	defer c.Close()
-	r := router.SetupRouter(c.TaskHandler())
+	return fn(c)
+}
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      r,
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+// serve : Runs until ctx is cancelled by SIGINT or SIGTERM, then shuts down gracefully.
+func serve(ctx context.Context, cfg *config.Config) error {
+	logger := loggingtype.GetLogger()
+	logger.Info("Starting Task Management Service")
+	if err := cfg.Validate(); err != nil {
+		logger.Warn("Configuration has problems, run check-config for details", "error", err)
 	}
 
-	go func() {
-		logger.Info("Task Management Service is listening", "port", cfg.Server.Port)
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Error("Task Management Service failed to start", "error", err)
-			os.Exit(1)
+	return withContainer(ctx, cfg, func(c *dependency.Container) error {
+		// Background work outlives the signal so it can be stopped after the servers have drained.
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+
+		r := router.SetupRouter(
+			[]router.RouteRegistrar{c.HealthHandler(), c.DocsHandler(), c.MetricsHandler()},
+			c.Middleware(),
+			c.TaskHandler(),
+			c.AdminHandler(),
+			c.WebhookHandler(),
+			c.TaskStreamHandler(),
+			c.TaskSocketHandler(),
+			c.GraphQLHandler(),
+		)
+		if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			return fmt.Errorf("setting trusted proxies: %w", err)
+		}
+
+		server := &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+			Handler:      r,
+			ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 		}
-	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+		consumerDone := make(chan struct{})
+		go func() {
+			defer close(consumerDone)
+			logger.Info("Starting event consumer", "driver", c.EventBusDriver(), "topic", cfg.Kafka.Topic, "group_id", cfg.Kafka.GroupID)
+			if err := c.RunEventConsumers(runCtx); err != nil {
+				logger.Error("Event consumer stopped with error", "error", err)
+			}
+		}()
 
-	logger.Info("Shutting down Task Management Service")
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
+		go c.WebhookDispatcher().Run(runCtx)
+		go c.TaskEventConsumerService().RunProcessedEventCleanup(runCtx, cfg.Kafka.ProcessedEventTTL, cfg.Kafka.ProcessedEventCleanupInterval)
+		go c.IdempotencyService().RunCleanup(runCtx, cfg.Idempotency.CleanupInterval)
 
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Error("Service forced to shutdown", "error", err)
-	}
+		go func() {
+			logger.Info("Task Management Service is listening", "port", cfg.Server.Port)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Task Management Service failed to start", "error", err)
+				os.Exit(1)
+			}
+		}()
+
+		grpcServer := c.GRPCServer()
+		if cfg.GRPC.Port != 0 {
+			listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+			if err != nil {
+				return fmt.Errorf("listening for gRPC on port %d: %w", cfg.GRPC.Port, err)
+			}
+			go func() {
+				logger.Info("gRPC API is listening", "port", cfg.GRPC.Port)
+				if err := grpcServer.Serve(listener); err != nil {
+					logger.Error("gRPC API failed", "error", err)
+					os.Exit(1)
+				}
+			}()
+		}
+
+		<-ctx.Done()
 
-	logger.Info("Service exited gracefully")
+		logger.Info("Shutting down Task Management Service", "drain_delay", cfg.Health.ShutdownDelay.String())
+		c.Health().Shutdown()
+		time.Sleep(cfg.Health.ShutdownDelay)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Service forced to shutdown", "error", err)
+		}
+		stopGRPC(shutdownCtx, grpcServer)
+
+		cancel()
+		select {
+		case <-consumerDone:
+			logger.Info("Event consumer stopped")
+		case <-shutdownCtx.Done():
+			logger.Error("Timed out waiting for event consumer to stop")
+		}
+
+		logger.Info("Service exited gracefully")
+		return nil
+	})
+}
+
+// stopGRPC : Waits for in-flight RPCs until ctx is done, then cancels the rest. Watch streams only end when
+// their client goes away, so without the deadline GracefulStop could block forever.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
 }
@@ -0,0 +1,219 @@
+// Package client is a Go client for the task management REST API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithAPIKey(os.Getenv("TASKS_API_KEY")))
+//	task, err := c.CreateTask(ctx, client.CreateTaskInput{Title: "Write docs"})
+//	if errors.Is(err, client.ErrBadRequest) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy : How failed requests are retried. Only idempotent requests (GET, PUT, DELETE) are retried, after
+// network errors and 429, 502, 503 and 504 responses. The backoff doubles on each attempt up to MaxBackoff,
+// and a Retry-After header is honoured when it asks for longer.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, Backoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	userAgent  string
+	retry      RetryPolicy
+}
+
+type Option func(*Client)
+
+// WithHTTPClient : Defaults to a client with a 30s timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey : Sent in the X-API-Key header.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy : A MaxRetries of 0 disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New : baseURL is the service root, such as http://localhost:8080.
+func New(baseURL string, options ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("base URL %q must be absolute", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "alle-task-manager-go-client",
+		retry:      DefaultRetryPolicy,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// envelope : The response.Response wrapper every endpoint responds with.
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Meta *struct {
+		Pagination *PageInfo `json:"pagination"`
+	} `json:"meta"`
+}
+
+// do : Sends the request, retrying per the policy, and decodes the envelope's data into out when it is
+// non-nil. The envelope is returned for callers that need its meta.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (*envelope, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	target := c.baseURL.JoinPath(path)
+	target.RawQuery = query.Encode()
+	idempotent := method != http.MethodPost
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target.String(), payload)
+		retryable := err != nil && ctx.Err() == nil
+		if err == nil {
+			retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusBadGateway ||
+				resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout
+		}
+		if !retryable || !idempotent || attempt >= c.retry.MaxRetries {
+			if err != nil {
+				return nil, err
+			}
+			return decode(resp, out)
+		}
+
+		wait := c.backoff(attempt)
+		if resp != nil {
+			if retryAfter := retryAfter(resp); retryAfter > wait {
+				wait = retryAfter
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retry.Backoff << attempt
+	if wait <= 0 || (c.retry.MaxBackoff > 0 && wait > c.retry.MaxBackoff) {
+		wait = c.retry.MaxBackoff
+	}
+	return wait
+}
+
+// retryAfter : Only the delay-seconds form of Retry-After is supported.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func decode(resp *http.Response, out interface{}) (*envelope, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return &envelope{Success: true}, nil
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		if resp.StatusCode >= 300 {
+			return nil, &APIError{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode), Message: strings.TrimSpace(string(raw))}
+		}
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if !env.Success || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if env.Error != nil {
+			apiErr.Code, apiErr.Message = env.Error.Code, env.Error.Message
+		}
+		return nil, apiErr
+	}
+	if out != nil {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, fmt.Errorf("decoding response data: %w", err)
+		}
+	}
+	return &env, nil
+}
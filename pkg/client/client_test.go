@@ -0,0 +1,237 @@
+package client
+
+import (
+	"alle-task-manager-gunish/internal/api/handler"
+	"alle-task-manager-gunish/internal/api/middleware"
+	"alle-task-manager-gunish/internal/api/openapi"
+	"alle-task-manager-gunish/internal/api/router"
+	"alle-task-manager-gunish/internal/common/config"
+	"alle-task-manager-gunish/internal/common/database"
+	"alle-task-manager-gunish/internal/domain/repository"
+	"alle-task-manager-gunish/internal/service"
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "secret-key"
+
+// newTestAPI : The real router, handlers and services over a temporary SQLite database.
+func newTestAPI(t *testing.T) http.Handler {
+	gin.SetMode(gin.TestMode)
+	db, err := database.NewDatabase(context.Background(), config.DBConfig{
+		Driver:             "sqlite",
+		Path:               filepath.Join(t.TempDir(), "tasks.db"),
+		AutoMigrate:        true,
+		MaxIdleConnections: 1,
+		MaxOpenConnections: 1,
+		ConnMaxLifetime:    time.Hour,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := repository.NewGormTaskRepository(db.Db)
+	require.NoError(t, err)
+	broadcaster := service.NewTaskStreamBroadcaster(10, 64)
+	taskService := service.NewTaskService(repo, service.NewTaskEventService(broadcaster))
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validation, err := middleware.RequestValidation(doc)
+	require.NoError(t, err)
+	middlewares := []gin.HandlerFunc{middleware.APIKeyAuth([]string{testAPIKey}), validation}
+	return router.SetupRouter(nil, middlewares, handler.NewTaskHandler(taskService), handler.NewTaskStreamHandler(broadcaster, time.Minute))
+}
+
+func newTestClient(t *testing.T, api http.Handler, options ...Option) *Client {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, append([]Option{WithAPIKey(testAPIKey)}, options...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_Tasks(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestAPI(t))
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := c.CreateTask(ctx, CreateTaskInput{Title: "Write docs", DueDate: &due})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, created.Status)
+	require.NotNil(t, created.DueDate)
+	assert.True(t, due.Equal(*created.DueDate))
+
+	status := StatusCompleted
+	updated, err := c.UpdateTask(ctx, created.ID, UpdateTaskInput{Status: &status})
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, updated.Status)
+	assert.Equal(t, "Write docs", updated.Title)
+
+	fetched, err := c.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated.ID, fetched.ID)
+
+	page, err := c.ListTasks(ctx, ListTasksOptions{Status: StatusCompleted})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, PageInfo{Page: 1, PageSize: 10, TotalItems: 1, TotalPages: 1}, page.PageInfo)
+
+	changes, err := c.ListChanges(ctx, "", 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 1)
+	assert.Equal(t, created.ID, changes.Changes[0].Task.ID)
+
+	require.NoError(t, c.DeleteTask(ctx, created.ID))
+	changes, err = c.ListChanges(ctx, changes.NextToken, 0)
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 1)
+	assert.True(t, changes.Changes[0].Deleted)
+
+	_, err = c.GetTask(ctx, created.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "NOT_FOUND", apiErr.Code)
+
+	_, err = c.GetTaskAsOf(ctx, created.ID, time.Now())
+	assert.ErrorIs(t, err, ErrBadRequest, "event sourcing is disabled")
+	_, err = c.CreateTask(ctx, CreateTaskInput{})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = c.GetTask(ctx, "")
+	assert.Error(t, err)
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	c := newTestClient(t, newTestAPI(t), WithAPIKey("wrong"))
+	_, err := c.ListTasks(context.Background(), ListTasksOptions{})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestClient_AllTasks(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestAPI(t))
+	for i := 0; i < 25; i++ {
+		_, err := c.CreateTask(ctx, CreateTaskInput{Title: fmt.Sprintf("Task %d", i)})
+		require.NoError(t, err)
+	}
+
+	seen := map[string]bool{}
+	for task, err := range c.AllTasks(ctx, ListTasksOptions{PageSize: 10}) {
+		require.NoError(t, err)
+		seen[task.ID] = true
+	}
+	assert.Len(t, seen, 25)
+
+	count := 0
+	for range c.AllTasks(ctx, ListTasksOptions{PageSize: 10}) {
+		if count++; count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+
+	for task, err := range c.AllTasks(ctx, ListTasksOptions{Status: "unknown"}) {
+		assert.Nil(t, task)
+		assert.ErrorIs(t, err, ErrBadRequest)
+	}
+}
+
+// flaky : Fails the first failures requests with 503 before handing them to next.
+func flaky(next http.Handler, failures int32, attempts *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestClient_Retries(t *testing.T) {
+	ctx := context.Background()
+	policy := WithRetryPolicy(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	var attempts atomic.Int32
+	c := newTestClient(t, flaky(newTestAPI(t), 2, &attempts), policy)
+	_, err := c.ListTasks(ctx, ListTasksOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	attempts.Store(0)
+	c = newTestClient(t, flaky(newTestAPI(t), 3, &attempts), policy)
+	_, err = c.ListTasks(ctx, ListTasksOptions{})
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(3), attempts.Load(), "gives up after MaxRetries")
+
+	attempts.Store(0)
+	c = newTestClient(t, flaky(newTestAPI(t), 1, &attempts), policy)
+	_, err = c.CreateTask(ctx, CreateTaskInput{Title: "Not retried"})
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(1), attempts.Load(), "POST is not idempotent")
+
+	attempts.Store(0)
+	c = newTestClient(t, flaky(newTestAPI(t), 10, &attempts), WithRetryPolicy(RetryPolicy{MaxRetries: 5, Backoff: time.Hour}))
+	cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = c.ListTasks(cancelled, ListTasksOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_WatchTasks(t *testing.T) {
+	c := newTestClient(t, newTestAPI(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan *TaskEvent)
+	go func() {
+		defer close(events)
+		for event, err := range c.WatchTasks(ctx, WatchOptions{Status: StatusCompleted}) {
+			if err != nil {
+				return
+			}
+			events <- event
+		}
+	}()
+
+	// The stream subscribes asynchronously, so keep changing tasks until one gets through.
+	var event *TaskEvent
+	for event == nil {
+		task, err := c.CreateTask(ctx, CreateTaskInput{Title: "Ship it"})
+		require.NoError(t, err)
+		status := StatusCompleted
+		_, err = c.UpdateTask(ctx, task.ID, UpdateTaskInput{Status: &status})
+		require.NoError(t, err)
+		select {
+		case event = <-events:
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("no task event received")
+		}
+	}
+	assert.Equal(t, EventTaskUpdated, event.Type, "the pending task's creation is filtered out")
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, []string{"status"}, event.ChangedFields)
+	require.NotNil(t, event.Task)
+	assert.Equal(t, StatusCompleted, event.Task.Status)
+
+	resumed := c.WatchTasks(ctx, WatchOptions{LastEventID: "expired"})
+	for event, err := range resumed {
+		require.NoError(t, err)
+		assert.Equal(t, EventReset, event.Type)
+		break
+	}
+
+	unauthorized := newTestClient(t, newTestAPI(t), WithAPIKey("wrong"))
+	for _, err := range unauthorized.WatchTasks(ctx, WatchOptions{}) {
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	}
+}
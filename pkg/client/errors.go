@@ -0,0 +1,43 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors matched by errors.Is against an *APIError with the corresponding code.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServer       = errors.New("server error")
+)
+
+var errMissingID = errors.New("task ID is required")
+
+var errorCodes = map[string]error{
+	"BAD_REQUEST":           ErrBadRequest,
+	"UNAUTHORIZED":          ErrUnauthorized,
+	"NOT_FOUND":             ErrNotFound,
+	"CONFLICT":              ErrConflict,
+	"INTERNAL_SERVER_ERROR": ErrServer,
+}
+
+// APIError : The error envelope of a failed request.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("task api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	if sentinel, ok := errorCodes[e.Code]; ok {
+		return sentinel == target
+	}
+	return target == ErrServer && e.StatusCode >= 500
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	EventTaskCreated = "TASK_CREATED"
+	EventTaskUpdated = "TASK_UPDATED"
+	EventTaskDeleted = "TASK_DELETED"
+	// EventReset is sent first when LastEventID is no longer buffered by the server; reload with ListTasks.
+	EventReset = "reset"
+)
+
+type WatchOptions struct {
+	// Status only streams changes to tasks in, or leaving, this status.
+	Status TaskStatus
+	// LastEventID resumes after the event with this ID.
+	LastEventID string
+}
+
+// TaskEvent : A task change from /tasks/stream. Task is the task after the change, or as it was when deleted;
+// created events only carry its title, description and status.
+type TaskEvent struct {
+	ID            string
+	Type          string
+	TaskID        string
+	Task          *Task
+	ChangedFields []string
+	// Data is the raw event payload.
+	Data json.RawMessage
+}
+
+// WatchTasks : Streams task changes until ctx is cancelled or the server ends the stream, after which the
+// caller can resume with the last event ID it received. Errors are yielded with a nil event and end the
+// iteration. The HTTP client's timeout does not apply to the stream.
+func (c *Client) WatchTasks(ctx context.Context, options WatchOptions) iter.Seq2[*TaskEvent, error] {
+	return func(yield func(*TaskEvent, error) bool) {
+		target := c.baseURL.JoinPath("/tasks/stream")
+		if options.Status != "" {
+			target.RawQuery = url.Values{"status": {string(options.Status)}}.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("User-Agent", c.userAgent)
+		if c.apiKey != "" {
+			req.Header.Set("X-API-Key", c.apiKey)
+		}
+		if options.LastEventID != "" {
+			req.Header.Set("Last-Event-ID", options.LastEventID)
+		}
+
+		streamClient := *c.httpClient
+		streamClient.Timeout = 0
+		resp, err := streamClient.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				yield(nil, err)
+			}
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			_, err := decode(resp, nil)
+			if err == nil {
+				err = fmt.Errorf("task api: unexpected status %d for task stream", resp.StatusCode)
+			}
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		var id, eventType string
+		var data strings.Builder
+		for scanner.Scan() {
+			line := scanner.Text()
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch {
+			case line == "":
+				if data.Len() > 0 || eventType != "" {
+					if !yield(newTaskEvent(id, eventType, data.String()), nil) {
+						return
+					}
+				}
+				id, eventType = "", ""
+				data.Reset()
+			case field == "id":
+				id = value
+			case field == "event":
+				eventType = value
+			case field == "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func newTaskEvent(id, eventType, data string) *TaskEvent {
+	event := &TaskEvent{ID: id, Type: eventType, Data: json.RawMessage(data)}
+	var payload struct {
+		TaskID        string     `json:"task_id"`
+		Title         string     `json:"title"`
+		Description   string     `json:"description"`
+		Status        TaskStatus `json:"status"`
+		Timestamp     time.Time  `json:"timestamp"`
+		Task          *Task      `json:"task"`
+		ChangedFields []string   `json:"changed_fields"`
+	}
+	if json.Unmarshal(event.Data, &payload) != nil || payload.TaskID == "" {
+		return event
+	}
+
+	event.TaskID, event.ChangedFields, event.Task = payload.TaskID, payload.ChangedFields, payload.Task
+	if event.Task == nil {
+		event.Task = &Task{
+			ID:          payload.TaskID,
+			Title:       payload.Title,
+			Description: payload.Description,
+			Status:      payload.Status,
+			CreatedAt:   payload.Timestamp,
+			UpdatedAt:   payload.Timestamp,
+		}
+	}
+	return event
+}
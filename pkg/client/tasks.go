@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func (c *Client) CreateTask(ctx context.Context, input CreateTaskInput) (*Task, error) {
+	var task Task
+	if _, err := c.do(ctx, http.MethodPost, "/tasks", nil, input, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	return c.getTask(ctx, id, nil)
+}
+
+// GetTaskAsOf : The task as it was at asOf. The service must run with event sourcing enabled.
+func (c *Client) GetTaskAsOf(ctx context.Context, id string, asOf time.Time) (*Task, error) {
+	return c.getTask(ctx, id, url.Values{"as_of": {asOf.UTC().Format(time.RFC3339Nano)}})
+}
+
+func (c *Client) getTask(ctx context.Context, id string, query url.Values) (*Task, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	var task Task
+	if _, err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(id), query, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id string, input UpdateTaskInput) (*Task, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	var task Task
+	if _, err := c.do(ctx, http.MethodPut, "/tasks/"+url.PathEscape(id), nil, input, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	if id == "" {
+		return errMissingID
+	}
+	_, err := c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+func (c *Client) ListTasks(ctx context.Context, options ListTasksOptions) (*TaskPage, error) {
+	query := url.Values{}
+	if options.Status != "" {
+		query.Set("status", string(options.Status))
+	}
+	if options.Page > 0 {
+		query.Set("page", strconv.Itoa(options.Page))
+	}
+	if options.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(options.PageSize))
+	}
+
+	page := &TaskPage{}
+	env, err := c.do(ctx, http.MethodGet, "/tasks", query, nil, &page.Tasks)
+	if err != nil {
+		return nil, err
+	}
+	if env.Meta != nil && env.Meta.Pagination != nil {
+		page.PageInfo = *env.Meta.Pagination
+	}
+	return page, nil
+}
+
+// AllTasks : Iterates over every task matching the options, fetching pages as it goes from options.Page on.
+// Iteration stops after the first error, which is yielded with a nil task.
+//
+//	for task, err := range c.AllTasks(ctx, client.ListTasksOptions{Status: client.StatusPending}) {
+//		if err != nil { ... }
+//	}
+func (c *Client) AllTasks(ctx context.Context, options ListTasksOptions) iter.Seq2[*Task, error] {
+	return func(yield func(*Task, error) bool) {
+		if options.Page < 1 {
+			options.Page = 1
+		}
+		for {
+			page, err := c.ListTasks(ctx, options)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, task := range page.Tasks {
+				if !yield(task, nil) {
+					return
+				}
+			}
+			if len(page.Tasks) == 0 || page.PageInfo.Page >= page.PageInfo.TotalPages {
+				return
+			}
+			options.Page = page.PageInfo.Page + 1
+		}
+	}
+}
+
+// ListChanges : Delta sync. since is the NextToken of the previous call, or empty for a full snapshot. A limit
+// of 0 uses the server default.
+func (c *Client) ListChanges(ctx context.Context, since string, limit int) (*TaskChangeSet, error) {
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var changes TaskChangeSet
+	if _, err := c.do(ctx, http.MethodGet, "/tasks/changes", query, nil, &changes); err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import "time"
+
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "pending"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusCompleted  TaskStatus = "completed"
+)
+
+type Task struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      TaskStatus `json:"status"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateTaskInput struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+}
+
+// UpdateTaskInput : Only the fields that are set are changed.
+type UpdateTaskInput struct {
+	Title       *string     `json:"title,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Status      *TaskStatus `json:"status,omitempty"`
+	DueDate     *time.Time  `json:"due_date,omitempty"`
+}
+
+type ListTasksOptions struct {
+	// Status filters the tasks; empty lists every status.
+	Status TaskStatus
+	// Page starts at 1 and defaults to 1.
+	Page int
+	// PageSize defaults to 10, max 100.
+	PageSize int
+}
+
+type PageInfo struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	TotalItems int `json:"total_items"`
+	TotalPages int `json:"total_pages"`
+}
+
+type TaskPage struct {
+	Tasks    []*Task
+	PageInfo PageInfo
+}
+
+// TaskChange : A changed task, or a tombstone with only the ID and deletion time when Deleted is set.
+type TaskChange struct {
+	ID        string     `json:"id"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Task      *Task      `json:"task,omitempty"`
+}
+
+type TaskChangeSet struct {
+	Changes []TaskChange `json:"changes"`
+	// NextToken is passed as since on the next call to ListChanges.
+	NextToken string `json:"next_token"`
+	HasMore   bool   `json:"has_more"`
+}